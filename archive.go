@@ -0,0 +1,146 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// the supported archive formats, the format is also used as file extension
+const (
+	FORMAT_ZIP     = "zip"
+	FORMAT_TAR_GZ  = "tar.gz"
+	FORMAT_TAR_ZST = "tar.zst"
+)
+
+var ArchiveFormats = []string{FORMAT_ZIP, FORMAT_TAR_GZ, FORMAT_TAR_ZST}
+
+var archiveMagics = []struct {
+	format string
+	magic  []byte
+}{
+	{FORMAT_ZIP, []byte("PK\x03\x04")},
+	{FORMAT_ZIP, []byte("PK\x05\x06")}, // empty archive
+	{FORMAT_TAR_GZ, []byte{0x1f, 0x8b}},
+	{FORMAT_TAR_ZST, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+func IsArchiveFormat(format string) bool {
+	for _, f := range ArchiveFormats {
+		if f == format {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ArchiveContentType returns the mime type of the format.
+func ArchiveContentType(format string) string {
+	switch format {
+	case FORMAT_TAR_GZ:
+		return "application/gzip"
+	case FORMAT_TAR_ZST:
+		return "application/zstd"
+	}
+
+	return "application/zip"
+}
+
+// DetectArchiveFormat reads the first bytes of the archive to find its format.
+func DetectArchiveFormat(archive string) (string, error) {
+	fp, err := os.Open(archive)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer fp.Close()
+
+	header := make([]byte, 4)
+
+	n, err := io.ReadFull(fp, header)
+
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+
+	for _, m := range archiveMagics {
+		if bytes.HasPrefix(header[:n], m.magic) {
+			return m.format, nil
+		}
+	}
+
+	return "", fmt.Errorf("Unknown archive format: %s", archive)
+}
+
+// CreateArchive generates the archive file in the requested format.
+func CreateArchive(format string, includePaths Paths, excludes *Matcher, target string) error {
+	if format == FORMAT_ZIP {
+		return Zip(includePaths, excludes, target)
+	}
+
+	fp, err := os.Create(target)
+
+	if err != nil {
+		return err
+	}
+
+	if err := ArchiveStream(format, includePaths, excludes, fp, nil); err != nil {
+		fp.Close()
+
+		return err
+	}
+
+	return fp.Close()
+}
+
+// ArchiveStream writes the archive in the requested format into w.
+func ArchiveStream(format string, includePaths Paths, excludes *Matcher, w io.Writer, manifest *Manifest) error {
+	switch format {
+	case FORMAT_ZIP:
+		return ZipStream(includePaths, excludes, w, manifest)
+	case FORMAT_TAR_GZ, FORMAT_TAR_ZST:
+		return TarStream(includePaths, excludes, w, format, manifest)
+	}
+
+	return fmt.Errorf("Invalid archive format: %s", format)
+}
+
+// ExtractArchive extracts the archive into the target path, the format is
+// detected from the archive content.
+func ExtractArchive(archive, target string) error {
+	format, err := DetectArchiveFormat(archive)
+
+	if err != nil {
+		return err
+	}
+
+	if format == FORMAT_ZIP {
+		return Unzip(archive, target)
+	}
+
+	return Untar(archive, target, format)
+}
+
+// NewArchiveManifest computes the manifest of the archive, the format is
+// detected from the archive content.
+func NewArchiveManifest(archive string) (*Manifest, error) {
+	format, err := DetectArchiveFormat(archive)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if format == FORMAT_ZIP {
+		return NewZipManifest(archive)
+	}
+
+	return NewTarManifest(archive, format)
+}
@@ -0,0 +1,227 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf16"
+)
+
+// BuildInfo contains the values embedded into the build outputs, they are
+// computed from the ci:meta data.
+type BuildInfo struct {
+	Version     string
+	Commit      string
+	ShortCommit string
+	Ref         string
+	Tag         string
+	PipelineId  string
+	PipelineUrl string
+	JobId       string
+	Date        string
+}
+
+// NewBuildInfo creates the build information, the version defaults to the
+// tag without the v prefix, or to a development version with the short sha.
+// The pipeline creation date is used as build date so all the jobs of a
+// pipeline share the same date.
+func NewBuildInfo(meta *Meta, version string, now time.Time) *BuildInfo {
+	if len(version) == 0 && len(meta.Build.Tag) > 0 {
+		version = strings.TrimPrefix(meta.Build.Tag, "v")
+	}
+
+	if len(version) == 0 {
+		version = "0.0.0-dev"
+
+		if len(meta.Commit.ShortSha) > 0 {
+			version += "+" + meta.Commit.ShortSha
+		}
+	}
+
+	date := meta.Pipeline.CreatedAt
+	if len(date) == 0 {
+		date = now.UTC().Format(time.RFC3339)
+	}
+
+	return &BuildInfo{
+		Version:     version,
+		Commit:      meta.Commit.Sha,
+		ShortCommit: meta.Commit.ShortSha,
+		Ref:         meta.Build.RefName,
+		Tag:         meta.Build.Tag,
+		PipelineId:  meta.Pipeline.Id,
+		PipelineUrl: meta.Pipeline.Url,
+		JobId:       meta.Build.Id,
+		Date:        date,
+	}
+}
+
+// values returns the names and the values in a stable order.
+func (b *BuildInfo) values() [][2]string {
+	return [][2]string{
+		{"Version", b.Version},
+		{"Commit", b.Commit},
+		{"ShortCommit", b.ShortCommit},
+		{"Ref", b.Ref},
+		{"Tag", b.Tag},
+		{"PipelineId", b.PipelineId},
+		{"PipelineUrl", b.PipelineUrl},
+		{"JobId", b.JobId},
+		{"Date", b.Date},
+	}
+}
+
+var goTemplate = template.Must(template.New("go").Parse(`// Code generated by gitlab-ci-helper ci:buildinfo. DO NOT EDIT.
+
+package {{ .Package }}
+
+// the build information, generated by the GitLab pipeline
+var (
+{{- range .Values }}
+	{{ index . 0 }} = {{ printf "%q" (index . 1) }}
+{{- end }}
+)
+`))
+
+// Go generates a Go file declaring one variable per value, the file can be
+// committed with empty values for the local builds.
+func (b *BuildInfo) Go(pkg string) ([]byte, error) {
+	buf := bytes.NewBuffer([]byte(""))
+
+	err := goTemplate.Execute(buf, map[string]interface{}{
+		"Package": pkg,
+		"Values":  b.values(),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// Properties generates a Java properties file, the keys are prefixed, ie:
+// build.version, build.shortCommit.
+func (b *BuildInfo) Properties(prefix string) []byte {
+	buf := bytes.NewBuffer([]byte(""))
+
+	for _, v := range b.values() {
+		key := prefix + strings.ToLower(v[0][:1]) + v[0][1:]
+
+		fmt.Fprintf(buf, "%s=%s\n", escapeProperty(key, true), escapeProperty(v[1], false))
+	}
+
+	return buf.Bytes()
+}
+
+// escapeProperty escapes the special characters of the properties format,
+// the non ascii characters are encoded as the file is read as ISO-8859-1.
+func escapeProperty(value string, key bool) string {
+	buf := bytes.NewBuffer([]byte(""))
+
+	for i, r := range value {
+		switch {
+		case r == '\\', r == '=', r == ':', r == '#', r == '!':
+			buf.WriteRune('\\')
+			buf.WriteRune(r)
+		case r == ' ' && (key || i == 0):
+			buf.WriteString("\\ ")
+		case r == '\n':
+			buf.WriteString("\\n")
+		case r == '\r':
+			buf.WriteString("\\r")
+		case r == '\t':
+			buf.WriteString("\\t")
+		case r < 0x20 || r > 0x7e:
+			for _, c := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(buf, "\\u%04x", c)
+			}
+		default:
+			buf.WriteRune(r)
+		}
+	}
+
+	return buf.String()
+}
+
+// StampPackageJson replaces the version of the package.json file, the rest
+// of the file is kept as is.
+func (b *BuildInfo) StampPackageJson(data []byte) ([]byte, error) {
+	fields := map[string]json.RawMessage{}
+
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("Invalid package.json file, err: %s", err)
+	}
+
+	if _, ok := fields["version"]; !ok {
+		return nil, errors.New("The package.json file has no version field")
+	}
+
+	current := ""
+
+	if err := json.Unmarshal(fields["version"], &current); err != nil {
+		return nil, errors.New("The version of the package.json file must be a string")
+	}
+
+	start, end := jsonObjectValue(data, "version")
+	version, _ := json.Marshal(b.Version)
+
+	stamped := append([]byte{}, data[:start]...)
+	stamped = append(stamped, version...)
+
+	return append(stamped, data[end:]...), nil
+}
+
+// jsonObjectValue returns the offsets of the string value of a top level
+// key, the last one if the key is repeated like the json decoder. The data
+// must be a valid json object.
+func jsonObjectValue(data []byte, name string) (int, int) {
+	start, end := -1, -1
+	depth, key, current := 0, false, ""
+
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case '{', '[':
+			depth++
+			key = depth == 1
+		case '}', ']':
+			depth--
+		case ':':
+			if depth == 1 {
+				key = false
+			}
+		case ',':
+			if depth == 1 {
+				key = true
+			}
+		case '"':
+			j := i + 1
+			for data[j] != '"' {
+				if data[j] == '\\' {
+					j++
+				}
+				j++
+			}
+
+			if depth == 1 && key {
+				json.Unmarshal(data[i:j+1], &current)
+			} else if depth == 1 && current == name {
+				start, end = i, j+1
+			}
+
+			i = j
+		}
+	}
+
+	return start, end
+}
@@ -0,0 +1,108 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMeta() *Meta {
+	return &Meta{
+		Build:    &MetaBuild{Id: "69", RefName: "master"},
+		Pipeline: &MetaPipeline{Id: "12", CreatedAt: "2017-08-01T10:00:00Z"},
+		Commit:   &MetaCommit{Sha: "4f0ac8c2a1", ShortSha: "4f0ac8c2"},
+	}
+}
+
+func Test_NewBuildInfo(t *testing.T) {
+	now := time.Date(2017, 8, 2, 10, 0, 0, 0, time.UTC)
+
+	m := newTestMeta()
+
+	info := NewBuildInfo(m, "", now)
+	assert.Equal(t, "0.0.0-dev+4f0ac8c2", info.Version)
+	assert.Equal(t, "2017-08-01T10:00:00Z", info.Date)
+
+	m.Build.Tag = "v1.2.0"
+	m.Pipeline.CreatedAt = ""
+
+	info = NewBuildInfo(m, "", now)
+	assert.Equal(t, "1.2.0", info.Version)
+	assert.Equal(t, "2017-08-02T10:00:00Z", info.Date)
+
+	assert.Equal(t, "2.0.0-rc.1", NewBuildInfo(m, "2.0.0-rc.1", now).Version)
+}
+
+func Test_BuildInfo_Go(t *testing.T) {
+	info := NewBuildInfo(newTestMeta(), "1.2.0", time.Now())
+
+	data, err := info.Go("buildinfo")
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "// Code generated by gitlab-ci-helper ci:buildinfo. DO NOT EDIT.\n\npackage buildinfo\n")
+	assert.Contains(t, string(data), "\tVersion     = \"1.2.0\"\n")
+	assert.Contains(t, string(data), "\tShortCommit = \"4f0ac8c2\"\n")
+
+	_, err = info.Go("invalid-package")
+	assert.Error(t, err)
+}
+
+func Test_BuildInfo_Properties(t *testing.T) {
+	info := &BuildInfo{Version: "1.2.0", Ref: "feature/ümlaut", PipelineUrl: "http://example.com:8080/pipelines/12"}
+
+	data := string(info.Properties("build."))
+
+	assert.Contains(t, data, "build.version=1.2.0\n")
+	assert.Contains(t, data, "build.ref=feature/\\u00fcmlaut\n")
+	assert.Contains(t, data, "build.pipelineUrl=http\\://example.com\\:8080/pipelines/12\n")
+	assert.Contains(t, data, "build.shortCommit=\n")
+
+	assert.Equal(t, "\\ a\\=b c\\n", escapeProperty(" a=b c\n", false))
+	assert.Equal(t, "my\\ key", escapeProperty("my key", true))
+}
+
+func Test_BuildInfo_StampPackageJson(t *testing.T) {
+	info := &BuildInfo{Version: "1.2.0"}
+
+	data := `{
+  "name": "app",
+  "engines": {"version": "keep"},
+  "files": ["version", {"version": "keep"}],
+  "version" :  "0.0.1",
+  "scripts": {}
+}
+`
+
+	stamped, err := info.StampPackageJson([]byte(data))
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{
+  "name": "app",
+  "engines": {"version": "keep"},
+  "files": ["version", {"version": "keep"}],
+  "version" :  "1.2.0",
+  "scripts": {}
+}
+`, string(stamped))
+
+	// the strings can contain the delimiters, the keys can be escaped
+	stamped, err = info.StampPackageJson([]byte(`{"description": "a \"version\": {[", "\u0076ersion": "0.0.1"}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"description": "a \"version\": {[", "\u0076ersion": "1.2.0"}`, string(stamped))
+
+	_, err = info.StampPackageJson([]byte(`{"name": "app", "engines": {"version": "keep"}}`))
+	assert.EqualError(t, err, "The package.json file has no version field")
+
+	_, err = info.StampPackageJson([]byte(`{"version": 1}`))
+	assert.Error(t, err)
+
+	_, err = info.StampPackageJson([]byte(`{"version": `))
+	assert.Error(t, err)
+}
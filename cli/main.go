@@ -12,6 +12,10 @@ import (
 	"github.com/rande/gitlab-ci-helper/commands"
 	"github.com/rande/gitlab-ci-helper/integrations/flowdock"
 	"github.com/rande/gitlab-ci-helper/integrations/hipchat"
+	"github.com/rande/gitlab-ci-helper/integrations/mail"
+	"github.com/rande/gitlab-ci-helper/integrations/mattermost"
+	"github.com/rande/gitlab-ci-helper/integrations/slack"
+	"github.com/rande/gitlab-ci-helper/integrations/webhook"
 )
 
 var (
@@ -41,6 +45,26 @@ func main() {
 				Ui: ui,
 			}, nil
 		},
+		"pipeline:status": func() (cli.Command, error) {
+			return &commands.PipelineStatusCommand{
+				Ui: ui,
+			}, nil
+		},
+		"pipeline:trigger": func() (cli.Command, error) {
+			return &commands.PipelineTriggerCommand{
+				Ui: ui,
+			}, nil
+		},
+		"pipeline:wait": func() (cli.Command, error) {
+			return &commands.PipelineWaitCommand{
+				Ui: ui,
+			}, nil
+		},
+		"ci:buildinfo": func() (cli.Command, error) {
+			return &commands.CiBuildInfoCommand{
+				Ui: ui,
+			}, nil
+		},
 		"ci:meta": func() (cli.Command, error) {
 			return &commands.CiDumpMetaCommand{
 				Ui: ui,
@@ -66,6 +90,41 @@ func main() {
 				Ui: ui,
 			}, nil
 		},
+		"config:show": func() (cli.Command, error) {
+			return &commands.ConfigShowCommand{
+				Ui: ui,
+			}, nil
+		},
+		"mail:status": func() (cli.Command, error) {
+			return &mail.MailStatusCommand{
+				Ui: ui,
+			}, nil
+		},
+		"slack:message": func() (cli.Command, error) {
+			return &slack.SlackMessageCommand{
+				Ui: ui,
+			}, nil
+		},
+		"slack:status": func() (cli.Command, error) {
+			return &slack.SlackStatusCommand{
+				Ui: ui,
+			}, nil
+		},
+		"mattermost:message": func() (cli.Command, error) {
+			return &mattermost.MattermostMessageCommand{
+				Ui: ui,
+			}, nil
+		},
+		"mattermost:status": func() (cli.Command, error) {
+			return &mattermost.MattermostStatusCommand{
+				Ui: ui,
+			}, nil
+		},
+		"webhook:send": func() (cli.Command, error) {
+			return &webhook.WebhookSendCommand{
+				Ui: ui,
+			}, nil
+		},
 		"dump:readme": func() (cli.Command, error) {
 			return &commands.DumpReadmeCommand{
 				Ui:       ui,
@@ -79,6 +138,16 @@ func main() {
 				RefLog:  RefLog,
 			}, nil
 		},
+		"version:next": func() (cli.Command, error) {
+			return &commands.VersionNextCommand{
+				Ui: ui,
+			}, nil
+		},
+		"release:create": func() (cli.Command, error) {
+			return &commands.ReleaseCreateCommand{
+				Ui: ui,
+			}, nil
+		},
 		"s3:archive": func() (cli.Command, error) {
 			return &commands.S3ArchiveCommand{
 				Ui: ui,
@@ -89,6 +158,26 @@ func main() {
 				Ui: ui,
 			}, nil
 		},
+		"s3:list": func() (cli.Command, error) {
+			return &commands.S3ListCommand{
+				Ui: ui,
+			}, nil
+		},
+		"s3:prune": func() (cli.Command, error) {
+			return &commands.S3PruneCommand{
+				Ui: ui,
+			}, nil
+		},
+		"storage:archive": func() (cli.Command, error) {
+			return &commands.StorageArchiveCommand{
+				Ui: ui,
+			}, nil
+		},
+		"storage:extract": func() (cli.Command, error) {
+			return &commands.StorageExtractCommand{
+				Ui: ui,
+			}, nil
+		},
 	}
 
 	exitStatus, _ := c.Run()
@@ -0,0 +1,392 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/rande/gitlab-ci-helper/storage"
+)
+
+// Archiver contains the logic shared by the archive and extract commands,
+// whatever the storage backend is.
+type Archiver struct {
+	Ui         cli.Ui
+	Storage    storage.Storage
+	Location   string
+	Project    *gitlab.Project
+	Ref        string
+	RefName    string
+	Job        string
+	TagMatcher *regexp.Regexp
+
+	// the archive format, detected on extraction if empty
+	Format string
+
+	// write the archive directly into the storage
+	Stream bool
+
+	// verify the archive checksum before the extraction
+	Verify bool
+
+	// the prefix of the report variables, ie: S3_EXTRACT_
+	ReportPrefix string
+}
+
+// NewExcludeMatcher builds the exclude rules from the command options, the
+// rules file is optional if it is the default one.
+func NewExcludeMatcher(mode string, excludes helper.Paths, excludeFrom string, ignoreCVS bool) (*helper.Matcher, error) {
+	matcher, err := helper.NewMatcher(mode)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ignoreCVS {
+		for _, path := range []string{".git/", ".svn/", ".hg/", ".bzr/"} {
+			matcher.Add(path)
+		}
+	}
+
+	if len(excludeFrom) > 0 {
+		if _, err := os.Stat(excludeFrom); err == nil || excludeFrom != helper.IGNORE_FILE {
+			if err := matcher.AddFile(excludeFrom); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, path := range excludes {
+		if err := matcher.Add(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return matcher, nil
+}
+
+// exit code returned when the archive does not match its checksum
+const EXIT_CHECKSUM_MISMATCH = 2
+
+func (a *Archiver) Lookup() *ArchiveLookup {
+	return &ArchiveLookup{
+		Storage:       a.Storage,
+		Namespace:     a.Project.Namespace.Path,
+		Project:       a.Project.Path,
+		Job:           a.Job,
+		TagMatcher:    a.TagMatcher,
+		Format:        a.Format,
+		MaxCandidates: 50,
+	}
+}
+
+// ResumeId identifies the uploads of a job, a retried job keeps the pipeline
+// id and the job name.
+func ResumeId(job string) string {
+	return fmt.Sprintf("%s-%s", os.Getenv("CI_PIPELINE_ID"), job)
+}
+
+func (a *Archiver) format() string {
+	if len(a.Format) > 0 {
+		return a.Format
+	}
+
+	return helper.FORMAT_ZIP
+}
+
+func (a *Archiver) Archive(includePaths helper.Paths, excludes *helper.Matcher) int {
+	format := a.format()
+	key := a.Lookup().Key(a.Ref, a.RefName)
+
+	object := &storage.Object{
+		ContentType: helper.ArchiveContentType(format),
+		Metadata: map[string]string{
+			META_REF:      a.Ref,
+			META_REF_NAME: a.RefName,
+			META_JOB:      a.Job,
+		},
+	}
+
+	if a.Stream {
+		return a.archiveStream(key, object, includePaths, excludes)
+	}
+
+	archiveTarget := fmt.Sprintf("%s/%s_%s.%s", os.TempDir(), a.Ref, a.Job, format)
+
+	a.Ui.Output(fmt.Sprintf("Generate %s file: %s", format, archiveTarget))
+
+	if err := helper.CreateArchive(format, includePaths, excludes, archiveTarget); err != nil {
+		a.Ui.Output(fmt.Sprintf("Unable to generate %s file: %s", format, err))
+
+		return 1
+	}
+
+	defer os.Remove(archiveTarget)
+
+	manifest, err := helper.NewArchiveManifest(archiveTarget)
+
+	if err != nil {
+		a.Ui.Output(fmt.Sprintf("Unable to compute the checksum: %s, %s", archiveTarget, err))
+
+		return 1
+	}
+
+	object.Metadata[META_SHA256] = manifest.Sha256
+
+	a.Ui.Output(fmt.Sprintf("Copy %s file: %s/%s", format, a.Location, key))
+
+	// the storage retries the failed parts
+	if err := a.put(key, archiveTarget, object); err != nil {
+		a.Ui.Output(fmt.Sprintf("Unable to copy %s file: %s, %s", format, archiveTarget, err))
+
+		return 1
+	}
+
+	return a.putManifest(key, manifest)
+}
+
+// archiveStream writes the zip directly into the storage, the archive is
+// never stored on the local filesystem. As the checksum is only known once
+// the upload is completed, it is only available in the manifest.
+func (a *Archiver) archiveStream(key string, object *storage.Object, includePaths helper.Paths, excludes *helper.Matcher) int {
+	pr, pw := io.Pipe()
+
+	manifest := &helper.Manifest{Files: []*helper.ManifestFile{}}
+	checksum := helper.NewChecksumWriter()
+
+	go func() {
+		pw.CloseWithError(helper.ArchiveStream(a.format(), includePaths, excludes, io.MultiWriter(pw, checksum), manifest))
+	}()
+
+	a.Ui.Output(fmt.Sprintf("Stream %s file: %s/%s", a.format(), a.Location, key))
+
+	err := a.Storage.Put(key, pr, object)
+
+	// unblock the zip writer if the upload has failed
+	pr.CloseWithError(err)
+
+	if err != nil {
+		a.Ui.Output(fmt.Sprintf("Unable to stream %s file: %s/%s, %s", a.format(), a.Location, key, err))
+
+		return 1
+	}
+
+	manifest.Sha256 = checksum.Sum()
+	manifest.Size = checksum.Size
+
+	return a.putManifest(key, manifest)
+}
+
+func (a *Archiver) putManifest(key string, manifest *helper.Manifest) int {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+
+	if err != nil {
+		a.Ui.Output(fmt.Sprintf("Unable to generate the manifest, %s", err))
+
+		return 1
+	}
+
+	a.Ui.Output(fmt.Sprintf("Copy manifest file: %s/%s%s (sha256: %s)", a.Location, key, MANIFEST_SUFFIX, manifest.Sha256))
+
+	err = a.Storage.Put(key+MANIFEST_SUFFIX, bytes.NewReader(data), &storage.Object{
+		ContentType: "application/json",
+	})
+
+	if err != nil {
+		a.Ui.Output(fmt.Sprintf("Unable to copy manifest file: %s/%s%s, %s", a.Location, key, MANIFEST_SUFFIX, err))
+
+		return 1
+	}
+
+	return 0
+}
+
+func (a *Archiver) put(key, file string, object *storage.Object) error {
+	fp, err := os.Open(file)
+
+	if err != nil {
+		return err
+	}
+
+	defer fp.Close()
+
+	return a.Storage.Put(key, fp, object)
+}
+
+// Extract searches the archive with the lookup strategies and extracts it.
+// The key and the strategy used are written in the report file if provided.
+func (a *Archiver) Extract(strategies []string, extractPath, reportFile string) int {
+	result, err := a.Lookup().Find(strategies, a.Ref, a.RefName, a.Project.DefaultBranch)
+
+	if err != nil {
+		a.Ui.Output(fmt.Sprintf("Unable to search archive in %s, %s", a.Location, err))
+
+		return 1
+	}
+
+	if result == nil {
+		a.Ui.Output(fmt.Sprintf("Unable to find an archive in %s (strategy: %v)", a.Location, strategies))
+
+		return 1
+	}
+
+	key := result.Key
+
+	a.Ui.Output(fmt.Sprintf("Found archive %s/%s (strategy: %s)", a.Location, key, result.Strategy))
+
+	if _, ok := a.Storage.(*storage.EncryptedStorage); !ok {
+		object, err := a.Storage.Stat(key)
+
+		if err != nil {
+			a.Ui.Output(fmt.Sprintf("Unable to retrieve the archive metadata: %s/%s, %s", a.Location, key, err))
+
+			return 1
+		}
+
+		if len(object.Meta(storage.META_ENCRYPTION)) > 0 {
+			a.Ui.Error(fmt.Sprintf("Error: the archive %s/%s is encrypted, the encryption key is required", a.Location, key))
+
+			return 1
+		}
+	}
+
+	if len(reportFile) > 0 {
+		report := fmt.Sprintf("%sKEY=%s\n%sSTRATEGY=%s\n", a.ReportPrefix, key, a.ReportPrefix, result.Strategy)
+
+		if err := ioutil.WriteFile(reportFile, []byte(report), 0644); err != nil {
+			a.Ui.Output(fmt.Sprintf("Unable to write the report file: %s, %s", reportFile, err))
+
+			return 1
+		}
+	}
+
+	archiveTarget := fmt.Sprintf("%s/%s_%s.archive", os.TempDir(), a.Ref, a.Job)
+
+	a.Ui.Output(fmt.Sprintf("Retrieve archive from %s/%s", a.Location, key))
+
+	if err := a.get(key, archiveTarget); err != nil {
+		a.Ui.Output(fmt.Sprintf("Unable to retrieve archive: %s, %s", archiveTarget, err))
+
+		return 1
+	}
+
+	defer os.Remove(archiveTarget)
+
+	if a.Verify {
+		if code := a.verify(key, archiveTarget); code != 0 {
+			return code
+		}
+	}
+
+	a.Ui.Output(fmt.Sprintf("Extract archive to %s", extractPath))
+
+	// the format is detected from the content, so the archive can be
+	// extracted whatever the key extension is
+	if err := helper.ExtractArchive(archiveTarget, extractPath); err != nil {
+		a.Ui.Output(fmt.Sprintf("Unable to extract archive: %s, %s", archiveTarget, err))
+
+		return 1
+	}
+
+	return 0
+}
+
+func (a *Archiver) get(key, file string) error {
+	r, err := a.Storage.Get(key)
+
+	if err != nil {
+		return err
+	}
+
+	defer r.Close()
+
+	fp, err := os.Create(file)
+
+	if err != nil {
+		return err
+	}
+
+	defer fp.Close()
+
+	_, err = io.Copy(fp, r)
+
+	return err
+}
+
+// verify compares the downloaded archive with the manifest, or with the
+// checksum stored in the metadata if the archive has no manifest.
+func (a *Archiver) verify(key, file string) int {
+	expected, err := a.expectedManifest(key)
+
+	if err != nil {
+		a.Ui.Output(fmt.Sprintf("Unable to retrieve the checksum of %s/%s, %s", a.Location, key, err))
+
+		return 1
+	}
+
+	if expected == nil {
+		a.Ui.Error(fmt.Sprintf("Error: no checksum available for %s/%s, use -verify=false to extract it", a.Location, key))
+
+		return 1
+	}
+
+	actual, err := helper.NewArchiveManifest(file)
+
+	if err == nil {
+		err = actual.Verify(expected)
+	}
+
+	if err != nil {
+		a.Ui.Error(fmt.Sprintf("Error: the archive %s/%s is corrupted, %s", a.Location, key, err))
+
+		return EXIT_CHECKSUM_MISMATCH
+	}
+
+	a.Ui.Output(fmt.Sprintf("Checksum verified (sha256: %s)", actual.Sha256))
+
+	return 0
+}
+
+func (a *Archiver) expectedManifest(key string) (*helper.Manifest, error) {
+	r, err := a.Storage.Get(key + MANIFEST_SUFFIX)
+
+	if err == nil {
+		defer r.Close()
+
+		manifest := &helper.Manifest{}
+
+		if err := json.NewDecoder(r).Decode(manifest); err != nil {
+			return nil, fmt.Errorf("invalid manifest, %s", err)
+		}
+
+		return manifest, nil
+	}
+
+	if err != storage.ErrNotFound {
+		return nil, err
+	}
+
+	object, err := a.Storage.Stat(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if sum := object.Meta(META_SHA256); len(sum) > 0 {
+		return &helper.Manifest{Sha256: sum}, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,173 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rande/gitlab-ci-helper/storage"
+)
+
+type ArchiveListItem struct {
+	Key          string            `json:"key"`
+	Job          string            `json:"job"`
+	Format       string            `json:"format"`
+	Size         int64             `json:"size"`
+	LastModified time.Time         `json:"last_modified"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// ArchiveListGroup contains the archives stored for a commit or a release.
+type ArchiveListGroup struct {
+	Kind     string             `json:"kind"`
+	Ref      string             `json:"ref"`
+	Archives []*ArchiveListItem `json:"archives"`
+}
+
+func (g *ArchiveListGroup) LastModified() time.Time {
+	last := time.Time{}
+
+	for _, a := range g.Archives {
+		if a.LastModified.After(last) {
+			last = a.LastModified
+		}
+	}
+
+	return last
+}
+
+// ListArchives returns the project's archives grouped by commit or release,
+// the most recent group first. The metadata requires one request per archive.
+func ListArchives(s storage.Storage, namespace, project string, kinds []string, job string, metadata bool) ([]*ArchiveListGroup, error) {
+	groups := []*ArchiveListGroup{}
+
+	for _, kind := range kinds {
+		objects, err := s.List(fmt.Sprintf("%s/%s/%s/", kind, namespace, project))
+
+		if err != nil {
+			return nil, err
+		}
+
+		refs := map[string]*ArchiveListGroup{}
+		kindGroups := []*ArchiveListGroup{}
+
+		for _, o := range objects {
+			archive, ok := ParseArchiveKey(o.Key)
+
+			if !ok || (len(job) > 0 && archive.Job != job) {
+				continue
+			}
+
+			item := &ArchiveListItem{
+				Key:          o.Key,
+				Job:          archive.Job,
+				Format:       archive.Format,
+				Size:         o.Size,
+				LastModified: o.LastModified,
+			}
+
+			if metadata {
+				object, err := s.Stat(o.Key)
+
+				if err != nil {
+					return nil, err
+				}
+
+				item.Metadata = object.Metadata
+			}
+
+			group, ok := refs[archive.Ref]
+
+			if !ok {
+				group = &ArchiveListGroup{Kind: kind, Ref: archive.Ref, Archives: []*ArchiveListItem{}}
+				refs[archive.Ref] = group
+				kindGroups = append(kindGroups, group)
+			}
+
+			group.Archives = append(group.Archives, item)
+		}
+
+		for _, g := range kindGroups {
+			sort.Slice(g.Archives, func(i, j int) bool {
+				return g.Archives[i].Job < g.Archives[j].Job
+			})
+		}
+
+		sort.SliceStable(kindGroups, func(i, j int) bool {
+			return kindGroups[i].LastModified().After(kindGroups[j].LastModified())
+		})
+
+		groups = append(groups, kindGroups...)
+	}
+
+	return groups, nil
+}
+
+// FormatArchiveList renders the groups as a table or as json.
+func FormatArchiveList(groups []*ArchiveListGroup, output string) (string, error) {
+	switch output {
+	case "json":
+		data, err := json.MarshalIndent(groups, "", "  ")
+
+		return string(data), err
+
+	case "table":
+		buf := bytes.NewBuffer([]byte(""))
+		w := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+
+		fmt.Fprintln(w, "KIND\tREF\tJOB\tFORMAT\tSIZE\tDATE\tMETADATA")
+
+		for _, g := range groups {
+			for _, a := range g.Archives {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", g.Kind, g.Ref, a.Job, a.Format, formatSize(a.Size), a.LastModified.Format("2006-01-02 15:04:05"), formatMetadata(a.Metadata))
+			}
+		}
+
+		w.Flush()
+
+		return strings.TrimRight(buf.String(), "\n"), nil
+	}
+
+	return "", fmt.Errorf("Invalid output format: %s", output)
+}
+
+func formatSize(size int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	value := float64(size)
+
+	i := 0
+	for ; value >= 1024 && i < len(units)-1; i++ {
+		value = value / 1024
+	}
+
+	if i == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	return fmt.Sprintf("%.1f %s", value, units[i])
+}
+
+func formatMetadata(metadata map[string]string) string {
+	keys := []string{}
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	pairs := []string{}
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, metadata[k]))
+	}
+
+	return strings.Join(pairs, " ")
+}
@@ -0,0 +1,88 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ListArchives(t *testing.T) {
+	pruner, clean := newTestArchivePruner(t)
+	defer clean()
+
+	groups, err := ListArchives(pruner.Storage, "rande", "project", []string{"releases", "commits"}, "", true)
+	assert.NoError(t, err)
+
+	refs := []string{}
+	for _, g := range groups {
+		refs = append(refs, g.Kind+":"+g.Ref)
+	}
+
+	// releases first, then the commits, the most recent first
+	assert.Equal(t, []string{"releases:v1.0.0", "commits:sha4", "commits:sha3", "commits:sha2", "commits:sha1", "commits:sha5"}, refs)
+
+	sha1 := groups[4]
+	assert.Len(t, sha1.Archives, 2)
+	assert.Equal(t, "build", sha1.Archives[0].Job)
+	assert.Equal(t, "test_unit", sha1.Archives[1].Job)
+	assert.Equal(t, "master", sha1.Archives[0].Metadata[META_REF_NAME])
+	assert.Equal(t, int64(7), sha1.Archives[0].Size)
+
+	groups, err = ListArchives(pruner.Storage, "rande", "project", []string{"commits"}, "test_unit", false)
+	assert.NoError(t, err)
+	assert.Len(t, groups, 2)
+	assert.Nil(t, groups[0].Archives[0].Metadata)
+}
+
+func Test_FormatArchiveList(t *testing.T) {
+	pruner, clean := newTestArchivePruner(t)
+	defer clean()
+
+	groups, err := ListArchives(pruner.Storage, "rande", "project", []string{"releases"}, "", true)
+	assert.NoError(t, err)
+
+	table, err := FormatArchiveList(groups, "table")
+	assert.NoError(t, err)
+
+	lines := strings.Split(table, "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "KIND      REF     JOB    FORMAT  SIZE  DATE"))
+	assert.Contains(t, lines[1], "releases  v1.0.0  build  zip     7 B")
+	assert.Contains(t, lines[1], "Ref-Name=v1.0.0")
+
+	data, err := FormatArchiveList(groups, "json")
+	assert.NoError(t, err)
+
+	decoded := []*ArchiveListGroup{}
+	assert.NoError(t, json.Unmarshal([]byte(data), &decoded))
+	assert.Equal(t, "releases/rande/project/v1.0.0_build.zip", decoded[0].Archives[0].Key)
+
+	_, err = FormatArchiveList(groups, "xml")
+	assert.Error(t, err)
+}
+
+func Test_ListArchivesOutput(t *testing.T) {
+	pruner, clean := newTestArchivePruner(t)
+	defer clean()
+
+	project := &gitlab.Project{Path: "project", Namespace: &gitlab.Namespace{Path: "rande"}}
+
+	ui := &cli.MockUi{}
+	assert.Equal(t, 0, ListArchivesOutput(ui, pruner.Storage, project, []string{"releases"}, "", false, "json"))
+	assert.True(t, strings.HasPrefix(ui.OutputWriter.String(), "[\n"))
+}
+
+func Test_FormatSize(t *testing.T) {
+	assert.Equal(t, "512 B", formatSize(512))
+	assert.Equal(t, "1.5 KB", formatSize(1536))
+	assert.Equal(t, "16.0 MB", formatSize(16*1024*1024))
+}
@@ -0,0 +1,278 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/storage"
+)
+
+const (
+	LOOKUP_EXACT          = "exact"
+	LOOKUP_BRANCH         = "branch"
+	LOOKUP_DEFAULT_BRANCH = "default"
+	LOOKUP_RELEASE        = "release"
+)
+
+// metadata attached to each archive by the archive commands
+const (
+	META_REF      = "Ref"
+	META_REF_NAME = "Ref-Name"
+	META_JOB      = "Job"
+	META_SHA256   = "Sha256"
+)
+
+// the manifest is stored next to the archive: <key>.manifest.json
+const MANIFEST_SUFFIX = ".manifest.json"
+
+// ArchiveKey contains the information stored in an archive key:
+// commits/<namespace>/<project>/<sha1>_<job>.<format> or
+// releases/<namespace>/<project>/<tag>_<job>.<format>
+type ArchiveKey struct {
+	Key       string
+	Kind      string
+	Namespace string
+	Project   string
+	Ref       string
+	Job       string
+	Format    string
+}
+
+// ParseArchiveKey returns false if the key is not an archive, ie: a manifest.
+// The ref must not contain an underscore, as the job name can contain one.
+func ParseArchiveKey(key string) (*ArchiveKey, bool) {
+	parts := strings.Split(key, "/")
+
+	if len(parts) != 4 || (parts[0] != "commits" && parts[0] != "releases") {
+		return nil, false
+	}
+
+	for _, format := range helper.ArchiveFormats {
+		if !strings.HasSuffix(parts[3], "."+format) {
+			continue
+		}
+
+		name := strings.TrimSuffix(parts[3], "."+format)
+		pos := strings.Index(name, "_")
+
+		if pos < 1 || pos == len(name)-1 {
+			return nil, false
+		}
+
+		return &ArchiveKey{
+			Key:       key,
+			Kind:      parts[0],
+			Namespace: parts[1],
+			Project:   parts[2],
+			Ref:       name[:pos],
+			Job:       name[pos+1:],
+			Format:    format,
+		}, true
+	}
+
+	return nil, false
+}
+
+type ArchiveLookupResult struct {
+	Key      string
+	Strategy string
+}
+
+// ArchiveLookup searches the best archive available for a job, following the
+// commits/ and releases/ layout used by the archive commands.
+type ArchiveLookup struct {
+	Storage    storage.Storage
+	Namespace  string
+	Project    string
+	Job        string
+	TagMatcher *regexp.Regexp
+
+	// the archive format, all formats are searched if empty
+	Format string
+
+	// the maximum number of archives to inspect when searching by branch
+	MaxCandidates int
+
+	listings map[string][]*storage.Object
+}
+
+func (l *ArchiveLookup) CommitKey(ref, format string) string {
+	return fmt.Sprintf("commits/%s/%s/%s_%s.%s", l.Namespace, l.Project, ref, l.Job, format)
+}
+
+func (l *ArchiveLookup) ReleaseKey(refName, format string) string {
+	return fmt.Sprintf("releases/%s/%s/%s_%s.%s", l.Namespace, l.Project, refName, l.Job, format)
+}
+
+// Key returns the archive key related to the ref, if the ref name matches the
+// tag matcher the archive is stored as a release.
+func (l *ArchiveLookup) Key(ref, refName string) string {
+	return l.key(ref, refName, l.formats()[0])
+}
+
+func (l *ArchiveLookup) key(ref, refName, format string) string {
+	if l.TagMatcher != nil && l.TagMatcher.Match([]byte(refName)) {
+		return l.ReleaseKey(refName, format)
+	}
+
+	return l.CommitKey(ref, format)
+}
+
+func (l *ArchiveLookup) formats() []string {
+	if len(l.Format) > 0 {
+		return []string{l.Format}
+	}
+
+	return helper.ArchiveFormats
+}
+
+// Find iterates over the strategies and returns the first archive found.
+func (l *ArchiveLookup) Find(strategies []string, ref, refName, defaultBranch string) (*ArchiveLookupResult, error) {
+	tried := map[string]bool{}
+
+	for _, strategy := range strategies {
+		var key string
+		var err error
+
+		strategy = strings.TrimSpace(strategy)
+
+		switch strategy {
+		case LOOKUP_EXACT:
+			key, err = l.findExact(ref, refName)
+		case LOOKUP_BRANCH:
+			if !tried[refName] {
+				tried[refName] = true
+				key, err = l.findBranch(refName)
+			}
+		case LOOKUP_DEFAULT_BRANCH:
+			if !tried[defaultBranch] {
+				tried[defaultBranch] = true
+				key, err = l.findBranch(defaultBranch)
+			}
+		case LOOKUP_RELEASE:
+			key, err = l.findRelease()
+		default:
+			return nil, fmt.Errorf("Invalid lookup strategy: %s", strategy)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(key) > 0 {
+			return &ArchiveLookupResult{Key: key, Strategy: strategy}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (l *ArchiveLookup) findExact(ref, refName string) (string, error) {
+	for _, format := range l.formats() {
+		key := l.key(ref, refName, format)
+
+		if _, err := l.Storage.Stat(key); err != nil {
+			if err == storage.ErrNotFound {
+				continue
+			}
+
+			return "", err
+		}
+
+		return key, nil
+	}
+
+	return "", nil
+}
+
+func (l *ArchiveLookup) findBranch(branch string) (string, error) {
+	if len(branch) == 0 || (l.TagMatcher != nil && l.TagMatcher.Match([]byte(branch))) {
+		return "", nil
+	}
+
+	objects, err := l.list(fmt.Sprintf("commits/%s/%s/", l.Namespace, l.Project))
+
+	if err != nil {
+		return "", err
+	}
+
+	for i, o := range objects {
+		if l.MaxCandidates > 0 && i >= l.MaxCandidates {
+			break
+		}
+
+		object, err := l.Storage.Stat(o.Key)
+
+		if err != nil {
+			return "", err
+		}
+
+		if object.Meta(META_REF_NAME) == branch {
+			return o.Key, nil
+		}
+	}
+
+	return "", nil
+}
+
+func (l *ArchiveLookup) findRelease() (string, error) {
+	objects, err := l.list(fmt.Sprintf("releases/%s/%s/", l.Namespace, l.Project))
+
+	if err != nil || len(objects) == 0 {
+		return "", err
+	}
+
+	return objects[0].Key, nil
+}
+
+// list returns the job's archives stored under the prefix, the most recent first.
+func (l *ArchiveLookup) list(prefix string) ([]*storage.Object, error) {
+	if objects, ok := l.listings[prefix]; ok {
+		return objects, nil
+	}
+
+	all, err := l.Storage.List(prefix)
+
+	if err != nil {
+		return nil, err
+	}
+
+	objects := []*storage.Object{}
+
+	for _, o := range all {
+		archive, ok := ParseArchiveKey(o.Key)
+
+		// the job name is compared exactly, build must not match docker_build
+		if !ok || archive.Job != l.Job {
+			continue
+		}
+
+		for _, format := range l.formats() {
+			if archive.Format == format {
+				objects = append(objects, o)
+
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(objects, func(i, j int) bool {
+		return objects[i].LastModified.After(objects[j].LastModified)
+	})
+
+	if l.listings == nil {
+		l.listings = map[string][]*storage.Object{}
+	}
+
+	l.listings[prefix] = objects
+
+	return objects, nil
+}
@@ -0,0 +1,124 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestArchiveLookup(t *testing.T) (*ArchiveLookup, func()) {
+	store, clean := newTestArchiveStorage(t, []testArchive{
+		{"commits/rande/project/sha1_build.zip", 3 * time.Hour, "master"},
+		{"commits/rande/project/sha2_build.zip", 2 * time.Hour, "feature"},
+		{"commits/rande/project/sha3_test.zip", 1 * time.Hour, "feature"},
+		{"commits/rande/project/sha4_docker_build.zip", 30 * time.Minute, "feature"},
+		{"releases/rande/project/v1.0.0_build.zip", 5 * time.Hour, "v1.0.0"},
+		{"releases/rande/project/v1.1.0_build.zip", 4 * time.Hour, "v1.1.0"},
+	}, false)
+
+	lookup := &ArchiveLookup{
+		Storage:    store,
+		Namespace:  "rande",
+		Project:    "project",
+		Job:        "build",
+		TagMatcher: regexp.MustCompile(helper.SEMVER_PATTERN),
+	}
+
+	return lookup, clean
+}
+
+func Test_ArchiveLookup_Find(t *testing.T) {
+	strategies := []string{LOOKUP_EXACT, LOOKUP_BRANCH, LOOKUP_DEFAULT_BRANCH, LOOKUP_RELEASE}
+
+	values := []struct {
+		Ref      string
+		RefName  string
+		Default  string
+		Key      string
+		Strategy string
+	}{
+		{"sha1", "master", "master", "commits/rande/project/sha1_build.zip", LOOKUP_EXACT},
+		{"v1.0.0", "v1.0.0", "master", "releases/rande/project/v1.0.0_build.zip", LOOKUP_EXACT},
+		{"sha9", "feature", "master", "commits/rande/project/sha2_build.zip", LOOKUP_BRANCH},
+		{"sha9", "new-feature", "master", "commits/rande/project/sha1_build.zip", LOOKUP_DEFAULT_BRANCH},
+		{"sha9", "new-feature", "develop", "releases/rande/project/v1.1.0_build.zip", LOOKUP_RELEASE},
+	}
+
+	lookup, clean := newTestArchiveLookup(t)
+	defer clean()
+
+	for _, v := range values {
+		lookup.listings = nil
+
+		result, err := lookup.Find(strategies, v.Ref, v.RefName, v.Default)
+
+		assert.NoError(t, err)
+		assert.Equal(t, v.Key, result.Key)
+		assert.Equal(t, v.Strategy, result.Strategy)
+	}
+}
+
+func Test_ArchiveLookup_Find_Not_Found(t *testing.T) {
+	lookup, clean := newTestArchiveLookup(t)
+	defer clean()
+
+	result, err := lookup.Find([]string{LOOKUP_EXACT}, "sha9", "feature", "master")
+
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+
+	_, err = lookup.Find([]string{"foobar"}, "sha9", "feature", "master")
+
+	assert.Error(t, err)
+}
+
+func Test_ArchiveLookup_Find_Exact_Job(t *testing.T) {
+	lookup, clean := newTestArchiveLookup(t)
+	defer clean()
+
+	// the docker_build archive is the most recent one of the branch
+	result, err := lookup.Find([]string{LOOKUP_BRANCH}, "sha9", "feature", "master")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "commits/rande/project/sha2_build.zip", result.Key)
+
+	lookup.Job = "docker_build"
+	lookup.listings = nil
+
+	result, err = lookup.Find([]string{LOOKUP_EXACT, LOOKUP_BRANCH}, "sha2", "feature", "master")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "commits/rande/project/sha4_docker_build.zip", result.Key)
+	assert.Equal(t, LOOKUP_BRANCH, result.Strategy)
+}
+
+func Test_ParseArchiveKey(t *testing.T) {
+	archive, ok := ParseArchiveKey("commits/rande/project/sha1_test_unit.tar.gz")
+
+	assert.True(t, ok)
+	assert.Equal(t, "commits", archive.Kind)
+	assert.Equal(t, "rande", archive.Namespace)
+	assert.Equal(t, "project", archive.Project)
+	assert.Equal(t, "sha1", archive.Ref)
+	assert.Equal(t, "test_unit", archive.Job)
+	assert.Equal(t, "tar.gz", archive.Format)
+
+	for _, key := range []string{
+		"commits/rande/project/sha1_build.zip" + MANIFEST_SUFFIX,
+		"commits/rande/project/sha1.zip",
+		"commits/rande/project/sha1_.zip",
+		"foo/rande/project/sha1_build.zip",
+		"commits/rande/sha1_build.zip",
+	} {
+		_, ok := ParseArchiveKey(key)
+		assert.False(t, ok, key)
+	}
+}
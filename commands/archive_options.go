@@ -0,0 +1,117 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/storage"
+)
+
+// ObjectOptions contains the encryption, storage class and tagging settings
+// shared by the archive and extract commands.
+type ObjectOptions struct {
+	ServerSideEncryption string
+	KMSKeyId             string
+	EncryptionKey        string
+	AllowPlaintext       bool
+	StorageClass         string
+	Tags                 helper.Paths
+
+	// called with the keys of the plaintext objects read, optional
+	Warn func(message string)
+}
+
+func (o *ObjectOptions) ArchiveFlags(flags *flag.FlagSet) {
+	o.Tags = make(helper.Paths, 0)
+
+	flags.StringVar(&o.ServerSideEncryption, "sse", "", "The server side encryption: AES256 or aws:kms")
+	flags.StringVar(&o.KMSKeyId, "sse-kms-key-id", "", "The kms key used by the aws:kms encryption")
+	flags.StringVar(&o.StorageClass, "storage-class", "", "The s3 storage class")
+	flags.Var(&o.Tags, "tag", "-tag key=value")
+	flags.StringVar(&o.EncryptionKey, "encryption-key", os.Getenv("ARCHIVE_ENCRYPTION_KEY"), "The client side encryption key")
+}
+
+func (o *ObjectOptions) ExtractFlags(flags *flag.FlagSet) {
+	flags.StringVar(&o.EncryptionKey, "encryption-key", os.Getenv("ARCHIVE_ENCRYPTION_KEY"), "The client side encryption key")
+	flags.BoolVar(&o.AllowPlaintext, "allow-plaintext", false, "Extract the archives stored without client side encryption")
+}
+
+// Apply configures the storage, the storage is wrapped to encrypt the objects
+// if an encryption key is provided.
+func (o *ObjectOptions) Apply(s storage.Storage) (storage.Storage, error) {
+	tags := url.Values{}
+
+	for _, tag := range o.Tags {
+		parts := strings.SplitN(tag, "=", 2)
+
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			return nil, fmt.Errorf("Invalid tag: %s, the format must be key=value", tag)
+		}
+
+		tags.Add(parts[0], parts[1])
+	}
+
+	options := storage.S3ObjectOptions{
+		ServerSideEncryption: o.ServerSideEncryption,
+		KMSKeyId:             o.KMSKeyId,
+		StorageClass:         o.StorageClass,
+		Tagging:              tags.Encode(),
+	}
+
+	if s3storage, ok := s.(*storage.S3Storage); ok {
+		if err := options.Validate(); err != nil {
+			return nil, err
+		}
+
+		s3storage.Options = options
+	} else if options != (storage.S3ObjectOptions{}) {
+		return nil, errors.New("The server side encryption, the storage class and the tags are only supported by the s3 storage")
+	}
+
+	if len(o.EncryptionKey) == 0 {
+		return s, nil
+	}
+
+	key, err := storage.ParseEncryptionKey(o.EncryptionKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted := &storage.EncryptedStorage{Storage: s, Key: key, AllowPlaintext: o.AllowPlaintext}
+
+	// the archives stored before the encryption was enabled are only read if
+	// allowed, a warning is displayed as they are not authenticated
+	if o.Warn != nil {
+		encrypted.OnPlaintext = func(key string) {
+			o.Warn(fmt.Sprintf("Warning: the object %s is not encrypted, it is read as is", key))
+		}
+	}
+
+	return encrypted, nil
+}
+
+var objectOptionsArchiveHelp = `
+  -sse                The server side encryption: AES256 or aws:kms (s3 only)
+  -sse-kms-key-id     The kms key id used with -sse=aws:kms, the default aws/s3 key
+                       is used if not set (s3 only)
+  -storage-class      The storage class, ie: STANDARD_IA (s3 only)
+  -tag                A tag attached to the archive: key=value (one option per tag, s3 only)
+  -encryption-key     The key used to encrypt the archive with AES-256-GCM before the
+                       upload (default: ARCHIVE_ENCRYPTION_KEY), the key must contain
+                       32 bytes encoded in base64: openssl rand -base64 32`
+
+var objectOptionsExtractHelp = `
+  -encryption-key     The key used to decrypt the archive (default: ARCHIVE_ENCRYPTION_KEY)
+  -allow-plaintext    Extract the archives stored without client side encryption, ie:
+                       before the encryption was enabled, with a warning (default: false)`
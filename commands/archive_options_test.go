@@ -0,0 +1,67 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/rande/gitlab-ci-helper/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ObjectOptions_Apply(t *testing.T) {
+	s3storage := &storage.S3Storage{}
+
+	o := &ObjectOptions{
+		ServerSideEncryption: "aws:kms",
+		KMSKeyId:             "key-id",
+		StorageClass:         "STANDARD_IA",
+		Tags:                 []string{"team=backend", "env=prod"},
+	}
+
+	s, err := o.Apply(s3storage)
+	assert.NoError(t, err)
+	assert.Equal(t, s3storage, s)
+	assert.Equal(t, "aws:kms", s3storage.Options.ServerSideEncryption)
+	assert.Equal(t, "env=prod&team=backend", s3storage.Options.Tagging)
+
+	// client side encryption
+	o = &ObjectOptions{EncryptionKey: "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="}
+
+	s, err = o.Apply(&storage.LocalStorage{})
+	assert.NoError(t, err)
+	assert.IsType(t, &storage.EncryptedStorage{}, s)
+	assert.False(t, s.(*storage.EncryptedStorage).AllowPlaintext)
+
+	// the plaintext objects are read with a warning
+	warnings := []string{}
+
+	o.AllowPlaintext = true
+	o.Warn = func(message string) {
+		warnings = append(warnings, message)
+	}
+
+	s, err = o.Apply(&storage.LocalStorage{})
+	assert.NoError(t, err)
+	assert.True(t, s.(*storage.EncryptedStorage).AllowPlaintext)
+
+	s.(*storage.EncryptedStorage).OnPlaintext("archive.zip")
+	assert.Equal(t, []string{"Warning: the object archive.zip is not encrypted, it is read as is"}, warnings)
+}
+
+func Test_ObjectOptions_Apply_Invalid(t *testing.T) {
+	_, err := (&ObjectOptions{Tags: []string{"team"}}).Apply(&storage.S3Storage{})
+	assert.Error(t, err)
+
+	_, err = (&ObjectOptions{StorageClass: "foo"}).Apply(&storage.S3Storage{})
+	assert.Error(t, err)
+
+	_, err = (&ObjectOptions{ServerSideEncryption: "AES256"}).Apply(&storage.LocalStorage{})
+	assert.Error(t, err)
+
+	_, err = (&ObjectOptions{EncryptionKey: "foo"}).Apply(&storage.LocalStorage{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,178 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/rande/gitlab-ci-helper/storage"
+)
+
+// the retention policies: keep the last archives per branch and job, or per job
+const (
+	PRUNE_BY_BRANCH = "branch"
+	PRUNE_BY_JOB    = "job"
+)
+
+type PruneCandidate struct {
+	Archive *ArchiveKey
+	Object  *storage.Object
+	RefName string
+}
+
+type ArchivePrunePlan struct {
+	Keep   []*PruneCandidate
+	Delete []*PruneCandidate
+}
+
+// ArchivePruner removes the old archives stored under commits/, the archives
+// stored under releases/ and the archives matching the tag matcher are never
+// removed.
+type ArchivePruner struct {
+	Storage    storage.Storage
+	Namespace  string
+	Project    string
+	Job        string
+	TagMatcher *regexp.Regexp
+
+	// the number of archives to keep per group
+	Keep   int
+	KeepBy string
+}
+
+// NewPruneTagMatcher compiles the tag matcher of the pruner, the pattern must
+// match the whole ref name so a branch like feature/upgrade-1.2.3 is not kept
+// forever. No tag is matched if the pattern is empty.
+func NewPruneTagMatcher(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) == 0 {
+		return nil, nil
+	}
+
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+func (p *ArchivePruner) Plan() (*ArchivePrunePlan, error) {
+	if p.KeepBy != PRUNE_BY_BRANCH && p.KeepBy != PRUNE_BY_JOB {
+		return nil, fmt.Errorf("Invalid retention policy: %s", p.KeepBy)
+	}
+
+	objects, err := p.Storage.List(fmt.Sprintf("commits/%s/%s/", p.Namespace, p.Project))
+
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &ArchivePrunePlan{
+		Keep:   []*PruneCandidate{},
+		Delete: []*PruneCandidate{},
+	}
+
+	groups := map[string][]*PruneCandidate{}
+
+	for _, o := range objects {
+		archive, ok := ParseArchiveKey(o.Key)
+
+		if !ok || (len(p.Job) > 0 && archive.Job != p.Job) {
+			continue
+		}
+
+		candidate := &PruneCandidate{
+			Archive: archive,
+			Object:  o,
+		}
+
+		// the listing does not contain the metadata, the ref name is only
+		// retrieved if required as it costs one request per archive
+		if p.KeepBy == PRUNE_BY_BRANCH || p.TagMatcher != nil {
+			object, err := p.Storage.Stat(o.Key)
+
+			if err != nil {
+				return nil, err
+			}
+
+			candidate.RefName = object.Meta(META_REF_NAME)
+
+			// the branch of the archives stored without the ref name is
+			// unknown, they are kept
+			if len(candidate.RefName) == 0 {
+				plan.Keep = append(plan.Keep, candidate)
+
+				continue
+			}
+		}
+
+		if p.TagMatcher != nil && p.TagMatcher.Match([]byte(candidate.RefName)) {
+			plan.Keep = append(plan.Keep, candidate)
+
+			continue
+		}
+
+		group := archive.Job
+		if p.KeepBy == PRUNE_BY_BRANCH {
+			group = fmt.Sprintf("%s/%s", candidate.RefName, archive.Job)
+		}
+
+		groups[group] = append(groups[group], candidate)
+	}
+
+	names := []string{}
+	for name := range groups {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		candidates := groups[name]
+
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].Object.LastModified.After(candidates[j].Object.LastModified)
+		})
+
+		for i, c := range candidates {
+			if i < p.Keep {
+				plan.Keep = append(plan.Keep, c)
+			} else {
+				plan.Delete = append(plan.Delete, c)
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// Delete removes the archives and their manifests, batchSize archives at a
+// time. The callback is called after each batch with the number of archives
+// deleted so far.
+func (p *ArchivePruner) Delete(candidates []*PruneCandidate, batchSize int, done func(count int)) error {
+	if batchSize < 1 {
+		batchSize = len(candidates)
+	}
+
+	for start := 0; start < len(candidates); start += batchSize {
+		end := start + batchSize
+		if end > len(candidates) {
+			end = len(candidates)
+		}
+
+		keys := []string{}
+		for _, c := range candidates[start:end] {
+			keys = append(keys, c.Archive.Key, c.Archive.Key+MANIFEST_SUFFIX)
+		}
+
+		if err := storage.DeleteBatch(p.Storage, keys); err != nil {
+			return err
+		}
+
+		if done != nil {
+			done(end)
+		}
+	}
+
+	return nil
+}
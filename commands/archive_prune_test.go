@@ -0,0 +1,192 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestArchivePruner(t *testing.T) (*ArchivePruner, func()) {
+	store, clean := newTestArchiveStorage(t, []testArchive{
+		{"commits/rande/project/sha1_build.zip", 5 * time.Hour, "master"},
+		{"commits/rande/project/sha2_build.zip", 4 * time.Hour, "master"},
+		{"commits/rande/project/sha3_build.tar.gz", 3 * time.Hour, "master"},
+		{"commits/rande/project/sha4_build.zip", 2 * time.Hour, "feature"},
+		{"commits/rande/project/sha5_build.zip", 6 * time.Hour, "v1.0.0"},
+		{"commits/rande/project/sha1_test_unit.zip", 5 * time.Hour, "master"},
+		{"commits/rande/project/sha3_test_unit.zip", 3 * time.Hour, "master"},
+		{"releases/rande/project/v1.0.0_build.zip", 10 * time.Hour, "v1.0.0"},
+	}, true)
+
+	pruner := &ArchivePruner{
+		Storage:    store,
+		Namespace:  "rande",
+		Project:    "project",
+		TagMatcher: regexp.MustCompile(helper.SEMVER_PATTERN),
+		Keep:       1,
+		KeepBy:     PRUNE_BY_BRANCH,
+	}
+
+	return pruner, clean
+}
+
+func pruneKeys(candidates []*PruneCandidate) []string {
+	keys := []string{}
+	for _, c := range candidates {
+		keys = append(keys, c.Archive.Key)
+	}
+
+	return keys
+}
+
+func Test_ArchivePruner_Plan_By_Branch(t *testing.T) {
+	pruner, clean := newTestArchivePruner(t)
+	defer clean()
+
+	plan, err := pruner.Plan()
+	assert.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{
+		"commits/rande/project/sha1_build.zip",
+		"commits/rande/project/sha2_build.zip",
+		"commits/rande/project/sha1_test_unit.zip",
+	}, pruneKeys(plan.Delete))
+
+	assert.ElementsMatch(t, []string{
+		"commits/rande/project/sha3_build.tar.gz",
+		"commits/rande/project/sha4_build.zip",
+		"commits/rande/project/sha5_build.zip",
+		"commits/rande/project/sha3_test_unit.zip",
+	}, pruneKeys(plan.Keep))
+}
+
+func Test_ArchivePruner_Plan_By_Job(t *testing.T) {
+	pruner, clean := newTestArchivePruner(t)
+	defer clean()
+
+	pruner.KeepBy = PRUNE_BY_JOB
+	pruner.Job = "build"
+
+	plan, err := pruner.Plan()
+	assert.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{
+		"commits/rande/project/sha1_build.zip",
+		"commits/rande/project/sha2_build.zip",
+		"commits/rande/project/sha3_build.tar.gz",
+	}, pruneKeys(plan.Delete))
+
+	pruner.KeepBy = "foo"
+
+	_, err = pruner.Plan()
+	assert.Error(t, err)
+}
+
+func Test_ArchivePruner_Plan_TagMatcher(t *testing.T) {
+	store, clean := newTestArchiveStorage(t, []testArchive{
+		{"commits/rande/project/sha1_build.zip", 5 * time.Hour, "v1.2.3"},
+		{"commits/rande/project/sha2_build.zip", 4 * time.Hour, "feature/upgrade-1.2.3"},
+		{"commits/rande/project/sha3_build.zip", 3 * time.Hour, "master"},
+	}, false)
+	defer clean()
+
+	tagMatcher, err := NewPruneTagMatcher(helper.SEMVER_PATTERN)
+	assert.NoError(t, err)
+
+	pruner := &ArchivePruner{
+		Storage:    store,
+		Namespace:  "rande",
+		Project:    "project",
+		TagMatcher: tagMatcher,
+		Keep:       1,
+		KeepBy:     PRUNE_BY_JOB,
+	}
+
+	// only the tag is kept, the branch containing a version is pruned
+	plan, err := pruner.Plan()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"commits/rande/project/sha2_build.zip"}, pruneKeys(plan.Delete))
+
+	tagMatcher, err = NewPruneTagMatcher("")
+	assert.NoError(t, err)
+	assert.Nil(t, tagMatcher)
+
+	_, err = NewPruneTagMatcher("(")
+	assert.Error(t, err)
+}
+
+func Test_ArchivePruner_Plan_Without_RefName(t *testing.T) {
+	store, clean := newTestArchiveStorage(t, []testArchive{
+		{"commits/rande/project/sha1_build.zip", 5 * time.Hour, ""},
+		{"commits/rande/project/sha2_build.zip", 4 * time.Hour, ""},
+		{"commits/rande/project/sha3_build.zip", 3 * time.Hour, "master"},
+		{"commits/rande/project/sha4_build.zip", 2 * time.Hour, "master"},
+	}, false)
+	defer clean()
+
+	pruner := &ArchivePruner{
+		Storage:   store,
+		Namespace: "rande",
+		Project:   "project",
+		Keep:      1,
+		KeepBy:    PRUNE_BY_BRANCH,
+	}
+
+	// the branch is unknown, the archives are kept
+	plan, err := pruner.Plan()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"commits/rande/project/sha3_build.zip"}, pruneKeys(plan.Delete))
+
+	// the metadata is not required
+	pruner.KeepBy = PRUNE_BY_JOB
+
+	plan, err = pruner.Plan()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"commits/rande/project/sha3_build.zip",
+		"commits/rande/project/sha2_build.zip",
+		"commits/rande/project/sha1_build.zip",
+	}, pruneKeys(plan.Delete))
+}
+
+func Test_Prune(t *testing.T) {
+	pruner, clean := newTestArchivePruner(t)
+	defer clean()
+
+	root := pruner.Storage.(*storage.LocalStorage).Root
+
+	// dry run
+	ui := &cli.MockUi{}
+	assert.Equal(t, 0, Prune(ui, pruner, "file://"+root, true, 2, false))
+	assert.Contains(t, ui.OutputWriter.String(), "Dry run: 3 archive(s) to delete, 4 archive(s) kept")
+
+	_, err := os.Stat(filepath.Join(root, "commits/rande/project/sha1_build.zip"))
+	assert.NoError(t, err)
+
+	// delete by batch of 2 archives
+	ui = &cli.MockUi{}
+	assert.Equal(t, 0, Prune(ui, pruner, "file://"+root, false, 2, false))
+	assert.Contains(t, ui.OutputWriter.String(), "Deleted 2/3 archive(s)\nDeleted 3/3 archive(s)\n")
+
+	for _, key := range []string{"commits/rande/project/sha1_build.zip", "commits/rande/project/sha1_build.zip" + MANIFEST_SUFFIX} {
+		_, err := os.Stat(filepath.Join(root, key))
+		assert.True(t, os.IsNotExist(err))
+	}
+
+	for _, key := range []string{"commits/rande/project/sha3_build.tar.gz", "releases/rande/project/v1.0.0_build.zip"} {
+		_, err := os.Stat(filepath.Join(root, key))
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// testArchive is an archive stored by newTestArchiveStorage, the age sets
+// its modification time.
+type testArchive struct {
+	Key     string
+	Age     time.Duration
+	RefName string
+}
+
+// newTestArchiveStorage creates a local storage with the archives, and their
+// manifest if required.
+func newTestArchiveStorage(t *testing.T, archives []testArchive, manifests bool) (*storage.LocalStorage, func()) {
+	root, err := ioutil.TempDir("", "gitlab_ci_helper_archives")
+	assert.NoError(t, err)
+
+	store := &storage.LocalStorage{Root: root}
+	now := time.Now()
+
+	for _, a := range archives {
+		keys := []string{a.Key}
+		if manifests {
+			keys = append(keys, a.Key+MANIFEST_SUFFIX)
+		}
+
+		for _, key := range keys {
+			err := store.Put(key, bytes.NewBufferString("content"), &storage.Object{
+				Metadata: map[string]string{META_REF_NAME: a.RefName},
+			})
+			assert.NoError(t, err)
+
+			mtime := now.Add(-a.Age)
+			os.Chtimes(filepath.Join(root, key), mtime, mtime)
+		}
+	}
+
+	return store, func() {
+		os.RemoveAll(root)
+	}
+}
+
+func Test_NewExcludeMatcher(t *testing.T) {
+	fp, err := ioutil.TempFile("", "gitlab_ci_helper_ignore")
+	assert.NoError(t, err)
+
+	defer os.Remove(fp.Name())
+
+	fp.WriteString("*.log\n")
+	fp.Close()
+
+	excludes := make(helper.Paths, 0)
+	excludes.Set("!keep.log")
+
+	m, err := NewExcludeMatcher(helper.MATCH_GLOB, excludes, fp.Name(), true)
+	assert.NoError(t, err)
+
+	assert.True(t, m.Excluded(".git/HEAD", false))
+	assert.True(t, m.Excluded("app.log", false))
+	assert.False(t, m.Excluded("keep.log", false))
+
+	// the default file is optional
+	_, err = NewExcludeMatcher(helper.MATCH_GLOB, excludes, helper.IGNORE_FILE, true)
+	assert.NoError(t, err)
+
+	_, err = NewExcludeMatcher(helper.MATCH_GLOB, excludes, "/path/not/found", true)
+	assert.Error(t, err)
+
+	_, err = NewExcludeMatcher(helper.MATCH_REGEXP, excludes, "", true)
+	assert.NoError(t, err)
+
+	excludes.Set("[a-")
+	_, err = NewExcludeMatcher(helper.MATCH_REGEXP, excludes, "", false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,150 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+)
+
+type CiBuildInfoCommand struct {
+	Ui               cli.Ui
+	Verbose          bool
+	Version          string
+	GoFile           string
+	GoPackage        string
+	PackageJson      string
+	PropertiesFile   string
+	PropertiesPrefix string
+}
+
+func (c *CiBuildInfoCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("ci:buildinfo", flag.ContinueOnError)
+	cmdFlags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	cmdFlags.BoolVar(&c.Verbose, "verbose", false, "")
+	cmdFlags.StringVar(&c.Version, "version", "", "The version")
+	cmdFlags.StringVar(&c.GoFile, "go", "", "The Go file to generate")
+	cmdFlags.StringVar(&c.GoPackage, "go-package", "buildinfo", "The package of the Go file")
+	cmdFlags.StringVar(&c.PackageJson, "package-json", "", "The package.json file to stamp")
+	cmdFlags.StringVar(&c.PropertiesFile, "properties", "", "The Java properties file to generate")
+	cmdFlags.StringVar(&c.PropertiesPrefix, "properties-prefix", "build.", "The prefix of the properties")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.GoFile) == 0 && len(c.PackageJson) == 0 && len(c.PropertiesFile) == 0 {
+		c.Ui.Error("Error: at least one of the -go, -package-json or -properties options is required")
+
+		return 1
+	}
+
+	info := helper.NewBuildInfo(helper.NewMeta(), c.Version, time.Now())
+
+	if c.Verbose {
+		c.Ui.Output(fmt.Sprintf("Version: %s, commit: %s, pipeline: %s", info.Version, info.Commit, info.PipelineId))
+	}
+
+	if len(c.GoFile) > 0 {
+		data, err := info.Go(c.GoPackage)
+
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error: unable to generate the Go file, %s", err.Error()))
+
+			return 1
+		}
+
+		if !c.write(c.GoFile, data) {
+			return 1
+		}
+	}
+
+	if len(c.PackageJson) > 0 {
+		data, err := ioutil.ReadFile(c.PackageJson)
+
+		if err == nil {
+			data, err = info.StampPackageJson(data)
+		}
+
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+			return 1
+		}
+
+		if !c.write(c.PackageJson, data) {
+			return 1
+		}
+	}
+
+	if len(c.PropertiesFile) > 0 && !c.write(c.PropertiesFile, info.Properties(c.PropertiesPrefix)) {
+		return 1
+	}
+
+	return 0
+}
+
+func (c *CiBuildInfoCommand) write(file string, data []byte) bool {
+	mode := os.FileMode(0644)
+
+	// keep the permissions of the stamped files
+	if stat, err := os.Stat(file); err == nil {
+		mode = stat.Mode()
+	}
+
+	if err := ioutil.WriteFile(file, data, mode); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return false
+	}
+
+	c.Ui.Output(fmt.Sprintf("Build info written to %s", file))
+
+	return true
+}
+
+func (c *CiBuildInfoCommand) Synopsis() string {
+	return "Generate the build information files for Go, Node and Java."
+}
+
+func (c *CiBuildInfoCommand) Help() string {
+	helpText := `
+Usage: gitlab-ci-helper ci:buildinfo [options]
+
+  Generate the build information files from the ci:meta data, so a service
+  can report its version, commit and pipeline at runtime:
+    - a Go file with a variable per value: Version, Commit, ShortCommit, Ref,
+      Tag, PipelineId, PipelineUrl, JobId and Date,
+    - the version of a package.json file, the rest of the file is kept,
+    - a Java properties file: build.version, build.commit, ...
+
+  The version defaults to the tag without the v prefix, or to 0.0.0-dev with
+  the short sha as build metadata. The date is the creation date of the
+  pipeline, the current date is used outside of a pipeline.
+
+Options:
+
+  -version            The version (default: the tag or 0.0.0-dev+<short sha>)
+  -go                 The Go file to generate, ie: buildinfo/buildinfo.go
+  -go-package         The package of the Go file (default: buildinfo)
+  -package-json       The package.json file to stamp
+  -properties         The Java properties file to generate, ie:
+                        src/main/resources/build.properties
+  -properties-prefix  The prefix of the properties (default: build.)
+  -verbose            Add verbose information to the output
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,85 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Ci_BuildInfo(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gitlab-ci-helper")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	packageJson := filepath.Join(dir, "package.json")
+	assert.NoError(t, ioutil.WriteFile(packageJson, []byte(`{"name": "app", "version": "0.0.1"}`), 0600))
+
+	envs := map[string]string{
+		"CI_COMMIT_SHA":          "4f0ac8c2a1",
+		"CI_COMMIT_TAG":          "v1.2.0",
+		"CI_PIPELINE_ID":         "12",
+		"CI_PIPELINE_CREATED_AT": "2017-08-01T10:00:00Z",
+	}
+
+	helper.WrapperTestCommand([]*helper.FakeRequest{}, envs, t, func(ts *httptest.Server) {
+		ui := &cli.MockUi{}
+		c := &CiBuildInfoCommand{
+			Ui: ui,
+		}
+
+		code := c.Run([]string{
+			"-go", filepath.Join(dir, "buildinfo.go"),
+			"-package-json", packageJson,
+			"-properties", filepath.Join(dir, "build.properties"),
+		})
+
+		assert.Equal(t, 0, code, ui.ErrorWriter.String())
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, "buildinfo.go"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), "\tPipelineId  = \"12\"\n")
+
+		data, err = ioutil.ReadFile(packageJson)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"name": "app", "version": "1.2.0"}`, string(data))
+
+		stat, err := os.Stat(packageJson)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), stat.Mode())
+
+		data, err = ioutil.ReadFile(filepath.Join(dir, "build.properties"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), "build.commit=4f0ac8c2a1\nbuild.shortCommit=4f0ac8c2\n")
+		assert.Contains(t, string(data), "build.date=2017-08-01T10\\:00\\:00Z\n")
+	})
+}
+
+func Test_Ci_BuildInfo_Help(t *testing.T) {
+	c := &CiBuildInfoCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.True(t, len(c.Help()) > 0)
+	assert.True(t, len(c.Synopsis()) > 0)
+}
+
+func Test_Ci_BuildInfo_InvalidRun(t *testing.T) {
+	c := &CiBuildInfoCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.Equal(t, 1, c.Run([]string{"--foobar"}))
+	assert.Equal(t, 1, c.Run([]string{}))
+	assert.Equal(t, 1, c.Run([]string{"-package-json", "missing.json"}))
+}
@@ -6,46 +6,36 @@
 package commands
 
 import (
-	"bytes"
 	"encoding/json"
 	"flag"
-	"os"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/mitchellh/cli"
 	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"gopkg.in/yaml.v2"
 )
 
-type MetaBuild struct {
-	Id      string `json:"id"`
-	Ref     string `json:"ref"`
-	RefName string `json:"ref_name"`
-	Tag     string `json:"tag"`
-	Stage   string `json:"stage"`
-	JobName string `json:"job_name"`
-}
-
-type MetaProject struct {
-	Id  string `json:"id"`
-	Dir string `json:"dir"`
-}
-
-type MetaServer struct {
-	Name     string `json:"name"`
-	Revision string `json:"revision"`
-	Version  string `json:"version"`
-}
-
-type Meta struct {
-	Build   *MetaBuild   `json:"build"`
-	Project *MetaProject `json:"project"`
-	Server  *MetaServer  `json:"server"`
+// the default file of each format, the template format requires the -file
+// option
+var metaFiles = map[string]string{
+	"json":   "ci.json",
+	"yaml":   "ci.yml",
+	"dotenv": "ci.env",
 }
 
 type CiDumpMetaCommand struct {
 	Ui       cli.Ui
 	Verbose  bool
 	MetaFile string
+	Api      bool
+	Format   string
+	Template string
+	Prefix   string
 }
 
 func (c *CiDumpMetaCommand) Run(args []string) int {
@@ -55,59 +45,216 @@ func (c *CiDumpMetaCommand) Run(args []string) int {
 	}
 
 	cmdFlags.BoolVar(&c.Verbose, "verbose", false, "")
-	cmdFlags.StringVar(&c.MetaFile, "file", "ci.json", "")
+	cmdFlags.StringVar(&c.MetaFile, "file", "", "The target file, - for the standard output")
+	cmdFlags.BoolVar(&c.Api, "api", false, "Complete the information with the GitLab API")
+	cmdFlags.StringVar(&c.Format, "format", "json", "The format: json, yaml, dotenv or template")
+	cmdFlags.StringVar(&c.Template, "template", "", "The Go template file used by the template format")
+	cmdFlags.StringVar(&c.Prefix, "prefix", "CI_META_", "The prefix of the dotenv variables")
 
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
 	}
 
-	meta := &Meta{
-		Build: &MetaBuild{
-			Id:      helper.GetEnv("CI_JOB_ID", os.Getenv("CI_BUILD_ID")),
-			Ref:     helper.GetEnv("CI_COMMIT_SHA", os.Getenv("CI_BUILD_REF")),
-			RefName: helper.GetEnv("CI_COMMIT_REF_NAME", os.Getenv("CI_BUILD_REF_NAME")),
-			Tag:     helper.GetEnv("CI_COMMIT_TAG", os.Getenv("CI_BUILD_TAG")),
-			Stage:   helper.GetEnv("CI_JOB_STAGE", os.Getenv("CI_BUILD_STAGE")),
-			JobName: helper.GetEnv("CI_JOB_NAME", os.Getenv("CI_BUILD_NAME")),
-		},
-		Project: &MetaProject{
-			Id:  os.Getenv("CI_PROJECT_ID"),
-			Dir: os.Getenv("CI_PROJECT_DIR"),
-		},
-		Server: &MetaServer{
-			Name:     os.Getenv("CI_SERVER_NAME"),
-			Revision: os.Getenv("CI_SERVER_REVISION"),
-			Version:  os.Getenv("CI_SERVER_VERSION"),
-		},
+	if _, ok := metaFiles[c.Format]; !ok && c.Format != "template" {
+		c.Ui.Error(fmt.Sprintf("Error: invalid format: %s", c.Format))
+
+		return 1
+	}
+
+	if len(c.MetaFile) == 0 {
+		c.MetaFile = metaFiles[c.Format]
+	}
+
+	if c.Format == "template" && (len(c.Template) == 0 || len(c.MetaFile) == 0) {
+		c.Ui.Error("Error: the -template and the -file options are required with the template format")
+
+		return 1
+	}
+
+	meta := helper.NewMeta()
+
+	if c.Api {
+		if err := c.enrich(meta); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+			return 1
+		}
+	}
+
+	data, err := c.encode(meta)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
 	}
 
-	fp, _ := os.Create(c.MetaFile)
-	defer fp.Close()
+	if c.MetaFile == "-" {
+		c.Ui.Output(strings.TrimSuffix(string(data), "\n"))
 
-	b, _ := json.Marshal(meta)
+		return 0
+	}
+
+	if err := ioutil.WriteFile(c.MetaFile, data, 0644); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
 
-	var out bytes.Buffer
-	json.Indent(&out, b, "", "    ")
+		return 1
+	}
 
-	out.WriteTo(fp)
+	if c.Verbose {
+		c.Ui.Output(fmt.Sprintf("Meta information written to %s (format: %s)", c.MetaFile, c.Format))
+	}
 
 	return 0
 }
 
+func (c *CiDumpMetaCommand) encode(meta *helper.Meta) ([]byte, error) {
+	switch c.Format {
+	case "json":
+		return json.MarshalIndent(meta, "", "    ")
+	case "yaml":
+		return yaml.Marshal(meta)
+	case "dotenv":
+		return dotenv(meta, c.Prefix)
+	case "template":
+		tpl, err := ioutil.ReadFile(c.Template)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out, err := helper.Render(string(tpl), meta, nil)
+
+		return []byte(out), err
+	}
+
+	return nil, fmt.Errorf("invalid format: %s", c.Format)
+}
+
+var dotenvEscaper = strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "$", "\\$", "\n", "\\n")
+
+var dotenvInvalid = regexp.MustCompile("[^A-Z0-9_]")
+
+// dotenv flattens the meta information, the names are built from the json
+// names, ie: CI_META_BUILD_REF_NAME. The lists are joined with a comma, the
+// values are double quoted.
+func dotenv(meta *helper.Meta, prefix string) ([]byte, error) {
+	data, err := json.Marshal(meta)
+
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+
+	lines := []string{}
+
+	var flatten func(name string, v interface{})
+
+	flatten = func(name string, v interface{}) {
+		switch value := v.(type) {
+		case map[string]interface{}:
+			for key, sub := range value {
+				flatten(name+"_"+key, sub)
+			}
+
+			return
+		case []interface{}:
+			items := []string{}
+			for _, item := range value {
+				items = append(items, fmt.Sprintf("%v", item))
+			}
+
+			v = strings.Join(items, ",")
+		case nil:
+			// the optional sections
+			return
+		}
+
+		name = dotenvInvalid.ReplaceAllString(strings.ToUpper(prefix+strings.TrimPrefix(name, "_")), "_")
+
+		lines = append(lines, fmt.Sprintf("%s=\"%s\"", name, dotenvEscaper.Replace(fmt.Sprintf("%v", v))))
+	}
+
+	flatten("", values)
+
+	sort.Strings(lines)
+
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+func (c *CiDumpMetaCommand) enrich(meta *helper.Meta) error {
+	config, err := helper.NewConfig()
+
+	if err != nil {
+		return err
+	}
+
+	client := gitlab.NewClient(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
+
+	project, err := helper.GetProject(meta.Project.Id, client)
+
+	if err != nil {
+		return err
+	}
+
+	if c.Verbose {
+		c.Ui.Output(fmt.Sprintf("Found project: %s/%s (id: %d)", project.Namespace.Name, project.Name, project.Id))
+	}
+
+	return meta.Enrich(project, client)
+}
+
 func (c *CiDumpMetaCommand) Synopsis() string {
-	return "Dump a json file with build information."
+	return "Dump a json, yaml or dotenv file with build information."
 }
 
 func (c *CiDumpMetaCommand) Help() string {
 	helpText := `
 Usage: gitlab-ci-helper ci:meta [options]
 
-  Dump meta information about ci into a ci.json file
+  Dump meta information about ci into a file: the build, the project,
+  the server, the pipeline, the runner, the environment, the merge request and
+  the commit. The information is retrieved from the GitLab CI env vars, with
+  a fallback on the 8.x variables.
+
+  The "version" field is the version of the schema, it is increased when a
+  field is renamed or removed.
+
+  The -api option completes the missing information with the GitLab API and
+  retrieves the files changed since the merge request base or the previous
+  pushed commit.
+
+  Formats:
+    json              The indented json document (default file: ci.json)
+    yaml              The yaml document (default file: ci.yml)
+    dotenv            One variable per value, the names are built from the
+                        json names: CI_META_BUILD_REF_NAME="master" (default
+                        file: ci.env)
+    template          The Go template provided by the -template option, the
+                        template receives the meta information, ie: .Build.Id,
+                        and the json and env functions:
+                        export default {{ json . }};
 
 Options:
 
-  -file               Target file (default: ci.json)
+  -file               Target file, - for the standard output (default:
+                        depends on the format)
+  -format             The format: json, yaml, dotenv or template (default: json)
+  -template           The Go template file, required by the template format
+  -prefix             The prefix of the dotenv variables (default: CI_META_)
+  -api                Complete the information with the GitLab API
   -verbose            Add verbose information to the output
+
+Credentials are retrieved from environment, if -api is used:
+
+  GITLAB_HOST         The gitlab host
+  GITLAB_TOKEN        The user's token
+  GITLAB_API_PATH     (optional) the api path, default to: "/api/v4"
 `
 	return strings.TrimSpace(helpText)
 }
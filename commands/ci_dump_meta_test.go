@@ -8,6 +8,7 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http/httptest"
 	"os"
 	"testing"
@@ -41,21 +42,25 @@ func Test_Ci_Dump_Meta_With_Values(t *testing.T) {
 
 	reqs := []*helper.FakeRequest{}
 	envs := map[string]string{
-		"CI_BUILD_ID":        "CI_BUILD_ID",
-		"CI_BUILD_REF":       "CI_BUILD_REF",
-		"CI_BUILD_REF_NAME":  "CI_BUILD_REF_NAME",
-		"CI_BUILD_TAG":       "CI_BUILD_TAG",
-		"CI_BUILD_STAGE":     "CI_BUILD_STAGE",
-		"CI_BUILD_NAME":      "CI_BUILD_NAME",
-		"CI_PROJECT_ID":      "CI_PROJECT_ID",
-		"CI_PROJECT_DIR":     "CI_PROJECT_DIR",
-		"CI_SERVER_NAME":     "CI_SERVER_NAME",
-		"CI_SERVER_REVISION": "CI_SERVER_REVISION",
-		"CI_SERVER_VERSION":  "CI_SERVER_VERSION",
+		"CI_BUILD_ID":         "CI_BUILD_ID",
+		"CI_BUILD_REF":        "CI_BUILD_REF",
+		"CI_BUILD_REF_NAME":   "CI_BUILD_REF_NAME",
+		"CI_BUILD_TAG":        "CI_BUILD_TAG",
+		"CI_BUILD_STAGE":      "CI_BUILD_STAGE",
+		"CI_BUILD_NAME":       "CI_BUILD_NAME",
+		"CI_PROJECT_ID":       "CI_PROJECT_ID",
+		"CI_PROJECT_DIR":      "CI_PROJECT_DIR",
+		"CI_SERVER_NAME":      "CI_SERVER_NAME",
+		"CI_SERVER_REVISION":  "CI_SERVER_REVISION",
+		"CI_SERVER_VERSION":   "CI_SERVER_VERSION",
+		"CI_PIPELINE_ID":      "12",
+		"CI_RUNNER_TAGS":      "docker, linux",
+		"CI_ENVIRONMENT_NAME": "production",
 	}
 
-	meta := &Meta{
-		Build: &MetaBuild{
+	meta := &helper.Meta{
+		Version: helper.META_VERSION,
+		Build: &helper.MetaBuild{
 			Id:      "CI_BUILD_ID",
 			Ref:     "CI_BUILD_REF",
 			RefName: "CI_BUILD_REF_NAME",
@@ -63,15 +68,29 @@ func Test_Ci_Dump_Meta_With_Values(t *testing.T) {
 			Stage:   "CI_BUILD_STAGE",
 			JobName: "CI_BUILD_NAME",
 		},
-		Project: &MetaProject{
+		Project: &helper.MetaProject{
 			Id:  "CI_PROJECT_ID",
 			Dir: "CI_PROJECT_DIR",
 		},
-		Server: &MetaServer{
+		Server: &helper.MetaServer{
 			Name:     "CI_SERVER_NAME",
 			Revision: "CI_SERVER_REVISION",
 			Version:  "CI_SERVER_VERSION",
 		},
+		Pipeline: &helper.MetaPipeline{
+			Id: "12",
+		},
+		Runner: &helper.MetaRunner{
+			Tags: []string{"docker", "linux"},
+		},
+		Environment: &helper.MetaEnvironment{
+			Name: "production",
+		},
+		Commit: &helper.MetaCommit{
+			Sha:          "CI_BUILD_REF",
+			ShortSha:     "CI_BUILD",
+			ChangedFiles: []string{},
+		},
 	}
 
 	helper.WrapperTestCommand(reqs, envs, t, func(ts *httptest.Server) {
@@ -89,7 +108,7 @@ func Test_Ci_Dump_Meta_With_Values(t *testing.T) {
 		assert.NoError(t, err)
 		defer r.Close()
 
-		m := &Meta{}
+		m := &helper.Meta{}
 		err = json.NewDecoder(r).Decode(m)
 
 		assert.NoError(t, err)
@@ -100,6 +119,58 @@ func Test_Ci_Dump_Meta_With_Values(t *testing.T) {
 	os.Remove(path)
 }
 
+func Test_Ci_Dump_Meta_Formats(t *testing.T) {
+	envs := map[string]string{
+		"CI_JOB_ID":          "42",
+		"CI_COMMIT_REF_NAME": "master",
+		"CI_COMMIT_MESSAGE":  "Fix the \"build\"\n\nwith $HOME",
+		"CI_RUNNER_TAGS":     `["docker", "linux"]`,
+	}
+
+	tpl := fmt.Sprintf("%s/gitlab_helper.meta.tpl", os.TempDir())
+	assert.NoError(t, ioutil.WriteFile(tpl, []byte("export default {{ json .Build }};"), 0644))
+	defer os.Remove(tpl)
+
+	helper.WrapperTestCommand([]*helper.FakeRequest{}, envs, t, func(ts *httptest.Server) {
+		ui := &cli.MockUi{}
+		c := &CiDumpMetaCommand{Ui: ui}
+
+		assert.Equal(t, 0, c.Run([]string{"-format", "yaml", "-file", "-"}))
+		assert.Contains(t, ui.OutputWriter.String(), "build:\n  id: \"42\"\n  ref: \"\"\n  ref_name: master\n")
+
+		ui = &cli.MockUi{}
+		c = &CiDumpMetaCommand{Ui: ui}
+
+		assert.Equal(t, 0, c.Run([]string{"-format", "dotenv", "-file", "-", "-prefix", "APP_"}))
+
+		output := ui.OutputWriter.String()
+		assert.Contains(t, output, "APP_BUILD_ID=\"42\"\n")
+		assert.Contains(t, output, "APP_BUILD_REF_NAME=\"master\"\n")
+		assert.Contains(t, output, "APP_COMMIT_MESSAGE=\"Fix the \\\"build\\\"\\n\\nwith \\$HOME\"\n")
+		assert.Contains(t, output, "APP_RUNNER_TAGS=\"docker,linux\"\n")
+		assert.Contains(t, output, "APP_VERSION=\"2\"\n")
+		assert.NotContains(t, output, "APP_ENVIRONMENT")
+
+		ui = &cli.MockUi{}
+		c = &CiDumpMetaCommand{Ui: ui}
+
+		assert.Equal(t, 0, c.Run([]string{"-format", "template", "-template", tpl, "-file", "-"}))
+		assert.Equal(t, "export default {\"id\":\"42\",\"ref\":\"\",\"ref_name\":\"master\",\"tag\":\"\",\"stage\":\"\",\"job_name\":\"\"};\n", ui.OutputWriter.String())
+	})
+}
+
+func Test_Ci_Dump_Meta_Errors(t *testing.T) {
+	ui := &cli.MockUi{}
+	c := &CiDumpMetaCommand{Ui: ui}
+
+	assert.Equal(t, 1, c.Run([]string{"-file", fmt.Sprintf("%s/gitlab_helper/missing/ci.json", os.TempDir())}))
+	assert.Contains(t, ui.ErrorWriter.String(), "Error: open ")
+
+	assert.Equal(t, 1, c.Run([]string{"-format", "xml"}))
+	assert.Equal(t, 1, c.Run([]string{"-format", "template", "-file", "-"}))
+	assert.Equal(t, 1, c.Run([]string{"-format", "template", "-template", "missing.tpl", "-file", "-"}))
+}
+
 func Test_Ci_Dump_Meta_Help(t *testing.T) {
 	c := &CiDumpMetaCommand{
 		Ui: &cli.MockUi{},
@@ -0,0 +1,159 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"gopkg.in/yaml.v2"
+)
+
+type ConfigShowCommand struct {
+	Ui      cli.Ui
+	Verbose bool
+	File    string
+	Profile string
+	Output  string
+}
+
+func (c *ConfigShowCommand) Run(args []string) int {
+	flags := flag.NewFlagSet("config:show", flag.ContinueOnError)
+	flags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	flags.BoolVar(&c.Verbose, "verbose", false, "")
+	flags.StringVar(&c.File, "config", os.Getenv(helper.CONFIG_FILE_ENV), "The configuration file")
+	flags.StringVar(&c.Profile, "profile", os.Getenv(helper.CONFIG_PROFILE_ENV), "The configuration profile")
+	flags.StringVar(&c.Output, "output", "yaml", "The output format: yaml or json")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if c.Output != "yaml" && c.Output != "json" {
+		c.Ui.Error(fmt.Sprintf("Error: invalid output format: %s", c.Output))
+
+		return 1
+	}
+
+	config, err := helper.LoadConfig(c.File, c.Profile)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	var data []byte
+
+	if c.Output == "json" {
+		data, err = json.MarshalIndent(config.Masked(), "", "  ")
+	} else {
+		data, err = yaml.Marshal(config.Masked())
+
+		c.Ui.Output(fmt.Sprintf("# profile: %s", config.Profile))
+
+		for _, file := range config.Files {
+			c.Ui.Output(fmt.Sprintf("# file: %s", file))
+		}
+	}
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	c.Ui.Output(strings.TrimSpace(string(data)))
+
+	return 0
+}
+
+func (c *ConfigShowCommand) Synopsis() string {
+	return "Display the effective configuration."
+}
+
+func (c *ConfigShowCommand) Help() string {
+	helpText := `
+Usage: gitlab-ci-helper config:show [options]
+
+  Display the configuration used by the commands, the secrets (tokens and
+  passwords) are masked.
+
+Options:
+
+  -config=XX          The configuration file (default: env var GITLAB_CI_HELPER_CONFIG),
+                       the default files are not loaded if set
+  -profile=XX         The profile to use (default: env var GITLAB_CI_HELPER_PROFILE,
+                       then default)
+  -output=yaml        The output format: yaml or json
+  -verbose            Add verbose information to the output
+
+The settings are resolved with the following precedence: command options,
+env vars, the selected profile and the shared settings of the configuration
+files. The files are loaded in this order, if they exist:
+
+  ~/.config/gitlab-ci-helper/config.yml
+  .gitlab-ci-helper.yml
+  .gitlab-ci-helper.json
+
+The files of the working directory can be committed in the project, so the
+hosts receiving the credentials are not read from them: gitlab.host,
+s3.endpoint, hipchat.server, slack.webhook_url, mattermost.webhook_url,
+mattermost.url, webhook.url and mail.host. They are set by the env vars, the
+user file or the -config file.
+
+Example:
+
+  gitlab:
+    host: https://gitlab.example.com
+  s3:
+    region: eu-west-1
+  profiles:
+    production:
+      gitlab:
+        token: XXX
+      s3:
+        bucket: artifacts-production
+
+Env vars:
+
+  gitlab.host         GITLAB_HOST
+  gitlab.token        GITLAB_TOKEN
+  gitlab.api_path     GITLAB_API_PATH (default: /api/v4)
+  s3.region           AWS_REGION
+  s3.endpoint         AWS_ENDPOINT
+  s3.profile          AWS_PROFILE (default: default)
+  s3.bucket           AWS_BUCKET
+  flowdock.token      FLOWDOCK_SOURCE_TOKEN
+  hipchat.token       HIPCHAT_TOKEN
+  hipchat.server      HIPCHAT_SERVER (default: https://api.hipchat.com)
+  slack.webhook_url   SLACK_WEBHOOK_URL
+  slack.token         SLACK_TOKEN
+  slack.channel       SLACK_CHANNEL
+  mattermost.webhook_url MATTERMOST_WEBHOOK_URL
+  mattermost.url      MATTERMOST_URL
+  mattermost.token    MATTERMOST_TOKEN
+  mattermost.channel  MATTERMOST_CHANNEL
+  webhook.url         WEBHOOK_URL
+  webhook.secret      WEBHOOK_SECRET
+  mail.subject        MAIL_SUBJECT
+  mail.sender         MAIL_SENDER
+  mail.dest           MAIL_DEST (comma separated)
+  mail.host           MAIL_HOST
+  mail.username       MAIL_USERNAME
+  mail.password       MAIL_PASSWORD
+`
+
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,57 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ConfigShowCommand(t *testing.T) {
+	fp, err := ioutil.TempFile("", "gitlab_ci_helper_config")
+	assert.NoError(t, err)
+
+	defer os.Remove(fp.Name())
+
+	fp.WriteString("gitlab:\n  host: https://gitlab.example.com\n  token: my-secret-gitlab-token\n")
+	fp.Close()
+
+	ui := &cli.MockUi{}
+	c := &ConfigShowCommand{Ui: ui}
+
+	assert.Equal(t, 0, c.Run([]string{"-config", fp.Name()}))
+	assert.Contains(t, ui.OutputWriter.String(), "# profile: default\n# file: "+fp.Name())
+	assert.Contains(t, ui.OutputWriter.String(), "host: https://gitlab.example.com")
+	assert.Contains(t, ui.OutputWriter.String(), "token: '****oken'")
+	assert.NotContains(t, ui.OutputWriter.String(), "my-secret-gitlab-token")
+
+	ui = &cli.MockUi{}
+	c = &ConfigShowCommand{Ui: ui}
+
+	assert.Equal(t, 1, c.Run([]string{"-config", fp.Name(), "-profile", "production"}))
+	assert.Contains(t, ui.ErrorWriter.String(), "Unable to find the profile production")
+}
+
+func Test_ConfigShowCommand_Help(t *testing.T) {
+	c := &ConfigShowCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.True(t, len(c.Help()) > 0)
+	assert.True(t, len(c.Synopsis()) > 0)
+}
+
+func Test_ConfigShowCommand_InvalidRun(t *testing.T) {
+	c := &ConfigShowCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.Equal(t, 1, c.Run([]string{"--foobar"}))
+}
@@ -0,0 +1,160 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/rande/gitlab-ci-helper/pipeline"
+)
+
+type PipelineStatusCommand struct {
+	Ui         cli.Ui
+	Verbose    bool
+	Jobs       bool
+	Project    string
+	PipelineId string
+	BuildRef   string
+}
+
+func (c *PipelineStatusCommand) Run(args []string) int {
+
+	flags := flag.NewFlagSet("pipeline:status", flag.ContinueOnError)
+	flags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	flags.BoolVar(&c.Verbose, "verbose", false, "")
+	flags.BoolVar(&c.Jobs, "jobs", false, "Display the jobs of each stage")
+	flags.StringVar(&c.Project, "project", os.Getenv("CI_PROJECT_ID"), "The project")
+	flags.StringVar(&c.PipelineId, "pipeline", os.Getenv("CI_PIPELINE_ID"), "The pipeline")
+	flags.StringVar(&c.BuildRef, "ref", helper.GetEnv("CI_COMMIT_SHA", os.Getenv("CI_BUILD_REF")), "The commit")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.PipelineId) == 0 && len(c.BuildRef) == 0 {
+		c.Ui.Error("Error: the pipeline or the commit is required")
+
+		return 1
+	}
+
+	config, err := helper.NewConfig()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	client := gitlab.NewClient(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
+
+	project, err := helper.GetProject(c.Project, client)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Unable to fetch the project: %s", err.Error()))
+
+		return 1
+	}
+
+	if c.Verbose {
+		c.Ui.Output(fmt.Sprintf("Found project: %s/%s (id: %d)", project.Namespace.Name, project.Name, project.Id))
+	}
+
+	var jobs []*gitlab.Job
+
+	if len(c.PipelineId) > 0 {
+		jobs, err = helper.GetPipelineJobs(project, c.PipelineId, client)
+	} else {
+		jobs, err = helper.GetCommitJobs(project, c.BuildRef, client)
+	}
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	status := pipeline.Aggregate(jobs)
+
+	if len(c.PipelineId) > 0 {
+		c.Ui.Output(fmt.Sprintf("Pipeline %s: %s%s", c.PipelineId, status.Status, warnings(status.Warnings)))
+	} else {
+		c.Ui.Output(fmt.Sprintf("Commit %s: %s%s", c.BuildRef, status.Status, warnings(status.Warnings)))
+	}
+
+	for _, st := range status.Stages {
+		c.Ui.Output(fmt.Sprintf(" > %-20s %s%s", st.Name, st.Status, warnings(st.Warnings)))
+
+		if !c.Jobs {
+			continue
+		}
+
+		for _, j := range st.Jobs {
+			allowFailure := ""
+			if j.AllowFailure {
+				allowFailure = " (allowed to fail)"
+			}
+
+			c.Ui.Output(fmt.Sprintf("     % 6d - %-25s %s%s", j.Id, j.Name, j.Status, allowFailure))
+		}
+	}
+
+	return 0
+}
+
+func warnings(w bool) string {
+	if w {
+		return " (with warnings)"
+	}
+
+	return ""
+}
+
+func (c *PipelineStatusCommand) Synopsis() string {
+	return "Display the status of a pipeline and of its stages."
+}
+
+func (c *PipelineStatusCommand) Help() string {
+	helpText := `
+Usage: gitlab-ci-helper pipeline:status [options]
+
+  Display the status of a pipeline and of its stages, computed with the GitLab
+  rules:
+    - only the latest attempt of a retried job is considered,
+    - a failed job allowed to fail does not fail the pipeline, the pipeline
+      passes with warnings,
+    - a manual job allowed to fail does not block the pipeline,
+    - the skipped jobs are ignored.
+
+  If no pipeline is provided, the status is computed from all the pipelines of
+  the commit.
+
+Options:
+
+  -project            The project, an id or a path (default: env var CI_PROJECT_ID)
+  -pipeline           The pipeline (default: env var CI_PIPELINE_ID)
+  -ref                The commit, used if no pipeline is provided (default:
+                        9.x: CI_COMMIT_SHA or 8.x: CI_BUILD_REF)
+  -jobs               Display the jobs of each stage
+  -verbose            Add verbose information to the output
+
+Credentials are retrieved from environment:
+
+  GITLAB_HOST         The gitlab host
+  GITLAB_TOKEN        The user's token
+  GITLAB_API_PATH     (optional) the api path, default to: "/api/v4"
+
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,78 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Pipeline_Status(t *testing.T) {
+	fpProject, err := os.Open("../fixtures/project.json")
+	assert.NoError(t, err)
+
+	fpJobs, err := os.Open("../fixtures/pipeline_12_jobs.json")
+	assert.NoError(t, err)
+
+	reqs := []*helper.FakeRequest{
+		{
+			Path:   "/api/v4/projects/3",
+			Method: "GET",
+			Response: &http.Response{
+				Body: fpProject,
+			},
+		},
+		{
+			Path:   "/api/v4/projects/3/pipelines/12/jobs",
+			Method: "GET",
+			Response: &http.Response{
+				Body: fpJobs,
+			},
+		},
+	}
+
+	helper.WrapperTestCommand(reqs, map[string]string{}, t, func(ts *httptest.Server) {
+		ui := &cli.MockUi{}
+		c := &PipelineStatusCommand{
+			Ui: ui,
+		}
+
+		code := c.Run([]string{"-project", "3", "-pipeline", "12", "-jobs"})
+
+		assert.Equal(t, 0, code, ui.ErrorWriter.String())
+
+		output := ui.OutputWriter.String()
+
+		assert.Contains(t, output, "Pipeline 12: failed\n")
+		assert.Contains(t, output, " > test                 failed\n")
+		assert.Contains(t, output, "9 - brakeman                  failed\n")
+		assert.Contains(t, output, "69 - rubocop                   canceled\n")
+	})
+}
+
+func Test_Pipeline_Status_Help(t *testing.T) {
+	c := &PipelineStatusCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.True(t, len(c.Help()) > 0)
+	assert.True(t, len(c.Synopsis()) > 0)
+}
+
+func Test_Pipeline_Status_InvalidRun(t *testing.T) {
+	c := &PipelineStatusCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.Equal(t, 1, c.Run([]string{"--foobar"}))
+	assert.Equal(t, 1, c.Run([]string{"-pipeline", "", "-ref", ""}))
+}
@@ -0,0 +1,368 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/rande/gitlab-ci-helper/pipeline"
+)
+
+type PipelineTriggerCommand struct {
+	Ui            cli.Ui
+	Verbose       bool
+	Project       string
+	Ref           string
+	Variables     helper.Paths
+	Token         string
+	Wait          bool
+	Artifacts     string
+	ArtifactsJobs helper.Paths
+
+	WaitOptions
+}
+
+func (c *PipelineTriggerCommand) Run(args []string) int {
+
+	flags := flag.NewFlagSet("pipeline:trigger", flag.ContinueOnError)
+	flags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	c.Variables = make(helper.Paths, 0)
+	c.ArtifactsJobs = make(helper.Paths, 0)
+
+	flags.BoolVar(&c.Verbose, "verbose", false, "")
+	flags.StringVar(&c.Project, "project", "", "The target project")
+	flags.StringVar(&c.Ref, "ref", "", "The branch or the tag of the target project")
+	flags.Var(&c.Variables, "var", "-var KEY=value")
+	flags.StringVar(&c.Token, "token", os.Getenv("GITLAB_TRIGGER_TOKEN"), "The trigger token")
+	flags.BoolVar(&c.Wait, "wait", false, "Wait for the pipeline to finish")
+	flags.StringVar(&c.Artifacts, "artifacts", "", "The path to extract the artifacts of the pipeline")
+	flags.Var(&c.ArtifactsJobs, "artifacts-job", "The job to download the artifacts from")
+
+	c.WaitOptions.Flags(flags)
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.Project) == 0 || len(c.Ref) == 0 {
+		c.Ui.Error("Error: the project and the ref are required")
+
+		return 1
+	}
+
+	if err := c.WaitOptions.Validate(); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	variables, err := c.variables()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	config, err := helper.NewConfig()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	// the trigger token cannot read the pipeline, the API token is checked
+	// before creating a pipeline that could not be followed
+	if (c.Wait || len(c.Artifacts) > 0) && len(config.Gitlab.Token) == 0 {
+		c.Ui.Error("Error: the GITLAB_TOKEN is required with -wait or -artifacts")
+
+		return 1
+	}
+
+	client := gitlab.NewClient(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
+
+	var project *gitlab.Project
+
+	// a trigger token is enough to create the pipeline of a project id
+	if id, err := strconv.Atoi(c.Project); err == nil && len(config.Gitlab.Token) == 0 {
+		project = &gitlab.Project{Id: id}
+	} else if project, err = helper.GetProject(c.Project, client); err != nil {
+		c.Ui.Error(fmt.Sprintf("Unable to fetch the project: %s", err.Error()))
+
+		return 1
+	}
+
+	if c.Verbose && project.Namespace != nil {
+		c.Ui.Output(fmt.Sprintf("Found project: %s/%s (id: %d)", project.Namespace.Name, project.Name, project.Id))
+	}
+
+	p, err := c.trigger(project, variables, client)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Pipeline %d created (ref: %s, status: %s)", p.Id, p.Ref, p.Status))
+
+	if len(p.WebUrl) > 0 {
+		c.Ui.Output(fmt.Sprintf("   %s", p.WebUrl))
+	}
+
+	if !c.Wait && len(c.Artifacts) == 0 {
+		return 0
+	}
+
+	pipelineId := strconv.Itoa(p.Id)
+	w := c.WaitOptions.Start()
+	last := ""
+
+	for {
+		// the status of the pipeline is used as is, a pipeline can fail
+		// without any job, ie: an invalid configuration
+		current, err := client.Pipeline(project.Id, p.Id)
+
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+			return 1
+		}
+
+		jobs, err := helper.GetPipelineJobs(project, pipelineId, client)
+
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+			return 1
+		}
+
+		status := pipeline.Aggregate(jobs)
+
+		if current.Status != last {
+			c.Ui.Output(fmt.Sprintf("Pipeline %s: %s", pipelineId, current.Status))
+			last = current.Status
+		}
+
+		w.Report(c.Ui, status.Jobs)
+
+		if pipeline.IsFinished(current.Status) {
+			if current.Status != pipeline.SUCCESS {
+				c.Ui.Error(fmt.Sprintf("Error: the pipeline %s has finished with the status %s", pipelineId, current.Status))
+
+				return WAIT_FAILED
+			}
+
+			if err := c.download(project, status.Jobs, client); err != nil {
+				c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+				return 1
+			}
+
+			return 0
+		}
+
+		if !w.Sleep() {
+			c.Ui.Error(fmt.Sprintf("Error: timeout after %s, pipeline %s: %s", c.Timeout, pipelineId, current.Status))
+
+			return WAIT_TIMEOUT
+		}
+	}
+}
+
+// variables parses the -var options, the UPSTREAM_PROJECT_ID and the
+// UPSTREAM_PIPELINE_ID variables are added when running in a job so the
+// downstream pipeline can retrieve the artifacts of the current pipeline.
+func (c *PipelineTriggerCommand) variables() (map[string]string, error) {
+	variables := map[string]string{}
+
+	upstream := map[string]string{
+		"UPSTREAM_PROJECT_ID":  os.Getenv("CI_PROJECT_ID"),
+		"UPSTREAM_PIPELINE_ID": os.Getenv("CI_PIPELINE_ID"),
+	}
+
+	for name, value := range upstream {
+		if len(value) > 0 {
+			variables[name] = value
+		}
+	}
+
+	for _, v := range c.Variables {
+		parts := strings.SplitN(v, "=", 2)
+
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			return nil, fmt.Errorf("Invalid variable: %s, the format must be KEY=value", v)
+		}
+
+		variables[parts[0]] = parts[1]
+	}
+
+	return variables, nil
+}
+
+// trigger creates the pipeline with the trigger token if provided, with the
+// API token otherwise.
+func (c *PipelineTriggerCommand) trigger(project *gitlab.Project, variables map[string]string, client gitlab.Client) (*gitlab.Pipeline, error) {
+	if len(c.Token) > 0 {
+		return client.TriggerPipeline(project.Id, &gitlab.TriggerPipelineOptions{
+			Token:     c.Token,
+			Ref:       c.Ref,
+			Variables: variables,
+		})
+	}
+
+	opts := &gitlab.CreatePipelineOptions{Ref: c.Ref}
+
+	for name, value := range variables {
+		opts.Variables = append(opts.Variables, &gitlab.PipelineVariable{Key: name, Value: value})
+	}
+
+	sort.Slice(opts.Variables, func(i, k int) bool {
+		return opts.Variables[i].Key < opts.Variables[k].Key
+	})
+
+	return client.CreatePipeline(project.Id, opts)
+}
+
+// download extracts the artifacts of the jobs into the -artifacts path, all
+// the jobs with artifacts are used if no -artifacts-job option is provided.
+func (c *PipelineTriggerCommand) download(project *gitlab.Project, jobs []*gitlab.Job, client gitlab.Client) error {
+	if len(c.Artifacts) == 0 {
+		return nil
+	}
+
+	names := map[string]bool{}
+	for _, n := range c.ArtifactsJobs {
+		names[n] = true
+	}
+
+	found := 0
+
+	for _, j := range jobs {
+		if len(names) > 0 && !names[j.Name] {
+			continue
+		}
+
+		if len(j.ArtifactsFile.Filename) == 0 {
+			if names[j.Name] {
+				return fmt.Errorf("The job %s has no artifacts", j.Name)
+			}
+
+			continue
+		}
+
+		c.Ui.Output(fmt.Sprintf("Extracting the artifacts of the job %s (id: %d) into %s", j.Name, j.Id, c.Artifacts))
+
+		if err := c.extract(project, j, client); err != nil {
+			return err
+		}
+
+		found++
+	}
+
+	if found < len(names) {
+		return fmt.Errorf("Unable to find the jobs %s in the pipeline", strings.Join(c.ArtifactsJobs, ", "))
+	}
+
+	return nil
+}
+
+func (c *PipelineTriggerCommand) extract(project *gitlab.Project, job *gitlab.Job, client gitlab.Client) error {
+	r, err := client.JobArtifacts(project.Id, job.Id)
+
+	if err != nil {
+		return err
+	}
+
+	defer r.Close()
+
+	fp, err := ioutil.TempFile("", "gitlab-ci-helper-artifacts")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(fp.Name())
+
+	_, err = io.Copy(fp, r)
+	fp.Close()
+
+	if err != nil {
+		return err
+	}
+
+	return helper.Unzip(fp.Name(), c.Artifacts)
+}
+
+func (c *PipelineTriggerCommand) Synopsis() string {
+	return "Trigger the pipeline of a project and wait for it."
+}
+
+func (c *PipelineTriggerCommand) Help() string {
+	helpText := `
+Usage: gitlab-ci-helper pipeline:trigger [options]
+
+  Create a pipeline on the ref of the project, the pipeline is created with
+  the trigger token if provided, with the API token otherwise. The CI_JOB_TOKEN
+  can be used as trigger token to link the pipeline to the current job.
+
+  When running in a job, the UPSTREAM_PROJECT_ID and UPSTREAM_PIPELINE_ID
+  variables are sent, the downstream jobs can retrieve the artifacts of the
+  current pipeline with the project:builds:artifacts -upstream option.
+
+  The -wait option polls the pipeline until it is finished and displays the
+  status changes of the jobs, the artifacts of the successful pipeline are
+  extracted if the -artifacts option is provided.
+
+  Exit codes:
+    0                 The pipeline has been created, or has passed with -wait
+    1                 An error has occurred
+    2                 The pipeline has failed, has been canceled or is blocked
+                        by a manual job
+    3                 The timeout has been reached
+
+Options:
+
+  -project            The target project, an id or a path (required)
+  -ref                The branch or the tag of the target project (required)
+  -var                A variable of the pipeline: KEY=value, can be repeated
+  -token              The trigger token (default: env var GITLAB_TRIGGER_TOKEN)
+  -wait               Wait for the pipeline to finish
+  -artifacts          The path to extract the artifacts of the pipeline, it
+                        implies -wait
+  -artifacts-job      The job to extract the artifacts from, can be repeated
+                        (default: all the jobs with artifacts)
+  -timeout            The maximum duration (default: 30m)
+  -interval           The delay between two checks (default: 10s)
+  -max-interval       The maximum delay between two checks (default: 1m)
+  -backoff            The delay multiplier (default: 1.5)
+  -verbose            Add verbose information to the output
+
+Credentials are retrieved from environment:
+
+  GITLAB_HOST         The gitlab host
+  GITLAB_TOKEN        The user's token, it is required to resolve a project
+                        path, to wait for the pipeline and to download the
+                        artifacts
+  GITLAB_API_PATH     (optional) the api path, default to: "/api/v4"
+
+`
+	return strings.TrimSpace(helpText)
+}
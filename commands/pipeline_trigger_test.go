@@ -0,0 +1,204 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Pipeline_Trigger(t *testing.T) {
+	ts := newWaitServer(t, map[string][]string{
+		"/api/v4/projects/3/pipeline": {
+			`{"id": 20, "ref": "master", "status": "created", "web_url": "http://example.com/diaspora/diaspora-project-site/pipelines/20"}`,
+		},
+	})
+	defer ts.Close()
+	defer os.Unsetenv("GITLAB_HOST")
+
+	os.Setenv("GITLAB_HOST", ts.URL)
+	os.Setenv("GITLAB_TOKEN", "token")
+	defer os.Unsetenv("GITLAB_TOKEN")
+
+	ui := &cli.MockUi{}
+	c := &PipelineTriggerCommand{
+		Ui: ui,
+	}
+
+	code := c.Run([]string{"-project", "3", "-ref", "master", "-var", "DEPLOY=true", "-verbose"})
+
+	assert.Equal(t, 0, code, ui.ErrorWriter.String())
+
+	expected := "Found project: Diaspora/Diaspora Project Site (id: 3)\nPipeline 20 created (ref: master, status: created)\n   http://example.com/diaspora/diaspora-project-site/pipelines/20\n"
+	assert.Equal(t, expected, ui.OutputWriter.String())
+}
+
+func Test_Pipeline_Trigger_Wait_Artifacts(t *testing.T) {
+	archive, err := ioutil.ReadFile("../fixtures/artifacts.zip")
+	assert.NoError(t, err)
+
+	ts := newWaitServer(t, map[string][]string{
+		"/api/v4/projects/3/trigger/pipeline": {
+			`{"id": 20, "ref": "master", "status": "pending"}`,
+		},
+		"/api/v4/projects/3/pipelines/20": {
+			`{"id": 20, "ref": "master", "status": "running"}`,
+			`{"id": 20, "ref": "master", "status": "success"}`,
+		},
+		"/api/v4/projects/3/pipelines/20/jobs": {
+			`[{"id": 21, "name": "build", "stage": "build", "status": "running"}, {"id": 22, "name": "deploy", "stage": "deploy", "status": "created"}]`,
+			`[{"id": 21, "name": "build", "stage": "build", "status": "success", "artifacts_file": {"filename": "artifacts.zip"}}, {"id": 22, "name": "deploy", "stage": "deploy", "status": "success"}]`,
+		},
+		"/api/v4/projects/3/jobs/21/artifacts": {
+			string(archive),
+		},
+	})
+	defer ts.Close()
+	defer os.Unsetenv("GITLAB_HOST")
+	defer os.Unsetenv("GITLAB_TOKEN")
+
+	dir, err := ioutil.TempDir("", "gitlab-ci-helper")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	clock := time.Date(2017, 8, 1, 10, 0, 0, 0, time.UTC)
+	delays := []time.Duration{}
+
+	os.Setenv("GITLAB_HOST", ts.URL)
+	os.Setenv("GITLAB_TOKEN", "token")
+
+	ui := &cli.MockUi{}
+	c := &PipelineTriggerCommand{
+		Ui:          ui,
+		WaitOptions: newWaitOptions(&clock, &delays),
+	}
+
+	code := c.Run([]string{"-project", "3", "-ref", "master", "-token", "trigger-token", "-artifacts", dir})
+
+	assert.Equal(t, 0, code, ui.ErrorWriter.String())
+	assert.Len(t, delays, 1)
+
+	output := ui.OutputWriter.String()
+	assert.Contains(t, output, "Pipeline 20: running\n")
+	assert.Contains(t, output, " >     21 - build                     running\n")
+	assert.Contains(t, output, " >     21 - build                     success\n")
+	assert.Contains(t, output, "Pipeline 20: success\n")
+	assert.Contains(t, output, "Extracting the artifacts of the job build (id: 21)")
+
+	assert.FileExists(t, filepath.Join(dir, "builds.json"))
+}
+
+func Test_Pipeline_Trigger_Wait_Failed(t *testing.T) {
+	ts := newWaitServer(t, map[string][]string{
+		"/api/v4/projects/3/trigger/pipeline": {
+			`{"id": 20, "ref": "master", "status": "pending"}`,
+		},
+		"/api/v4/projects/3/pipelines/20": {
+			`{"id": 20, "ref": "master", "status": "failed"}`,
+		},
+		"/api/v4/projects/3/pipelines/20/jobs": {
+			`[{"id": 21, "name": "build", "stage": "build", "status": "failed"}]`,
+		},
+	})
+	defer ts.Close()
+	defer os.Unsetenv("GITLAB_HOST")
+	defer os.Unsetenv("GITLAB_TOKEN")
+
+	os.Setenv("GITLAB_HOST", ts.URL)
+	os.Setenv("GITLAB_TOKEN", "token")
+
+	ui := &cli.MockUi{}
+	c := &PipelineTriggerCommand{
+		Ui: ui,
+	}
+
+	code := c.Run([]string{"-project", "3", "-ref", "master", "-token", "trigger-token", "-wait"})
+
+	assert.Equal(t, WAIT_FAILED, code)
+	assert.Contains(t, ui.ErrorWriter.String(), "Error: the pipeline 20 has finished with the status failed")
+}
+
+func Test_Pipeline_Trigger_Wait_Failed_Without_Jobs(t *testing.T) {
+	ts := newWaitServer(t, map[string][]string{
+		"/api/v4/projects/3/trigger/pipeline": {
+			`{"id": 20, "ref": "master", "status": "pending"}`,
+		},
+		"/api/v4/projects/3/pipelines/20": {
+			`{"id": 20, "ref": "master", "status": "failed"}`,
+		},
+		"/api/v4/projects/3/pipelines/20/jobs": {
+			`[]`,
+		},
+	})
+	defer ts.Close()
+	defer os.Unsetenv("GITLAB_HOST")
+	defer os.Unsetenv("GITLAB_TOKEN")
+
+	os.Setenv("GITLAB_HOST", ts.URL)
+	os.Setenv("GITLAB_TOKEN", "token")
+
+	clock := time.Date(2017, 8, 1, 10, 0, 0, 0, time.UTC)
+	delays := []time.Duration{}
+
+	ui := &cli.MockUi{}
+	c := &PipelineTriggerCommand{
+		Ui:          ui,
+		WaitOptions: newWaitOptions(&clock, &delays),
+	}
+
+	code := c.Run([]string{"-project", "3", "-ref", "master", "-token", "trigger-token", "-wait"})
+
+	assert.Equal(t, WAIT_FAILED, code)
+	assert.Len(t, delays, 0)
+	assert.Contains(t, ui.ErrorWriter.String(), "Error: the pipeline 20 has finished with the status failed")
+}
+
+func Test_Pipeline_Trigger_Wait_Without_Token(t *testing.T) {
+	ts := newWaitServer(t, map[string][]string{})
+	defer ts.Close()
+	defer os.Unsetenv("GITLAB_HOST")
+
+	os.Setenv("GITLAB_HOST", ts.URL)
+	os.Unsetenv("GITLAB_TOKEN")
+
+	for _, option := range []string{"-wait", "-artifacts=./artifacts"} {
+		ui := &cli.MockUi{}
+		c := &PipelineTriggerCommand{
+			Ui: ui,
+		}
+
+		// the pipeline is not created
+		code := c.Run([]string{"-project", "3", "-ref", "master", "-token", "trigger-token", option})
+
+		assert.Equal(t, 1, code)
+		assert.Contains(t, ui.ErrorWriter.String(), "Error: the GITLAB_TOKEN is required with -wait or -artifacts")
+	}
+}
+
+func Test_Pipeline_Trigger_Help(t *testing.T) {
+	c := &PipelineTriggerCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.True(t, len(c.Help()) > 0)
+	assert.True(t, len(c.Synopsis()) > 0)
+}
+
+func Test_Pipeline_Trigger_InvalidRun(t *testing.T) {
+	c := &PipelineTriggerCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.Equal(t, 1, c.Run([]string{"--foobar"}))
+	assert.Equal(t, 1, c.Run([]string{"-project", "3"}))
+	assert.Equal(t, 1, c.Run([]string{"-project", "3", "-ref", "master", "-var", "=value"}))
+}
@@ -0,0 +1,290 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/rande/gitlab-ci-helper/pipeline"
+)
+
+type PipelineWaitCommand struct {
+	Ui         cli.Ui
+	Verbose    bool
+	Project    string
+	PipelineId string
+	Trigger    string
+	Jobs       helper.Paths
+	Stages     helper.Paths
+	JobId      string
+
+	WaitOptions
+}
+
+func (c *PipelineWaitCommand) Run(args []string) int {
+
+	flags := flag.NewFlagSet("pipeline:wait", flag.ContinueOnError)
+	flags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	c.Jobs = make(helper.Paths, 0)
+	c.Stages = make(helper.Paths, 0)
+
+	flags.BoolVar(&c.Verbose, "verbose", false, "")
+	flags.StringVar(&c.Project, "project", os.Getenv("CI_PROJECT_ID"), "The project")
+	flags.StringVar(&c.PipelineId, "pipeline", os.Getenv("CI_PIPELINE_ID"), "The pipeline")
+	flags.StringVar(&c.Trigger, "trigger", "", "The trigger job creating the downstream pipeline")
+	flags.Var(&c.Jobs, "job", "The job to wait for")
+	flags.Var(&c.Stages, "stage", "The stage to wait for")
+	flags.StringVar(&c.JobId, "current-job", os.Getenv("CI_JOB_ID"), "The current job, it is never waited for")
+
+	c.WaitOptions.Flags(flags)
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.PipelineId) == 0 {
+		c.Ui.Error("Error: the pipeline is required")
+
+		return 1
+	}
+
+	if err := c.WaitOptions.Validate(); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	config, err := helper.NewConfig()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	client := gitlab.NewClient(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
+
+	project, err := helper.GetProject(c.Project, client)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Unable to fetch the project: %s", err.Error()))
+
+		return 1
+	}
+
+	if c.Verbose {
+		c.Ui.Output(fmt.Sprintf("Found project: %s/%s (id: %d)", project.Namespace.Name, project.Name, project.Id))
+	}
+
+	w := c.WaitOptions.Start()
+	last := ""
+
+	target, pipelineId := project, c.PipelineId
+	resolved := len(c.Trigger) == 0
+	checked := false
+
+	for {
+		var status *pipeline.Status
+
+		current := "waiting for the jobs"
+
+		if !resolved {
+			downstream, err := helper.GetDownstreamPipeline(project, c.PipelineId, c.Trigger, client)
+
+			if err != nil {
+				c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+				return 1
+			}
+
+			current = "waiting for the downstream pipeline"
+
+			if downstream != nil {
+				target, pipelineId = &gitlab.Project{Id: downstream.ProjectId}, strconv.Itoa(downstream.Id)
+				resolved = true
+
+				c.Ui.Output(fmt.Sprintf("Found the downstream pipeline %s (project: %d)", pipelineId, target.Id))
+			}
+		}
+
+		if resolved {
+			jobs, err := helper.GetPipelineJobs(target, pipelineId, client)
+
+			if err != nil {
+				c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+				return 1
+			}
+
+			// the jobs are created with the pipeline, an unknown job or
+			// stage would be waited for until the timeout
+			if !checked && len(jobs) > 0 {
+				if err := c.check(pipelineId, jobs); err != nil {
+					c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+					return 1
+				}
+
+				checked = true
+			}
+
+			if jobs = c.filter(jobs); len(jobs) > 0 {
+				status = pipeline.Aggregate(jobs)
+				current = fmt.Sprintf("%s (%d jobs)", status.Status, len(status.Jobs))
+			}
+		}
+
+		if current != last || c.Verbose {
+			c.Ui.Output(fmt.Sprintf("Pipeline %s: %s", pipelineId, current))
+			last = current
+		}
+
+		if status != nil {
+			w.Report(c.Ui, status.Jobs)
+		}
+
+		if status != nil && status.IsFinished() {
+			if !status.IsSuccessful() {
+				c.Ui.Error(fmt.Sprintf("Error: the pipeline %s has finished with the status %s", pipelineId, status.Status))
+
+				return WAIT_FAILED
+			}
+
+			return 0
+		}
+
+		if !w.Sleep() {
+			c.Ui.Error(fmt.Sprintf("Error: timeout after %s, pipeline %s: %s", c.Timeout, pipelineId, current))
+
+			return WAIT_TIMEOUT
+		}
+	}
+}
+
+// check returns an error if a -job or a -stage option does not match any job
+// of the pipeline.
+func (c *PipelineWaitCommand) check(pipelineId string, jobs []*gitlab.Job) error {
+	names := map[string]bool{}
+	stages := map[string]bool{}
+
+	for _, j := range jobs {
+		names[j.Name] = true
+		stages[j.Stage] = true
+	}
+
+	unknown := []string{}
+
+	for _, n := range c.Jobs {
+		if !names[n] {
+			unknown = append(unknown, fmt.Sprintf("job %s", n))
+		}
+	}
+
+	for _, s := range c.Stages {
+		if !stages[s] {
+			unknown = append(unknown, fmt.Sprintf("stage %s", s))
+		}
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("the pipeline %s has no %s", pipelineId, strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// filter returns the jobs matching the -job and -stage options, the current
+// job is removed as it cannot finish while waiting.
+func (c *PipelineWaitCommand) filter(jobs []*gitlab.Job) []*gitlab.Job {
+	names := map[string]bool{}
+	for _, n := range c.Jobs {
+		names[n] = true
+	}
+
+	stages := map[string]bool{}
+	for _, s := range c.Stages {
+		stages[s] = true
+	}
+
+	filtered := []*gitlab.Job{}
+
+	for _, j := range jobs {
+		if strconv.Itoa(j.Id) == c.JobId {
+			continue
+		}
+
+		if (len(names) > 0 || len(stages) > 0) && !names[j.Name] && !stages[j.Stage] {
+			continue
+		}
+
+		filtered = append(filtered, j)
+	}
+
+	return filtered
+}
+
+func (c *PipelineWaitCommand) Synopsis() string {
+	return "Wait for the jobs of a pipeline to finish."
+}
+
+func (c *PipelineWaitCommand) Help() string {
+	helpText := `
+Usage: gitlab-ci-helper pipeline:wait [options]
+
+  Poll the GitLab API until the jobs of a pipeline are finished, the status is
+  computed like pipeline:status and the status changes of the jobs are
+  displayed. The delay between two checks is multiplied by the backoff after
+  each check.
+
+  By default all the jobs of the pipeline are waited for, use the -job or the
+  -stage options to wait for the jobs of the current pipeline, the jobs of the
+  next stages will not start before the current job is finished.
+
+  The -trigger option waits for the downstream pipeline created by the trigger
+  job, the pipeline can belong to another project.
+
+  Exit codes:
+    0                 The jobs have passed
+    1                 An error has occurred
+    2                 The jobs have failed, have been canceled or are blocked
+                        by a manual job
+    3                 The timeout has been reached
+
+Options:
+
+  -project            The project, an id or a path (default: env var CI_PROJECT_ID)
+  -pipeline           The pipeline (default: env var CI_PIPELINE_ID)
+  -trigger            The name of the trigger job creating the downstream pipeline
+  -job                The name of a job to wait for, can be repeated, an error is
+                        returned if the pipeline has no such job
+  -stage              The name of a stage to wait for, can be repeated, an error is
+                        returned if the pipeline has no such stage
+  -current-job        The current job, it is never waited for (default: env var CI_JOB_ID)
+  -timeout            The maximum duration (default: 30m)
+  -interval           The delay between two checks (default: 10s)
+  -max-interval       The maximum delay between two checks (default: 1m)
+  -backoff            The delay multiplier (default: 1.5)
+  -verbose            Add verbose information to the output
+
+Credentials are retrieved from environment:
+
+  GITLAB_HOST         The gitlab host
+  GITLAB_TOKEN        The user's token
+  GITLAB_API_PATH     (optional) the api path, default to: "/api/v4"
+
+`
+	return strings.TrimSpace(helpText)
+}
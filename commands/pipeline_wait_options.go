@@ -0,0 +1,114 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+)
+
+// the exit codes of the commands waiting for a pipeline, 1 is used for the
+// errors
+const (
+	WAIT_FAILED  = 2
+	WAIT_TIMEOUT = 3
+)
+
+// WaitOptions contains the polling settings shared by the pipeline:wait and
+// pipeline:trigger commands.
+type WaitOptions struct {
+	Timeout     time.Duration
+	Interval    time.Duration
+	MaxInterval time.Duration
+	Backoff     float64
+
+	sleep func(d time.Duration)
+	now   func() time.Time
+}
+
+func (o *WaitOptions) Flags(flags *flag.FlagSet) {
+	flags.DurationVar(&o.Timeout, "timeout", 30*time.Minute, "The maximum duration")
+	flags.DurationVar(&o.Interval, "interval", 10*time.Second, "The delay between two checks")
+	flags.DurationVar(&o.MaxInterval, "max-interval", time.Minute, "The maximum delay between two checks")
+	flags.Float64Var(&o.Backoff, "backoff", 1.5, "The delay multiplier")
+}
+
+func (o *WaitOptions) Validate() error {
+	if o.Interval <= 0 || o.Backoff < 1 {
+		return errors.New("the interval must be positive and the backoff greater or equal to 1")
+	}
+
+	return nil
+}
+
+// Start starts the timeout, the returned waiter is used between two checks.
+func (o *WaitOptions) Start() *waiter {
+	if o.sleep == nil {
+		o.sleep = time.Sleep
+	}
+
+	if o.now == nil {
+		o.now = time.Now
+	}
+
+	return &waiter{
+		options:  o,
+		deadline: o.now().Add(o.Timeout),
+		delay:    o.Interval,
+		jobs:     map[int]string{},
+	}
+}
+
+type waiter struct {
+	options  *WaitOptions
+	deadline time.Time
+	delay    time.Duration
+
+	// the last known status of each job
+	jobs map[int]string
+}
+
+// Sleep waits before the next check, the delay is multiplied by the backoff.
+// It returns false if the timeout is reached.
+func (w *waiter) Sleep() bool {
+	remaining := w.deadline.Sub(w.options.now())
+
+	if remaining <= 0 {
+		return false
+	}
+
+	delay := w.delay
+	if delay > remaining {
+		delay = remaining
+	}
+
+	w.options.sleep(delay)
+
+	w.delay = time.Duration(float64(w.delay) * w.options.Backoff)
+	if w.delay > w.options.MaxInterval {
+		w.delay = w.options.MaxInterval
+	}
+
+	return true
+}
+
+// Report outputs the jobs whose status has changed since the previous check.
+func (w *waiter) Report(ui cli.Ui, jobs []*gitlab.Job) {
+	for _, j := range jobs {
+		if w.jobs[j.Id] == j.Status {
+			continue
+		}
+
+		w.jobs[j.Id] = j.Status
+
+		ui.Output(fmt.Sprintf(" > % 6d - %-25s %s", j.Id, j.Name, j.Status))
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+// newWaitServer returns a GitLab server, each call to a path returns the next
+// response of the list, the last one is repeated.
+func newWaitServer(t *testing.T, responses map[string][]string) *httptest.Server {
+	project, err := ioutil.ReadFile("../fixtures/project.json")
+	assert.NoError(t, err)
+
+	calls := map[string]int{}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v4/projects/3" {
+			w.Write(project)
+
+			return
+		}
+
+		list, ok := responses[r.URL.Path]
+
+		if !ok {
+			t.Errorf("Unexpected request: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		i := calls[r.URL.Path]
+		if i >= len(list) {
+			i = len(list) - 1
+		}
+
+		calls[r.URL.Path]++
+
+		w.Write([]byte(list[i]))
+	}))
+}
+
+func newWaitCommand(ts *httptest.Server, ui cli.Ui) (*PipelineWaitCommand, *[]time.Duration) {
+	os.Setenv("GITLAB_HOST", ts.URL)
+
+	clock := time.Date(2017, 8, 1, 10, 0, 0, 0, time.UTC)
+	delays := []time.Duration{}
+
+	c := &PipelineWaitCommand{
+		Ui:          ui,
+		WaitOptions: newWaitOptions(&clock, &delays),
+	}
+
+	return c, &delays
+}
+
+// newWaitOptions returns options with a fake clock, the sleep delays are
+// recorded.
+func newWaitOptions(clock *time.Time, delays *[]time.Duration) WaitOptions {
+	return WaitOptions{
+		sleep: func(d time.Duration) {
+			*delays = append(*delays, d)
+			*clock = clock.Add(d)
+		},
+		now: func() time.Time {
+			return *clock
+		},
+	}
+}
+
+func Test_Pipeline_Wait(t *testing.T) {
+	ts := newWaitServer(t, map[string][]string{
+		"/api/v4/projects/3/pipelines/12/jobs": {
+			`[{"id": 1, "name": "test", "stage": "test", "status": "running"}, {"id": 2, "name": "lint", "stage": "test", "status": "pending", "allow_failure": true}, {"id": 3, "name": "wait", "stage": "test", "status": "running"}]`,
+			`[{"id": 1, "name": "test", "stage": "test", "status": "running"}, {"id": 2, "name": "lint", "stage": "test", "status": "failed", "allow_failure": true}, {"id": 3, "name": "wait", "stage": "test", "status": "running"}]`,
+			`[{"id": 1, "name": "test", "stage": "test", "status": "success"}, {"id": 2, "name": "lint", "stage": "test", "status": "failed", "allow_failure": true}, {"id": 3, "name": "wait", "stage": "test", "status": "running"}]`,
+		},
+	})
+	defer ts.Close()
+	defer os.Unsetenv("GITLAB_HOST")
+
+	ui := &cli.MockUi{}
+	c, delays := newWaitCommand(ts, ui)
+
+	code := c.Run([]string{"-project", "3", "-pipeline", "12", "-current-job", "3", "-stage", "test", "-interval", "10s", "-backoff", "2", "-max-interval", "15s"})
+
+	assert.Equal(t, 0, code, ui.ErrorWriter.String())
+	assert.Equal(t, []time.Duration{10 * time.Second, 15 * time.Second}, *delays)
+	assert.Contains(t, ui.OutputWriter.String(), "Pipeline 12: running (2 jobs)\n")
+	assert.Contains(t, ui.OutputWriter.String(), "Pipeline 12: success (2 jobs)\n")
+	assert.NotContains(t, ui.OutputWriter.String(), " wait ")
+}
+
+func Test_Pipeline_Wait_Failed(t *testing.T) {
+	ts := newWaitServer(t, map[string][]string{
+		"/api/v4/projects/3/pipelines/12/jobs": {
+			`[{"id": 1, "name": "test", "stage": "test", "status": "failed"}, {"id": 2, "name": "lint", "stage": "test", "status": "running"}]`,
+		},
+	})
+	defer ts.Close()
+	defer os.Unsetenv("GITLAB_HOST")
+
+	ui := &cli.MockUi{}
+	c, delays := newWaitCommand(ts, ui)
+
+	code := c.Run([]string{"-project", "3", "-pipeline", "12", "-job", "test"})
+
+	assert.Equal(t, WAIT_FAILED, code)
+	assert.Len(t, *delays, 0)
+	assert.Contains(t, ui.ErrorWriter.String(), "Error: the pipeline 12 has finished with the status failed")
+}
+
+func Test_Pipeline_Wait_Timeout(t *testing.T) {
+	ts := newWaitServer(t, map[string][]string{
+		"/api/v4/projects/3/pipelines/12/jobs": {
+			`[{"id": 1, "name": "test", "stage": "test", "status": "running"}]`,
+		},
+	})
+	defer ts.Close()
+	defer os.Unsetenv("GITLAB_HOST")
+
+	ui := &cli.MockUi{}
+	c, delays := newWaitCommand(ts, ui)
+
+	code := c.Run([]string{"-project", "3", "-pipeline", "12", "-timeout", "25s", "-interval", "10s", "-backoff", "1"})
+
+	assert.Equal(t, WAIT_TIMEOUT, code)
+	assert.Equal(t, []time.Duration{10 * time.Second, 10 * time.Second, 5 * time.Second}, *delays)
+	assert.Contains(t, ui.ErrorWriter.String(), "Error: timeout after 25s, pipeline 12: running (1 jobs)")
+}
+
+func Test_Pipeline_Wait_Unknown_Filter(t *testing.T) {
+	ts := newWaitServer(t, map[string][]string{
+		"/api/v4/projects/3/pipelines/12/jobs": {
+			`[{"id": 1, "name": "test", "stage": "test", "status": "running"}]`,
+		},
+	})
+	defer ts.Close()
+	defer os.Unsetenv("GITLAB_HOST")
+
+	ui := &cli.MockUi{}
+	c, delays := newWaitCommand(ts, ui)
+
+	code := c.Run([]string{"-project", "3", "-pipeline", "12", "-job", "test", "-job", "tset", "-stage", "deploy"})
+
+	assert.Equal(t, 1, code)
+	assert.Len(t, *delays, 0)
+	assert.Contains(t, ui.ErrorWriter.String(), "Error: the pipeline 12 has no job tset, stage deploy")
+}
+
+func Test_Pipeline_Wait_Trigger(t *testing.T) {
+	ts := newWaitServer(t, map[string][]string{
+		"/api/v4/projects/3/pipelines/12/bridges": {
+			`[{"id": 5, "name": "deploy", "status": "created", "downstream_pipeline": null}]`,
+			`[{"id": 5, "name": "deploy", "status": "running", "downstream_pipeline": {"id": 20, "project_id": 4}}]`,
+		},
+		"/api/v4/projects/4/pipelines/20/jobs": {
+			`[{"id": 21, "name": "deploy", "stage": "deploy", "status": "success"}]`,
+		},
+	})
+	defer ts.Close()
+	defer os.Unsetenv("GITLAB_HOST")
+
+	ui := &cli.MockUi{}
+	c, delays := newWaitCommand(ts, ui)
+
+	code := c.Run([]string{"-project", "3", "-pipeline", "12", "-trigger", "deploy"})
+
+	assert.Equal(t, 0, code, ui.ErrorWriter.String())
+	assert.Len(t, *delays, 1)
+	assert.Contains(t, ui.OutputWriter.String(), "Pipeline 12: waiting for the downstream pipeline\n")
+	assert.Contains(t, ui.OutputWriter.String(), "Found the downstream pipeline 20 (project: 4)\n")
+	assert.Contains(t, ui.OutputWriter.String(), "Pipeline 20: success (1 jobs)\n")
+}
+
+func Test_Pipeline_Wait_Help(t *testing.T) {
+	c := &PipelineWaitCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.True(t, len(c.Help()) > 0)
+	assert.True(t, len(c.Synopsis()) > 0)
+}
+
+func Test_Pipeline_Wait_InvalidRun(t *testing.T) {
+	c := &PipelineWaitCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.Equal(t, 1, c.Run([]string{"--foobar"}))
+	assert.Equal(t, 1, c.Run([]string{"-pipeline", ""}))
+	assert.Equal(t, 1, c.Run([]string{"-pipeline", "12", "-backoff", "0.5"}))
+}
@@ -6,6 +6,7 @@
 package commands
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -14,8 +15,8 @@ import (
 	"strings"
 
 	"github.com/mitchellh/cli"
-	gitlab "gopkg.in/plouc/go-gitlab-client.v1"
 	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
 )
 
 type ProjectBuildArtifactCommand struct {
@@ -26,7 +27,13 @@ type ProjectBuildArtifactCommand struct {
 	BuildId       string
 	Job           string
 	Ref           string
+	RefName       string
+	Status        string
+	PipelineId    string
+	Upstream      bool
 	Project       string
+	Verify        bool
+	Sha256        string
 }
 
 func (c *ProjectBuildArtifactCommand) Run(args []string) int {
@@ -43,7 +50,13 @@ func (c *ProjectBuildArtifactCommand) Run(args []string) int {
 
 	flags.StringVar(&c.Job, "job", "", "The job to search the artifacts")
 	flags.StringVar(&c.Ref, "ref", helper.GetEnv("CI_COMMIT_SHA", os.Getenv("CI_BUILD_REF")), "The reference (sha1) to search the artifacts")
+	flags.StringVar(&c.RefName, "ref-name", "", "The reference name (branch or tag) to search the latest artifacts")
+	flags.StringVar(&c.Status, "status", "", "The job's status (default: success with -ref-name, any status otherwise)")
+	flags.StringVar(&c.PipelineId, "pipeline", "", "The pipeline to search the artifacts")
+	flags.BoolVar(&c.Upstream, "upstream", false, "Search the artifacts in the upstream pipeline")
 	flags.StringVar(&c.Project, "project", os.Getenv("CI_PROJECT_ID"), "The project reference")
+	flags.BoolVar(&c.Verify, "verify", false, "Verify the artifacts before the extraction")
+	flags.StringVar(&c.Sha256, "sha256", "", "The expected sha256 checksum of the artifacts")
 
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -59,10 +72,21 @@ func (c *ProjectBuildArtifactCommand) Run(args []string) int {
 		return 1
 	}
 
-	config := helper.NewConfig()
-	client := gitlab.NewGitlab(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
+	if c.Upstream && len(c.PipelineId) == 0 && len(c.BuildId) == 0 {
+		c.PipelineId = os.Getenv("UPSTREAM_PIPELINE_ID")
 
-	project, err := helper.GetProject(c.Project, client)
+		if len(c.PipelineId) == 0 {
+			c.Ui.Error(fmt.Sprintf("Error: %s", "The UPSTREAM_PIPELINE_ID variable is not set, -upstream only works in a pipeline created by pipeline:trigger"))
+
+			return 1
+		}
+
+		if len(os.Getenv("UPSTREAM_PROJECT_ID")) > 0 {
+			c.Project = os.Getenv("UPSTREAM_PROJECT_ID")
+		}
+	}
+
+	config, err := helper.NewConfig()
 
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
@@ -70,35 +94,30 @@ func (c *ProjectBuildArtifactCommand) Run(args []string) int {
 		return 1
 	}
 
-	c.Ui.Output(fmt.Sprintf("Found project: %s/%s (id: %d)", project.Namespace.Name, project.Name, project.Id))
+	client := gitlab.NewClient(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
 
-	var build *gitlab.Build
+	project, err := helper.GetProject(c.Project, client)
 
-	if len(c.BuildId) > 0 {
-		build, err = helper.GetBuild(project, c.BuildId, client)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
 
-		if err != nil {
-			c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+		return 1
+	}
 
-			return 1
-		}
+	c.Ui.Output(fmt.Sprintf("Found project: %s/%s (id: %d)", project.Namespace.Name, project.Name, project.Id))
 
-	} else if len(c.Ref) > 0 {
-		builds, err := client.ProjectCommitBuilds(strconv.FormatInt(int64(project.Id), 10), c.Ref)
+	var build *gitlab.Job
 
-		if err != nil {
-			c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+	if len(c.BuildId) > 0 {
+		build, err = helper.GetJob(project, c.BuildId, client)
+	} else {
+		build, err = c.findJob(project, client)
+	}
 
-			return 1
-		}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
 
-		for _, b := range builds {
-			fmt.Printf("\nname: %s, job: %s\n", b.Name, c.Job)
-			if b.Name == c.Job {
-				build = b
-				break
-			}
-		}
+		return 1
 	}
 
 	if build == nil {
@@ -117,7 +136,9 @@ func (c *ProjectBuildArtifactCommand) Run(args []string) int {
 		return 1
 	}
 
-	r, err := client.ProjectBuildArtifacts(strconv.FormatInt(int64(project.Id), 10), strconv.FormatInt(int64(build.Id), 10))
+	defer fp.Close()
+
+	r, err := client.JobArtifacts(project.Id, build.Id)
 
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
@@ -125,6 +146,8 @@ func (c *ProjectBuildArtifactCommand) Run(args []string) int {
 		return 1
 	}
 
+	defer r.Close()
+
 	c.Ui.Output(fmt.Sprintf("Downloading artifacts... (%s)", fp.Name()))
 	written, err := io.Copy(fp, r)
 
@@ -140,6 +163,20 @@ func (c *ProjectBuildArtifactCommand) Run(args []string) int {
 		return 1
 	}
 
+	fp.Close()
+
+	// an expected checksum is always verified
+	if c.Verify || len(c.Sha256) > 0 {
+		if err := c.verify(build, written); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error: the artifacts are corrupted, %s", err.Error()))
+
+			// the corrupted file must not be used by a later step
+			os.Remove(c.ArtifactsFile)
+
+			return EXIT_CHECKSUM_MISMATCH
+		}
+	}
+
 	if len(c.ExtractPath) > 0 {
 		c.Ui.Output(fmt.Sprintf("Extracting package... (%s)", c.ExtractPath))
 
@@ -160,6 +197,107 @@ func (c *ProjectBuildArtifactCommand) Run(args []string) int {
 	return 0
 }
 
+// verify checks the downloaded size against the size reported by GitLab and
+// the integrity of every file stored in the zip, the checksum is compared if
+// provided.
+func (c *ProjectBuildArtifactCommand) verify(build *gitlab.Job, written int64) error {
+	if build.ArtifactsFile.Size > 0 && int64(build.ArtifactsFile.Size) != written {
+		return fmt.Errorf("expected %d bytes, downloaded: %d bytes", build.ArtifactsFile.Size, written)
+	}
+
+	manifest, err := helper.NewZipManifest(c.ArtifactsFile)
+
+	if err != nil {
+		return err
+	}
+
+	if err := manifest.Verify(&helper.Manifest{Sha256: strings.ToLower(c.Sha256)}); err != nil {
+		return err
+	}
+
+	c.Ui.Output(fmt.Sprintf("Artifacts verified (sha256: %s)", manifest.Sha256))
+
+	return nil
+}
+
+// findJob resolves the job from the pipeline, the reference name or the sha1,
+// in this order.
+func (c *ProjectBuildArtifactCommand) findJob(project *gitlab.Project, client gitlab.Client) (*gitlab.Job, error) {
+	if len(c.Job) == 0 {
+		return nil, errors.New("The -job option is required to search the build")
+	}
+
+	if len(c.PipelineId) > 0 {
+		jobs, err := helper.GetPipelineJobs(project, c.PipelineId, client)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return helper.FindJob(jobs, c.Job, c.Status)
+	}
+
+	if len(c.RefName) > 0 {
+		status := c.Status
+		if len(status) == 0 {
+			status = "success"
+		}
+
+		opts := &gitlab.PipelineListOptions{
+			ListOptions: gitlab.ListOptions{Page: 1, PerPage: 20},
+			Ref:         c.RefName,
+			OrderBy:     "id",
+			Sort:        "desc",
+		}
+
+		for opts.Page > 0 {
+			pipelines, resp, err := client.Pipelines(project.Id, opts)
+
+			if err != nil {
+				return nil, fmt.Errorf("Unable to retrieve pipelines (projectId:%d), err: %s", project.Id, err)
+			}
+
+			for _, p := range pipelines {
+				if c.Verbose {
+					c.Ui.Output(fmt.Sprintf("Search job %s in pipeline %d (status: %s)", c.Job, p.Id, p.Status))
+				}
+
+				jobs, err := helper.GetPipelineJobs(project, strconv.Itoa(p.Id), client)
+
+				if err != nil {
+					return nil, err
+				}
+
+				if job, err := helper.FindJob(jobs, c.Job, status); job != nil || err != nil {
+					return job, err
+				}
+			}
+
+			opts.Page = resp.NextPage
+		}
+
+		return nil, nil
+	}
+
+	if len(c.Ref) > 0 {
+		jobs, err := helper.GetCommitJobs(project, c.Ref, client)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if c.Verbose {
+			for _, j := range jobs {
+				c.Ui.Output(fmt.Sprintf("Job found - name: %s, status: %s, id: %d", j.Name, j.Status, j.Id))
+			}
+		}
+
+		return helper.FindJob(jobs, c.Job, c.Status)
+	}
+
+	return nil, nil
+}
+
 func (c *ProjectBuildArtifactCommand) Synopsis() string {
 	return "Download artifact from a job."
 }
@@ -174,14 +312,34 @@ Options:
 
   -project=XX         The project reference (default: CI_PROJECT_ID)
   -build=XX           The build number used to retrieved the related artifact
-  -job=XX             The job to search the build (must be used with -ref, default: package)
-  -ref=XX             The sha1 linked to the build (must be used with -stage,
+  -job=XX             The job to search the build (must be used with -ref, -ref-name,
+                       -pipeline or -upstream)
+  -ref=XX             The sha1 linked to the build (must be used with -job,
                        default 9.X: CI_COMMIT_SHA / 8.x: CI_BUILD_REF)
+  -ref-name=XX        The branch or tag name, the artifacts are retrieved from the latest
+                       pipeline with a matching job (must be used with -job)
+  -pipeline=XX        The pipeline id used to search the job (must be used with -job)
+  -upstream           Search the job in the upstream pipeline, only works in a pipeline
+                       created by pipeline:trigger: the pipeline is retrieved from
+                       UPSTREAM_PIPELINE_ID and the project from UPSTREAM_PROJECT_ID,
+                       these variables are not set by GitLab for the pipelines created
+                       with a trigger: job or the API
+  -status=XX          The job's status (default: success with -ref-name, any otherwise)
   -file=artifacts.zip The path to the artifact file (default: artifacts.zip)
   -path=./package     The path to extract the command. If not set, the artifact will not
                       be extracted.
+  -verify             Verify the size and the content of the artifacts before the
+                       extraction (default: false)
+  -sha256=XX          The expected sha256 checksum of the artifacts file, the
+                       artifacts are verified if set
   -verbose            Add verbose information to the output
 
+The build is resolved with the following precedence: -build, -pipeline, -upstream,
+-ref-name and -ref. If a job has been retried, the latest attempt is used. An error
+is raised if several pipelines contain a matching job.
+
+The command exits with the code 2 if the artifacts do not pass the verification.
+
 Credentials are retrieved from environment:
 
   GITLAB_HOST         The gitlab host
@@ -17,7 +17,7 @@ import (
 )
 
 func Test_Project_Builds_Artifacts(t *testing.T) {
-	fpBuilds, err := os.Open("../fixtures/builds.json")
+	fpJobs, err := os.Open("../fixtures/jobs.json")
 	assert.NoError(t, err)
 
 	fpProjects, err := os.Open("../fixtures/projects.json")
@@ -26,7 +26,10 @@ func Test_Project_Builds_Artifacts(t *testing.T) {
 	fpProject, err := os.Open("../fixtures/project.json")
 	assert.NoError(t, err)
 
-	fpCommits, err := os.Open("../fixtures/commits.json")
+	fpPipelines, err := os.Open("../fixtures/commit_pipelines.json")
+	assert.NoError(t, err)
+
+	fpPipelineJobs, err := os.Open("../fixtures/pipeline_12_jobs.json")
 	assert.NoError(t, err)
 
 	fpArchive, err := os.Open("../fixtures/artifacts.zip")
@@ -54,23 +57,31 @@ func Test_Project_Builds_Artifacts(t *testing.T) {
 			},
 		},
 		{
-			Path:   "/api/v4/projects/3/builds",
+			Path:   "/api/v4/projects/3/jobs",
+			Method: "GET",
+			Response: &http.Response{
+				Body:   fpJobs,
+				Header: headers,
+			},
+		},
+		{
+			Path:   "/api/v4/projects/3/pipelines",
 			Method: "GET",
 			Response: &http.Response{
-				Body:   fpBuilds,
+				Body:   fpPipelines,
 				Header: headers,
 			},
 		},
 		{
-			Path:   "/api/v4/projects/3/repository/commits/889935cf4d3e7558ae6c0d4dd62e20ea600f5a57/builds",
+			Path:   "/api/v4/projects/3/pipelines/12/jobs",
 			Method: "GET",
 			Response: &http.Response{
-				Body:   fpCommits,
+				Body:   fpPipelineJobs,
 				Header: headers,
 			},
 		},
 		{
-			Path:   "/api/v4/projects/3/builds/69/artifacts",
+			Path:   "/api/v4/projects/3/jobs/69/artifacts",
 			Method: "GET",
 			Response: &http.Response{
 				Body: fpArchive,
@@ -89,11 +100,11 @@ func Test_Project_Builds_Artifacts(t *testing.T) {
 			Ui: ui,
 		}
 
-		code := c.Run([]string{"-project", "3", "-ref", "889935cf4d3e7558ae6c0d4dd62e20ea600f5a57", "-job", "rubocop"})
+		code := c.Run([]string{"-project", "3", "-ref", "889935cf4d3e7558ae6c0d4dd62e20ea600f5a57", "-job", "rubocop", "-verify"})
 
 		assert.Equal(t, 0, code)
 
-		expected := "Found project: Diaspora/Diaspora Project Site (id: 3)\nFound build - stage:test status:canceled id:69\nDownloading artifacts... (artifacts.zip)\nDone!\n"
+		expected := "Found project: Diaspora/Diaspora Project Site (id: 3)\nFound build - stage:test status:canceled id:69\nDownloading artifacts... (artifacts.zip)\nArtifacts verified (sha256: 2b84a1faa38b7404d59defa8ff6db580fe6b4a1260a513a8e24e9a0a7929ff89)\nDone!\n"
 		assert.Equal(t, expected, ui.OutputWriter.String())
 		assert.Equal(t, "", ui.ErrorWriter.String())
 
@@ -102,6 +113,167 @@ func Test_Project_Builds_Artifacts(t *testing.T) {
 
 }
 
+func Test_Project_Builds_Artifacts_From_RefName(t *testing.T) {
+	fpProject, err := os.Open("../fixtures/project.json")
+	assert.NoError(t, err)
+
+	fpPipelines, err := os.Open("../fixtures/pipelines.json")
+	assert.NoError(t, err)
+
+	fpJobs48, err := os.Open("../fixtures/pipeline_48_jobs.json")
+	assert.NoError(t, err)
+
+	fpJobs47, err := os.Open("../fixtures/pipeline_47_jobs.json")
+	assert.NoError(t, err)
+
+	fpArchive, err := os.Open("../fixtures/artifacts.zip")
+	assert.NoError(t, err)
+
+	headers := http.Header{
+		"Content-Type": []string{"application/json"},
+	}
+
+	reqs := []*helper.FakeRequest{
+		{
+			Path:     "/api/v4/projects/3",
+			Method:   "GET",
+			Response: &http.Response{Body: fpProject, Header: headers},
+		},
+		{
+			Path:     "/api/v4/projects/3/pipelines",
+			Method:   "GET",
+			Response: &http.Response{Body: fpPipelines, Header: headers},
+		},
+		{
+			Path:     "/api/v4/projects/3/pipelines/48/jobs",
+			Method:   "GET",
+			Response: &http.Response{Body: fpJobs48, Header: headers},
+		},
+		{
+			Path:     "/api/v4/projects/3/pipelines/47/jobs",
+			Method:   "GET",
+			Response: &http.Response{Body: fpJobs47, Header: headers},
+		},
+		{
+			Path:   "/api/v4/projects/3/jobs/101/artifacts",
+			Method: "GET",
+			Response: &http.Response{
+				Body: fpArchive,
+				Header: http.Header{
+					"Content-Type": []string{"application/zip"},
+				},
+			},
+		},
+	}
+
+	envs := map[string]string{}
+
+	helper.WrapperTestCommand(reqs, envs, t, func(ts *httptest.Server) {
+		ui := &cli.MockUi{}
+		c := &ProjectBuildArtifactCommand{
+			Ui: ui,
+		}
+
+		// the latest attempt of the package job in the pipeline 48 has failed,
+		// so the artifacts must come from the pipeline 47
+		code := c.Run([]string{"-project", "3", "-ref-name", "master", "-job", "package", "-file", "gitlab_helper.artifacts.zip"})
+
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "", ui.ErrorWriter.String())
+		assert.Contains(t, ui.OutputWriter.String(), "Found build - stage:build status:success id:101\n")
+
+		os.Remove(c.ArtifactsFile)
+	})
+}
+
+func Test_Project_Builds_Artifacts_Upstream_Without_Pipeline(t *testing.T) {
+	ui := &cli.MockUi{}
+	c := &ProjectBuildArtifactCommand{
+		Ui: ui,
+	}
+
+	os.Unsetenv("UPSTREAM_PIPELINE_ID")
+
+	assert.Equal(t, 1, c.Run([]string{"-upstream", "-job", "package"}))
+	assert.Contains(t, ui.ErrorWriter.String(), "UPSTREAM_PIPELINE_ID")
+}
+
+func Test_Project_Builds_Artifacts_Checksum_Mismatch(t *testing.T) {
+	fpProject, err := os.Open("../fixtures/project.json")
+	assert.NoError(t, err)
+
+	fpPipelines, err := os.Open("../fixtures/commit_pipelines.json")
+	assert.NoError(t, err)
+
+	fpPipelineJobs, err := os.Open("../fixtures/pipeline_12_jobs.json")
+	assert.NoError(t, err)
+
+	fpArchive, err := os.Open("../fixtures/artifacts.zip")
+	assert.NoError(t, err)
+
+	headers := http.Header{
+		"Content-Type": []string{"application/json"},
+	}
+
+	reqs := []*helper.FakeRequest{
+		{
+			Path:   "/api/v4/projects/3",
+			Method: "GET",
+			Response: &http.Response{
+				Body:   fpProject,
+				Header: headers,
+			},
+		},
+		{
+			Path:   "/api/v4/projects/3/pipelines",
+			Method: "GET",
+			Response: &http.Response{
+				Body:   fpPipelines,
+				Header: headers,
+			},
+		},
+		{
+			Path:   "/api/v4/projects/3/pipelines/12/jobs",
+			Method: "GET",
+			Response: &http.Response{
+				Body:   fpPipelineJobs,
+				Header: headers,
+			},
+		},
+		{
+			Path:   "/api/v4/projects/3/jobs/69/artifacts",
+			Method: "GET",
+			Response: &http.Response{
+				Body: fpArchive,
+				Header: http.Header{
+					"Content-Type": []string{"application/zip"},
+				},
+			},
+		},
+	}
+
+	helper.WrapperTestCommand(reqs, map[string]string{}, t, func(ts *httptest.Server) {
+		ui := &cli.MockUi{}
+		c := &ProjectBuildArtifactCommand{
+			Ui: ui,
+		}
+
+		defer os.RemoveAll("./artifacts-extract")
+
+		code := c.Run([]string{"-project", "3", "-ref", "889935cf4d3e7558ae6c0d4dd62e20ea600f5a57", "-job", "rubocop", "-sha256", "foobar", "-path", "./artifacts-extract"})
+
+		assert.Equal(t, EXIT_CHECKSUM_MISMATCH, code)
+		assert.Contains(t, ui.ErrorWriter.String(), "Checksum mismatch for archive, expected: foobar")
+
+		_, err := os.Stat("./artifacts-extract")
+		assert.True(t, os.IsNotExist(err))
+
+		// the corrupted artifacts are removed
+		_, err = os.Stat(c.ArtifactsFile)
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
 func Test_Project_Builds_Artifacts_Help(t *testing.T) {
 	c := &ProjectBuildArtifactCommand{
 		Ui: &cli.MockUi{},
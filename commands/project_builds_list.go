@@ -8,12 +8,11 @@ package commands
 import (
 	"flag"
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/mitchellh/cli"
-	gitlab "gopkg.in/plouc/go-gitlab-client.v1"
 	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
 )
 
 var (
@@ -50,8 +49,15 @@ func (c *ProjectBuildsListCommand) Run(args []string) int {
 		return 1
 	}
 
-	config := helper.NewConfig()
-	client := gitlab.NewGitlab(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
+	config, err := helper.NewConfig()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	client := gitlab.NewClient(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
 
 	project, err := helper.GetProject(args[0], client)
 
@@ -63,7 +69,7 @@ func (c *ProjectBuildsListCommand) Run(args []string) int {
 
 	c.Ui.Output(fmt.Sprintf("Project: %s/%s (id: %d)", project.Namespace.Name, project.Name, project.Id))
 
-	builds, err := client.ProjectBuilds(strconv.FormatInt(int64(project.Id), 10))
+	builds, _, err := client.Jobs(project.Id, nil)
 
 	if err != nil {
 		flags.Usage()
@@ -88,7 +94,7 @@ func (c *ProjectBuildsListCommand) Run(args []string) int {
 			status = icon_red
 		}
 
-		c.Ui.Output(fmt.Sprintf(" > %s  %s % 4d - %-15s ref: %-25s short id: %s", status, artifacts, b.Id, b.Name, b.Ref, b.Commit.Short_Id))
+		c.Ui.Output(fmt.Sprintf(" > %s  %s % 4d - %-15s ref: %-25s short id: %s", status, artifacts, b.Id, b.Name, b.Ref, b.Commit.ShortId))
 	}
 
 	return 0
@@ -106,7 +112,7 @@ Usage: gitlab-ci-helper project:builds:list [options] project
 
 Arguments:
 
-  project             Can be an id or a string: namespace/name
+  project             Can be an id or a path: namespace/name or group/subgroup/name
 
 Options:
 
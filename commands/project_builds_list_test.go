@@ -17,7 +17,7 @@ import (
 )
 
 func Test_Project_BuildsList_From_Args(t *testing.T) {
-	fpBuild, err := os.Open("../fixtures/builds.json")
+	fpJobs, err := os.Open("../fixtures/jobs.json")
 	assert.NoError(t, err)
 
 	fpProject, err := os.Open("../fixtures/project.json")
@@ -32,10 +32,10 @@ func Test_Project_BuildsList_From_Args(t *testing.T) {
 			},
 		},
 		{
-			Path:   "/api/v4/projects/3/builds",
+			Path:   "/api/v4/projects/3/jobs",
 			Method: "GET",
 			Response: &http.Response{
-				Body: fpBuild,
+				Body: fpJobs,
 			},
 		},
 	}
@@ -11,8 +11,8 @@ import (
 	"strings"
 
 	"github.com/mitchellh/cli"
-	gitlab "gopkg.in/plouc/go-gitlab-client.v1"
 	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
 )
 
 type ProjectsListCommand struct {
@@ -32,13 +32,19 @@ func (c *ProjectsListCommand) Run(args []string) int {
 		return 1
 	}
 
-	config := helper.NewConfig()
+	config, err := helper.NewConfig()
 
-	client := gitlab.NewGitlab(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	client := gitlab.NewClient(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
 
 	c.Ui.Output("Trying to find project from options")
 
-	projects, err := client.Projects()
+	projects, _, err := client.Projects(nil)
 
 	if err != nil {
 		c.Ui.Error(err.Error())
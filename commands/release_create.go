@@ -0,0 +1,376 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/rande/gitlab-ci-helper/storage"
+)
+
+type ReleaseCreateCommand struct {
+	Ui          cli.Ui
+	Verbose     bool
+	Project     string
+	Tag         string
+	Ref         string
+	Name        string
+	Description string
+	Notes       bool
+	TagMatcher  string
+	Links       helper.Paths
+	ArchiveUrl  string
+	DryRun      bool
+
+	// s3 settings
+	AwsRegion   string
+	AwsEndPoint string
+	AwsProfile  string
+	AwsBucket   string
+}
+
+func (c *ReleaseCreateCommand) Run(args []string) int {
+
+	config, err := helper.NewConfig()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	flags := flag.NewFlagSet("release:create", flag.ContinueOnError)
+	flags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	c.Links = make(helper.Paths, 0)
+
+	flags.BoolVar(&c.Verbose, "verbose", false, "")
+	flags.StringVar(&c.Project, "project", os.Getenv("CI_PROJECT_ID"), "The project")
+	flags.StringVar(&c.Tag, "tag", os.Getenv("CI_COMMIT_TAG"), "The tag of the release")
+	flags.StringVar(&c.Ref, "ref", helper.GetEnv("CI_COMMIT_SHA", os.Getenv("CI_BUILD_REF")), "The commit of the tag")
+	flags.StringVar(&c.Name, "name", "", "The name of the release")
+	flags.StringVar(&c.Description, "description", "", "The text added before the notes")
+	flags.BoolVar(&c.Notes, "notes", true, "Generate the notes from the commits and the merge requests")
+	flags.StringVar(&c.TagMatcher, "tag-matcher", helper.SEMVER_PATTERN, "Regular expression to match tag (default: semver format)")
+	flags.Var(&c.Links, "link", "-link name=url")
+	flags.StringVar(&c.ArchiveUrl, "archive-url", "", "The public url of the bucket")
+	flags.BoolVar(&c.DryRun, "dry-run", false, "Display the release without publishing it")
+
+	flags.StringVar(&c.AwsRegion, "region", config.S3.Region, "The s3 region")
+	flags.StringVar(&c.AwsEndPoint, "endpoint", config.S3.Endpoint, "The s3 endpoint")
+	flags.StringVar(&c.AwsProfile, "profile", config.S3.Profile, "The aws credentials")
+	flags.StringVar(&c.AwsBucket, "bucket", config.S3.Bucket, "The s3 bucket")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.Tag) == 0 {
+		c.Ui.Error("Error: the tag is required")
+
+		return 1
+	}
+
+	if len(c.Name) == 0 {
+		c.Name = c.Tag
+	}
+
+	tagMatcher, err := regexp.Compile(c.TagMatcher)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: invalid tag matcher, %s", err.Error()))
+
+		return 1
+	}
+
+	links := []*gitlab.ReleaseLink{}
+
+	for _, l := range c.Links {
+		parts := strings.SplitN(l, "=", 2)
+
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			c.Ui.Error(fmt.Sprintf("Error: invalid link: %s, the format must be name=url", l))
+
+			return 1
+		}
+
+		links = append(links, &gitlab.ReleaseLink{Name: parts[0], Url: parts[1], LinkType: "other"})
+	}
+
+	client := gitlab.NewClient(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
+
+	project, err := helper.GetProject(c.Project, client)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Unable to fetch the project: %s", err.Error()))
+
+		return 1
+	}
+
+	description := strings.TrimSpace(c.Description)
+
+	// the previous release can only be found from a version
+	withNotes := c.Notes
+	if _, err := helper.ParseVersion(c.Tag); withNotes && err != nil {
+		c.Ui.Warn(fmt.Sprintf("Warning: the tag %s is not a version, the notes are not generated", c.Tag))
+
+		withNotes = false
+	}
+
+	if withNotes {
+		to := c.Ref
+		if len(to) == 0 {
+			to = c.Tag
+		}
+
+		notes, err := helper.NewReleaseNotes(project, client, c.Tag, to, tagMatcher)
+
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error: unable to generate the notes, %s", err.Error()))
+
+			return 1
+		}
+
+		if c.Verbose {
+			c.Ui.Output(fmt.Sprintf("Previous release: %s, %d commits, %d merge requests", notes.Previous, len(notes.Commits), len(notes.MergeRequests)))
+		}
+
+		if len(description) > 0 {
+			description += "\n\n"
+		}
+
+		description += notes.Markdown()
+	}
+
+	if len(c.AwsBucket) > 0 {
+		s3client, err := storage.NewS3Client(c.AwsRegion, c.AwsEndPoint, c.AwsProfile)
+
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error: unable to load credentials, %s", err))
+
+			return 1
+		}
+
+		baseUrl := c.ArchiveUrl
+		if len(baseUrl) == 0 {
+			baseUrl = S3BucketUrl(c.AwsRegion, c.AwsEndPoint, c.AwsBucket)
+		}
+
+		store := &storage.S3Storage{Client: s3client, Bucket: c.AwsBucket}
+
+		archives, skipped, err := ReleaseArchiveLinks(store, project.Namespace.Path, project.Path, c.Tag, baseUrl)
+
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error: unable to list the archives, %s", err.Error()))
+
+			return 1
+		}
+
+		for _, key := range skipped {
+			c.Ui.Warn(fmt.Sprintf("Warning: the archive %s is encrypted, no link is added", key))
+		}
+
+		links = append(links, archives...)
+	}
+
+	if c.DryRun {
+		c.Ui.Output(fmt.Sprintf("Release: %s (%s)\n", c.Name, c.Tag))
+		c.Ui.Output(description)
+
+		for _, l := range links {
+			c.Ui.Output(fmt.Sprintf(" > %s: %s", l.Name, l.Url))
+		}
+
+		return 0
+	}
+
+	release, err := client.Release(project.Id, c.Tag)
+
+	if err != nil && !gitlab.IsNotFound(err) {
+		c.Ui.Error(fmt.Sprintf("Error: unable to retrieve the release %s, %s", c.Tag, err.Error()))
+
+		return 1
+	}
+
+	if release == nil {
+		opts := &gitlab.ReleaseOptions{
+			TagName:     c.Tag,
+			Name:        c.Name,
+			Description: description,
+			Ref:         c.Ref,
+			Assets:      &gitlab.ReleaseAssets{Links: links},
+		}
+
+		if _, err := client.CreateRelease(project.Id, opts); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error: unable to create the release %s, %s", c.Tag, err.Error()))
+
+			return 1
+		}
+
+		c.Ui.Output(fmt.Sprintf("Release %s created with %d links", c.Tag, len(links)))
+
+		return 0
+	}
+
+	opts := &gitlab.ReleaseOptions{
+		Name:        c.Name,
+		Description: description,
+	}
+
+	if _, err := client.UpdateRelease(project.Id, c.Tag, opts); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: unable to update the release %s, %s", c.Tag, err.Error()))
+
+		return 1
+	}
+
+	// the assets are not updated by the release endpoint, the existing links
+	// are kept
+	existing := map[string]bool{}
+
+	if release.Assets != nil {
+		for _, l := range release.Assets.Links {
+			existing[l.Name] = true
+		}
+	}
+
+	added := 0
+
+	for _, l := range links {
+		if existing[l.Name] {
+			continue
+		}
+
+		if _, err := client.CreateReleaseLink(project.Id, c.Tag, l); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error: unable to add the link %s, %s", l.Name, err.Error()))
+
+			return 1
+		}
+
+		added++
+	}
+
+	c.Ui.Output(fmt.Sprintf("Release %s updated, %d links added", c.Tag, added))
+
+	return 0
+}
+
+// ReleaseArchiveLinks returns the links to the archives stored for the tag
+// under the releases/ prefix, the keys are appended to the base url. The
+// encrypted archives cannot be downloaded as is, they are skipped and
+// returned apart.
+func ReleaseArchiveLinks(s storage.Storage, namespace, project, tag, baseUrl string) ([]*gitlab.ReleaseLink, []string, error) {
+	groups, err := ListArchives(s, namespace, project, []string{"releases"}, "", false)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	links := []*gitlab.ReleaseLink{}
+	skipped := []string{}
+
+	for _, g := range groups {
+		if g.Ref != tag {
+			continue
+		}
+
+		for _, a := range g.Archives {
+			object, err := s.Stat(a.Key)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if len(object.Meta(storage.META_ENCRYPTION)) > 0 {
+				skipped = append(skipped, a.Key)
+
+				continue
+			}
+
+			links = append(links, &gitlab.ReleaseLink{
+				Name:     fmt.Sprintf("%s (%s)", a.Job, a.Format),
+				Url:      strings.TrimRight(baseUrl, "/") + "/" + a.Key,
+				LinkType: "package",
+			})
+		}
+	}
+
+	return links, skipped, nil
+}
+
+// S3BucketUrl returns the url of the bucket, the path style is used with a
+// custom endpoint.
+func S3BucketUrl(region, endpoint, bucket string) string {
+	if len(endpoint) > 0 {
+		return strings.TrimRight(endpoint, "/") + "/" + bucket
+	}
+
+	if len(region) == 0 {
+		return fmt.Sprintf("https://%s.s3.amazonaws.com", bucket)
+	}
+
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region)
+}
+
+func (c *ReleaseCreateCommand) Synopsis() string {
+	return "Create or update the GitLab release of a tag, with the notes and the assets."
+}
+
+func (c *ReleaseCreateCommand) Help() string {
+	helpText := `
+Usage: gitlab-ci-helper release:create [options]
+
+  Create the GitLab release of a tag, or update it if it already exists. The
+  notes list the commits created since the previous release tag, grouped by
+  conventional commit type, and the merge requests merged by these commits.
+  The pre-release tags are ignored when searching the previous release.
+
+  The links are attached as assets, with the archives stored for the tag by
+  s3:archive under the releases/ prefix if a bucket is configured. The links
+  point to the objects directly: the archives must be publicly readable, and
+  the archives encrypted with -encryption-key are skipped. On update, the name
+  and the description are replaced and only the missing links are added.
+
+Options:
+
+  -project            The project, an id or a path (default: env var CI_PROJECT_ID)
+  -tag                The tag of the release (default: env var CI_COMMIT_TAG)
+  -ref                The commit of the tag, used to create the tag if it does
+                        not exist (default: 9.x: CI_COMMIT_SHA or 8.x: CI_BUILD_REF)
+  -name               The name of the release (default: the tag)
+  -description        The text added before the notes
+  -notes              Generate the notes from the commits (default: true), the
+                        notes are skipped if the tag is not a version
+  -tag-matcher        The regular expression to match a tag (default: semver)
+  -link               An asset link: name=url, can be repeated
+  -archive-url        The public url of the bucket, used by the archive links, the
+                        objects must be readable without credentials (default:
+                        the s3 url of the bucket)
+  -dry-run            Display the release without publishing it
+  -verbose            Add verbose information to the output
+
+  -region             The s3 region (default: AWS_REGION)
+  -endpoint           The s3 endpoint (default: AWS_ENDPOINT)
+  -profile            The aws credentials name (default: AWS_PROFILE, if not set default)
+  -bucket             The s3 bucket name, the archives are not listed if empty
+                        (default: AWS_BUCKET)
+
+Credentials are retrieved from environment:
+
+  GITLAB_HOST         The gitlab host
+  GITLAB_TOKEN        The user's token
+  GITLAB_API_PATH     (optional) the api path, default to: "/api/v4"
+`
+	return strings.TrimSpace(helpText)
+}
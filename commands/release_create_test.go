@@ -0,0 +1,195 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/rande/gitlab-ci-helper/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// newReleaseServer serves the responses by method and path, the other
+// requests are not found.
+func newReleaseServer(t *testing.T, responses map[string]string, payloads map[string]map[string]interface{}) *httptest.Server {
+	project, err := ioutil.ReadFile("../fixtures/project.json")
+	assert.NoError(t, err)
+
+	responses["GET /api/v4/projects/3"] = string(project)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.Path
+
+		if r.Method != "GET" {
+			payload := map[string]interface{}{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+
+			payloads[key] = payload
+		}
+
+		body, ok := responses[key]
+
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "404 Not Found"}`))
+
+			return
+		}
+
+		w.Write([]byte(body))
+	}))
+}
+
+func Test_ReleaseCreate_Create(t *testing.T) {
+	payloads := map[string]map[string]interface{}{}
+
+	ts := newReleaseServer(t, map[string]string{
+		"GET /api/v4/projects/3/repository/tags":           `[{"name": "v1.2.0"}, {"name": "v1.1.0"}]`,
+		"GET /api/v4/projects/3/repository/compare":        `{"commits": [{"id": "sha1", "short_id": "sha1", "title": "feat: add the releases", "parent_ids": ["sha0"]}]}`,
+		"GET /api/v4/projects/3/repository/commits/v1.2.0": `{"id": "sha0", "committed_date": "2017-08-01T10:00:00Z"}`,
+		"GET /api/v4/projects/3/merge_requests":            `[{"iid": 12, "title": "Add the releases", "sha": "sha1"}]`,
+		"POST /api/v4/projects/3/releases":                 `{"tag_name": "v1.3.0"}`,
+	}, payloads)
+
+	defer ts.Close()
+
+	os.Setenv("GITLAB_HOST", ts.URL)
+	defer os.Unsetenv("GITLAB_HOST")
+
+	ui := &cli.MockUi{}
+	c := &ReleaseCreateCommand{
+		Ui: ui,
+	}
+
+	code := c.Run([]string{"-project", "3", "-tag", "v1.3.0", "-ref", "sha1", "-bucket", "", "-link", "docs=https://example.com/docs"})
+
+	assert.Equal(t, 0, code, ui.ErrorWriter.String())
+	assert.Equal(t, "Release v1.3.0 created with 1 links\n", ui.OutputWriter.String())
+
+	payload := payloads["POST /api/v4/projects/3/releases"]
+	assert.Equal(t, "v1.3.0", payload["tag_name"])
+	assert.Equal(t, "sha1", payload["ref"])
+	assert.Contains(t, payload["description"], "## Changes since v1.2.0")
+	assert.Contains(t, payload["description"], "- add the releases (sha1)")
+	assert.Contains(t, payload["description"], "- !12 Add the releases")
+	assert.Equal(t, "docs", payload["assets"].(map[string]interface{})["links"].([]interface{})[0].(map[string]interface{})["name"])
+}
+
+func Test_ReleaseCreate_Create_Without_Version(t *testing.T) {
+	payloads := map[string]map[string]interface{}{}
+
+	ts := newReleaseServer(t, map[string]string{
+		"POST /api/v4/projects/3/releases": `{"tag_name": "nightly"}`,
+	}, payloads)
+
+	defer ts.Close()
+
+	os.Setenv("GITLAB_HOST", ts.URL)
+	defer os.Unsetenv("GITLAB_HOST")
+
+	ui := &cli.MockUi{}
+	c := &ReleaseCreateCommand{
+		Ui: ui,
+	}
+
+	code := c.Run([]string{"-project", "3", "-tag", "nightly", "-ref", "sha1", "-bucket", "", "-description", "Nightly build"})
+
+	assert.Equal(t, 0, code, ui.ErrorWriter.String())
+	assert.Contains(t, ui.ErrorWriter.String(), "Warning: the tag nightly is not a version, the notes are not generated")
+	assert.Equal(t, "Nightly build", payloads["POST /api/v4/projects/3/releases"]["description"])
+}
+
+func Test_ReleaseCreate_Update(t *testing.T) {
+	payloads := map[string]map[string]interface{}{}
+
+	ts := newReleaseServer(t, map[string]string{
+		"GET /api/v4/projects/3/releases/v1.3.0":               `{"tag_name": "v1.3.0", "assets": {"links": [{"id": 1, "name": "docs"}]}}`,
+		"PUT /api/v4/projects/3/releases/v1.3.0":               `{"tag_name": "v1.3.0"}`,
+		"POST /api/v4/projects/3/releases/v1.3.0/assets/links": `{"id": 2, "name": "binary"}`,
+	}, payloads)
+
+	defer ts.Close()
+
+	os.Setenv("GITLAB_HOST", ts.URL)
+	defer os.Unsetenv("GITLAB_HOST")
+
+	ui := &cli.MockUi{}
+	c := &ReleaseCreateCommand{
+		Ui: ui,
+	}
+
+	code := c.Run([]string{
+		"-project", "3", "-tag", "v1.3.0", "-notes=false", "-bucket", "",
+		"-description", "Hotfix release",
+		"-link", "docs=https://example.com/docs",
+		"-link", "binary=https://example.com/binary",
+	})
+
+	assert.Equal(t, 0, code, ui.ErrorWriter.String())
+	assert.Equal(t, "Release v1.3.0 updated, 1 links added\n", ui.OutputWriter.String())
+	assert.Equal(t, "Hotfix release", payloads["PUT /api/v4/projects/3/releases/v1.3.0"]["description"])
+	assert.Equal(t, "binary", payloads["POST /api/v4/projects/3/releases/v1.3.0/assets/links"]["name"])
+}
+
+func Test_ReleaseArchiveLinks(t *testing.T) {
+	pruner, clean := newTestArchivePruner(t)
+	defer clean()
+
+	links, skipped, err := ReleaseArchiveLinks(pruner.Storage, "rande", "project", "v1.0.0", "https://cdn.example.com/")
+	assert.NoError(t, err)
+	assert.Len(t, links, 1)
+	assert.Len(t, skipped, 0)
+	assert.Equal(t, "build (zip)", links[0].Name)
+	assert.Equal(t, "https://cdn.example.com/releases/rande/project/v1.0.0_build.zip", links[0].Url)
+	assert.Equal(t, "package", links[0].LinkType)
+
+	links, _, err = ReleaseArchiveLinks(pruner.Storage, "rande", "project", "v2.0.0", "https://cdn.example.com")
+	assert.NoError(t, err)
+	assert.Len(t, links, 0)
+
+	// the encrypted archives cannot be downloaded from the link
+	assert.NoError(t, pruner.Storage.Put("releases/rande/project/v1.0.0_build.zip", strings.NewReader("archive"), &storage.Object{
+		Metadata: map[string]string{storage.META_ENCRYPTION: storage.ENCRYPTION_AES_GCM},
+	}))
+
+	links, skipped, err = ReleaseArchiveLinks(pruner.Storage, "rande", "project", "v1.0.0", "https://cdn.example.com/")
+	assert.NoError(t, err)
+	assert.Len(t, links, 0)
+	assert.Equal(t, []string{"releases/rande/project/v1.0.0_build.zip"}, skipped)
+}
+
+func Test_S3BucketUrl(t *testing.T) {
+	assert.Equal(t, "https://releases.s3.eu-west-1.amazonaws.com", S3BucketUrl("eu-west-1", "", "releases"))
+	assert.Equal(t, "https://releases.s3.amazonaws.com", S3BucketUrl("", "", "releases"))
+	assert.Equal(t, "http://minio:9000/releases", S3BucketUrl("eu-west-1", "http://minio:9000/", "releases"))
+}
+
+func Test_ReleaseCreate_Help(t *testing.T) {
+	c := &ReleaseCreateCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.True(t, len(c.Help()) > 0)
+	assert.True(t, len(c.Synopsis()) > 0)
+}
+
+func Test_ReleaseCreate_InvalidRun(t *testing.T) {
+	c := &ReleaseCreateCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.Equal(t, 1, c.Run([]string{"--foobar"}))
+	assert.Equal(t, 1, c.Run([]string{"-tag", ""}))
+	assert.Equal(t, 1, c.Run([]string{"-tag", "v1.0.0", "-tag-matcher", "("}))
+	assert.Equal(t, 1, c.Run([]string{"-tag", "v1.0.0", "-link", "docs"}))
+}
@@ -11,14 +11,11 @@ import (
 	"os"
 	"regexp"
 	"strings"
-	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/mitchellh/cli"
-	gitlab "gopkg.in/plouc/go-gitlab-client.v1"
 	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/rande/gitlab-ci-helper/storage"
 )
 
 type S3ArchiveCommand struct {
@@ -31,7 +28,16 @@ type S3ArchiveCommand struct {
 	IncludePaths helper.Paths
 	IgnorePaths  helper.Paths
 	IgnoreCVS    bool
+	ExcludeMode  string
+	ExcludeFrom  string
 	TagMatcher   string
+	Format       string
+	Stream       bool
+	PartSize     int64
+	Concurrency  int
+	Retries      int
+	Resume       bool
+	Options      ObjectOptions
 
 	// s3 settings
 	AwsRegion   string
@@ -42,6 +48,14 @@ type S3ArchiveCommand struct {
 
 func (c *S3ArchiveCommand) Run(args []string) int {
 
+	config, err := helper.NewConfig()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
 	flags := flag.NewFlagSet("s3:uploads", flag.ContinueOnError)
 	flags.Usage = func() {
 		c.Ui.Output(c.Help())
@@ -52,20 +66,29 @@ func (c *S3ArchiveCommand) Run(args []string) int {
 	flags.StringVar(&c.Ref, "ref", helper.GetEnv("CI_COMMIT_SHA", os.Getenv("CI_BUILD_REF")), "The reference (sha1)")
 	flags.StringVar(&c.RefName, "ref-name", helper.GetEnv("CI_COMMIT_REF_NAME", os.Getenv("CI_BUILD_REF_NAME")), "The reference name (tag or branch)")
 	flags.StringVar(&c.Project, "project", os.Getenv("CI_PROJECT_ID"), "The project reference")
-	flags.StringVar(&c.TagMatcher, "tag-matcher", "(v|)[0-9]{1,}\\.[0-9]{1,}\\.[0-9]{1,}(-[A-Za-z]*|)", "Regular expression to match tag (default: semver format)")
+	flags.StringVar(&c.TagMatcher, "tag-matcher", helper.SEMVER_PATTERN, "Regular expression to match tag (default: semver format)")
+	flags.StringVar(&c.Format, "format", helper.FORMAT_ZIP, "The archive format: zip, tar.gz or tar.zst")
 
-	flags.StringVar(&c.AwsRegion, "region", os.Getenv("AWS_REGION"), "The s3 region")
-	flags.StringVar(&c.AwsEndPoint, "endpoint", os.Getenv("AWS_ENDPOINT"), "The s3 endpoint")
-	flags.StringVar(&c.AwsProfile, "profile", helper.GetEnv("AWS_PROFILE", "default"), "The aws credentials")
-	flags.StringVar(&c.AwsBucket, "bucket", os.Getenv("AWS_BUCKET"), "The s3 bucket")
+	flags.StringVar(&c.AwsRegion, "region", config.S3.Region, "The s3 region")
+	flags.StringVar(&c.AwsEndPoint, "endpoint", config.S3.Endpoint, "The s3 endpoint")
+	flags.StringVar(&c.AwsProfile, "profile", config.S3.Profile, "The aws credentials")
+	flags.StringVar(&c.AwsBucket, "bucket", config.S3.Bucket, "The s3 bucket")
 
 	flags.BoolVar(&c.IgnoreCVS, "ignore-cvs", true, "Ignore CVS files")
+	flags.StringVar(&c.ExcludeMode, "exclude-mode", helper.MATCH_GLOB, "The exclude rules syntax: glob or regexp")
+	flags.StringVar(&c.ExcludeFrom, "exclude-from", helper.IGNORE_FILE, "The file containing the exclude rules")
+	flags.BoolVar(&c.Stream, "stream", true, "Stream the zip file to the storage")
+	flags.Int64Var(&c.PartSize, "part-size", 16, "The multipart upload part size in MB")
+	flags.IntVar(&c.Concurrency, "concurrency", 4, "The number of parts uploaded in parallel")
+	flags.IntVar(&c.Retries, "retries", 5, "The number of retries per part")
+	flags.BoolVar(&c.Resume, "resume", false, "Keep a failed upload so a retry of the job resumes it")
+
+	c.Options.ArchiveFlags(flags)
 
 	c.IgnorePaths = make(helper.Paths, 0)
 	c.IncludePaths = make(helper.Paths, 0)
 
-	config := helper.NewConfig()
-	client := gitlab.NewGitlab(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
+	client := gitlab.NewClient(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
 
 	flags.Var(&c.IgnorePaths, "exclude", "-ignore path/to/ignore")
 	flags.Var(&c.IncludePaths, "include", "-include path/to/ignore")
@@ -82,88 +105,76 @@ func (c *S3ArchiveCommand) Run(args []string) int {
 		return 1
 	}
 
-	if len(c.IncludePaths) == 0 {
-		c.IncludePaths.Set("./") // add the current path
-	}
-
-	if c.IgnoreCVS {
-		c.IgnorePaths.Set(".git/")
-		c.IgnorePaths.Set(".svn/")
-		c.IgnorePaths.Set(".hg/")
-		c.IgnorePaths.Set(".bzr/")
-	}
-
-	zipTarget := fmt.Sprintf("%s/%s_%s.zip", os.TempDir(), c.Ref, c.Job)
-
-	c.Ui.Output(fmt.Sprintf("Generate zip file: %s", zipTarget))
-
-	if err := helper.Zip(c.IncludePaths, c.IgnorePaths, zipTarget); err != nil {
-		c.Ui.Output(fmt.Sprintf("Unable to zip file: %s", err))
+	if len(c.Format) > 0 && !helper.IsArchiveFormat(c.Format) {
+		c.Ui.Error(fmt.Sprintf("Error: invalid archive format: %s", c.Format))
 
 		return 1
 	}
 
-	credentials, err := helper.GetAwsCredentials(c.AwsProfile)
+	tagMatcher, err := regexp.Compile(c.TagMatcher)
 
 	if err != nil {
-		c.Ui.Output(fmt.Sprintf("Unable to load credentials: %s", err))
+		c.Ui.Error(fmt.Sprintf("Error: invalid tag matcher, %s", err.Error()))
 
 		return 1
 	}
 
-	awsConfig := &aws.Config{
-		Region:           aws.String(c.AwsRegion),
-		Endpoint:         aws.String(c.AwsEndPoint),
-		S3ForcePathStyle: aws.Bool(true),
-		Credentials:      credentials,
-	}
-
-	s3client := s3.New(session.New(), awsConfig)
+	if c.PartSize*1024*1024 < storage.S3_MIN_PART_SIZE {
+		c.Ui.Error(fmt.Sprintf("Error: the part size must be at least %d MB", storage.S3_MIN_PART_SIZE/1024/1024))
 
-	key := fmt.Sprintf("commits/%s/%s/%s_%s.zip", project.Namespace.Path, project.Path, c.Ref, c.Job)
-
-	if regexp.MustCompile(c.TagMatcher).Match([]byte(c.RefName)) {
-		key = fmt.Sprintf("releases/%s/%s/%s_%s.zip", project.Namespace.Path, project.Path, c.RefName, c.Job)
+		return 1
 	}
 
-	f, _ := os.Open(zipTarget)
-	defer f.Close()
-
-	putObject := &s3.PutObjectInput{
-		Bucket:      aws.String(c.AwsBucket),
-		Key:         aws.String(key),
-		Body:        f,
-		ContentType: aws.String("application/zip"),
+	if len(c.IncludePaths) == 0 {
+		c.IncludePaths.Set("./") // add the current path
 	}
 
-	count := 0
-	for {
-		count++
+	excludes, err := NewExcludeMatcher(c.ExcludeMode, c.IgnorePaths, c.ExcludeFrom, c.IgnoreCVS)
 
-		c.Ui.Output(fmt.Sprintf("Copy zip file: s3://%s/%s (%d)", c.AwsBucket, key, count))
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
 
-		_, err = s3client.PutObject(putObject)
+		return 1
+	}
 
-		if err != nil {
-			c.Ui.Output(fmt.Sprintf("Unable to copy zip file: %s, %s", zipTarget, err))
+	s3client, err := storage.NewS3Client(c.AwsRegion, c.AwsEndPoint, c.AwsProfile)
 
-			if count > 5 {
-				return 1
-			}
+	if err != nil {
+		c.Ui.Output(fmt.Sprintf("Unable to load credentials: %s", err))
 
-			c.Ui.Output(fmt.Sprintf("Retry to copy file: %s", zipTarget))
+		return 1
+	}
 
-			time.Sleep(2 * time.Second)
+	store, err := c.Options.Apply(&storage.S3Storage{
+		Client:      s3client,
+		Bucket:      c.AwsBucket,
+		PartSize:    c.PartSize * 1024 * 1024,
+		Concurrency: c.Concurrency,
+		MaxRetries:  c.Retries,
+		Resume:      c.Resume,
+		ResumeId:    ResumeId(c.Job),
+	})
 
-			continue
-		}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
 
-		break
+		return 1
 	}
 
-	os.Remove(zipTarget)
+	archiver := &Archiver{
+		Ui:         c.Ui,
+		Storage:    store,
+		Location:   fmt.Sprintf("s3://%s", c.AwsBucket),
+		Project:    project,
+		Ref:        c.Ref,
+		RefName:    c.RefName,
+		Job:        c.Job,
+		TagMatcher: tagMatcher,
+		Format:     c.Format,
+		Stream:     c.Stream,
+	}
 
-	return 0
+	return archiver.Archive(c.IncludePaths, excludes)
 }
 
 func (c *S3ArchiveCommand) Synopsis() string {
@@ -171,15 +182,18 @@ func (c *S3ArchiveCommand) Synopsis() string {
 }
 
 func (c *S3ArchiveCommand) Help() string {
-	helpText := `
+	helpText := fmt.Sprintf(`
 Usage: gitlab-ci-helper s3:archive
 
   Send archive to a S3 bucket
 
 Options:
 
-  -include            Path to include (one option per path)
-  -exclude            Path to exclude (one option per path)
+  -include            Path or glob pattern to include (one option per path)
+  -exclude            Rule to exclude files (one option per rule)
+  -exclude-from       The file containing the exclude rules, one rule per line
+                       (default: .ciarchiveignore, if the file exists)
+  -exclude-mode       The rules syntax: glob or regexp (default: glob)
   -ignore-cvs         Exclude CVS files: .git .svn .bzr .hg
   -verbose            Add verbose information to the output
   -job                The job name (default: 9.x: CI_JOB_NAME and 8.x: CI_BUILD_NAME)
@@ -191,6 +205,32 @@ Options:
   -profile            The aws credentials name (default: AWS_PROFILE, if not set default)
   -bucket             The s3 bucket name (default: AWS_BUCKET)
   -tag-matcher        The regular expression to match a tag (default: semver)
+  -format             The archive format: zip, tar.gz or tar.zst (default: zip), the tar
+                       formats preserve the modes, the symlinks and the ownership
+  -stream             Stream the zip file to the storage without storing it in a
+                       temporary file (default: true), use -stream=false to disable
+  -part-size          The part size in MB used by s3 multipart uploads (default: 16, min: 5)
+  -concurrency        The number of parts uploaded in parallel (default: 4)
+  -retries            The number of retries per part, the delay between two attempts
+                       doubles after each failure (default: 5)
+  -resume             Keep a failed s3 upload so a retry of the job resumes it, the
+                       failed uploads are aborted otherwise (default: false)%s
+
+The exclude rules use the .gitignore syntax, the last matching rule wins:
+
+  **/node_modules/**  any number of directories
+  !dist/keep.txt      a leading ! re-includes the matching files
+  /build              a leading / (or a / in the middle) anchors the rule to the
+                       current path, otherwise the rule matches at any depth
+  cache/              a trailing / only matches directories
+
+With -exclude-mode=regexp, each rule is a regular expression matched against the
+full path of the file.
+
+A manifest with the sha256 checksum of the archive and of each file is stored next
+to the archive (<key>.manifest.json) and is used by s3:extract to verify the
+archive. The Sha256 metadata is only set with -stream=false, as the checksum of a
+streamed archive is only known once the upload is completed.
 
 Credentials are retrieved from environment:
 
@@ -198,6 +238,7 @@ Credentials are retrieved from environment:
   GITLAB_TOKEN        The user's token
   GITLAB_API_PATH     (optional) the api path, default to: "/api/v4"
 
-`
+`, objectOptionsArchiveHelp)
+
 	return strings.TrimSpace(helpText)
 }
@@ -9,18 +9,13 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 
 	"github.com/mitchellh/cli"
-	gitlab "gopkg.in/plouc/go-gitlab-client.v1"
 	helper "github.com/rande/gitlab-ci-helper"
-
-	"io"
-	"regexp"
-
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/rande/gitlab-ci-helper/storage"
 )
 
 type S3ExtractCommand struct {
@@ -32,6 +27,11 @@ type S3ExtractCommand struct {
 	Project     string
 	ExtractPath string
 	TagMatcher  string
+	Format      string
+	Strategy    string
+	ReportFile  string
+	Verify      bool
+	Options     ObjectOptions
 
 	// s3 settings
 	AwsRegion   string
@@ -42,6 +42,14 @@ type S3ExtractCommand struct {
 
 func (c *S3ExtractCommand) Run(args []string) int {
 
+	config, err := helper.NewConfig()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
 	flags := flag.NewFlagSet("s3:uploads", flag.ContinueOnError)
 	flags.Usage = func() {
 		c.Ui.Output(c.Help())
@@ -54,15 +62,20 @@ func (c *S3ExtractCommand) Run(args []string) int {
 	flags.StringVar(&c.RefName, "ref-name", helper.GetEnv("CI_COMMIT_REF_NAME", os.Getenv("CI_BUILD_REF_NAME")), "The reference name (tag or branch)")
 	flags.StringVar(&c.Project, "project", os.Getenv("CI_PROJECT_ID"), "The project reference")
 	flags.StringVar(&c.ExtractPath, "path", "./", "The project reference")
-	flags.StringVar(&c.TagMatcher, "tag-matcher", "(v|)[0-9]{1,}\\.[0-9]{1,}\\.[0-9]{1,}(-[A-Za-z]*|)", "Regular expression to match tag (default: semver format)")
+	flags.StringVar(&c.TagMatcher, "tag-matcher", helper.SEMVER_PATTERN, "Regular expression to match tag (default: semver format)")
+	flags.StringVar(&c.Format, "format", "", "The archive format, detected from the content if empty")
+	flags.StringVar(&c.Strategy, "strategy", LOOKUP_EXACT, "The lookup strategies, comma separated (exact, branch, default, release)")
+	flags.StringVar(&c.ReportFile, "report", "", "The file to store the key used to extract the archive")
+	flags.BoolVar(&c.Verify, "verify", true, "Verify the archive checksum before the extraction")
+
+	c.Options.ExtractFlags(flags)
 
-	flags.StringVar(&c.AwsRegion, "region", os.Getenv("AWS_REGION"), "The s3 region")
-	flags.StringVar(&c.AwsEndPoint, "endpoint", os.Getenv("AWS_ENDPOINT"), "The s3 endpoint")
-	flags.StringVar(&c.AwsProfile, "profile", os.Getenv("AWS_PROFILE"), "The aws credentials")
-	flags.StringVar(&c.AwsBucket, "bucket", os.Getenv("AWS_BUCKET"), "The s3 bucket")
+	flags.StringVar(&c.AwsRegion, "region", config.S3.Region, "The s3 region")
+	flags.StringVar(&c.AwsEndPoint, "endpoint", config.S3.Endpoint, "The s3 endpoint")
+	flags.StringVar(&c.AwsProfile, "profile", config.S3.Profile, "The aws credentials")
+	flags.StringVar(&c.AwsBucket, "bucket", config.S3.Bucket, "The s3 bucket")
 
-	config := helper.NewConfig()
-	client := gitlab.NewGitlab(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
+	client := gitlab.NewClient(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
 
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -76,78 +89,61 @@ func (c *S3ExtractCommand) Run(args []string) int {
 		return 1
 	}
 
-	credentials, err := helper.GetAwsCredentials(c.Project)
-
-	if err != nil {
-		c.Ui.Output(fmt.Sprintf("Unable to load credentials: %s", err))
+	if len(c.Format) > 0 && !helper.IsArchiveFormat(c.Format) {
+		c.Ui.Error(fmt.Sprintf("Error: invalid archive format: %s", c.Format))
 
 		return 1
 	}
 
-	awsConfig := &aws.Config{
-		Region:           aws.String(c.AwsRegion),
-		Endpoint:         aws.String(c.AwsEndPoint),
-		S3ForcePathStyle: aws.Bool(true),
-		Credentials:      credentials,
-	}
-
-	s3client := s3.New(session.New(), awsConfig)
-
-	key := fmt.Sprintf("commits/%s/%s/%s_%s.zip", project.Namespace.Path, project.Path, c.Ref, c.Job)
-
-	if regexp.MustCompile(c.TagMatcher).Match([]byte(c.RefName)) {
-		key = fmt.Sprintf("releases/%s/%s/%s_%s.zip", project.Namespace.Path, project.Path, c.RefName, c.Job)
-	}
-
-	zipTarget := fmt.Sprintf("%s/%s_%s.zip", os.TempDir(), c.Ref, c.Job)
-
-	f, _ := os.Create(zipTarget)
-	defer f.Close()
-
-	putObject := &s3.GetObjectInput{
-		Bucket: aws.String(c.AwsBucket),
-		Key:    aws.String(key),
-	}
-
-	c.Ui.Output(fmt.Sprintf("Retrieve zip file from s3://%s/%s", c.AwsBucket, key))
-
-	objOutput, err := s3client.GetObject(putObject)
+	tagMatcher, err := regexp.Compile(c.TagMatcher)
 
 	if err != nil {
-		c.Ui.Output(fmt.Sprintf("Unable to download archive from s3://%s/%s, %s", c.AwsBucket, key, err))
+		c.Ui.Error(fmt.Sprintf("Error: invalid tag matcher, %s", err.Error()))
 
 		return 1
 	}
 
-	_, err = io.Copy(f, objOutput.Body)
+	s3client, err := storage.NewS3Client(c.AwsRegion, c.AwsEndPoint, c.AwsProfile)
 
 	if err != nil {
-		c.Ui.Output(fmt.Sprintf("Unable to retrieve zip file: %s, %s", zipTarget, err))
+		c.Ui.Output(fmt.Sprintf("Unable to load credentials: %s", err))
 
 		return 1
 	}
 
-	c.Ui.Output(fmt.Sprintf("Extract zip to %s", c.ExtractPath))
+	c.Options.Warn = c.Ui.Warn
 
-	err = helper.Unzip(zipTarget, c.ExtractPath)
+	store, err := c.Options.Apply(&storage.S3Storage{Client: s3client, Bucket: c.AwsBucket})
 
 	if err != nil {
-		c.Ui.Output(fmt.Sprintf("Unable to extract zip file: %s, %s", zipTarget, err))
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
 
 		return 1
 	}
 
-	os.Remove(zipTarget)
+	archiver := &Archiver{
+		Ui:           c.Ui,
+		Storage:      store,
+		Location:     fmt.Sprintf("s3://%s", c.AwsBucket),
+		Project:      project,
+		Ref:          c.Ref,
+		RefName:      c.RefName,
+		Job:          c.Job,
+		TagMatcher:   tagMatcher,
+		Format:       c.Format,
+		Verify:       c.Verify,
+		ReportPrefix: "S3_EXTRACT_",
+	}
 
-	return 0
+	return archiver.Extract(strings.Split(c.Strategy, ","), c.ExtractPath, c.ReportFile)
 }
 
 func (c *S3ExtractCommand) Synopsis() string {
-	return "Send archive to a S3 bucket."
+	return "Extract archive from a S3 bucket."
 }
 
 func (c *S3ExtractCommand) Help() string {
-	helpText := `
+	helpText := fmt.Sprintf(`
 Usage: gitlab-ci-helper s3:extract
 
   Extract archive from a S3 bucket
@@ -164,6 +160,21 @@ Options:
   -profile            The aws credentials name (default: AWS_PROFILE, if not set default)
   -bucket             The s3 bucket name (default: AWS_BUCKET)
   -tag-matcher        The regular expression to match a tag (default: semver)
+  -format             Only search archives with this format: zip, tar.gz or tar.zst
+                       (default: all formats), the format is detected on extraction
+  -strategy           The lookup strategies used to find the archive, comma separated
+                       (default: exact):
+                        exact:   the archive for the sha1 or the tag
+                        branch:  the latest archive for the reference name
+                        default: the latest archive for the project's default branch
+                        release: the latest archive stored as a release
+  -report             Write the key and the strategy used into a dotenv file, ie:
+                        S3_EXTRACT_KEY=commits/...
+                        S3_EXTRACT_STRATEGY=branch
+  -verify             Verify the archive against its manifest before the extraction
+                       (default: true), the command exits with the code 2 if the
+                       archive is corrupted and fails if the archive has no
+                       checksum. Use -verify=false to disable%s
 
 Credentials are retrieved from environment:
 
@@ -171,6 +182,7 @@ Credentials are retrieved from environment:
   GITLAB_TOKEN        The user's token
   GITLAB_API_PATH     (optional) the api path, default to: "/api/v4"
 
-`
+`, objectOptionsExtractHelp)
+
 	return strings.TrimSpace(helpText)
 }
@@ -0,0 +1,166 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/rande/gitlab-ci-helper/storage"
+)
+
+type S3ListCommand struct {
+	Ui       cli.Ui
+	Verbose  bool
+	Project  string
+	Job      string
+	Kind     string
+	Output   string
+	Metadata bool
+
+	// s3 settings
+	AwsRegion   string
+	AwsEndPoint string
+	AwsProfile  string
+	AwsBucket   string
+}
+
+func (c *S3ListCommand) Run(args []string) int {
+
+	config, err := helper.NewConfig()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	flags := flag.NewFlagSet("s3:list", flag.ContinueOnError)
+	flags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	flags.BoolVar(&c.Verbose, "verbose", false, "")
+	flags.StringVar(&c.Project, "project", os.Getenv("CI_PROJECT_ID"), "The project reference")
+	flags.StringVar(&c.Job, "job", "", "Only list the archives of this job")
+	flags.StringVar(&c.Kind, "kind", "all", "The archives to list: commits, releases or all")
+	flags.StringVar(&c.Output, "output", "table", "The output format: table or json")
+	flags.BoolVar(&c.Metadata, "metadata", true, "Retrieve the metadata of each archive")
+
+	flags.StringVar(&c.AwsRegion, "region", config.S3.Region, "The s3 region")
+	flags.StringVar(&c.AwsEndPoint, "endpoint", config.S3.Endpoint, "The s3 endpoint")
+	flags.StringVar(&c.AwsProfile, "profile", config.S3.Profile, "The aws credentials")
+	flags.StringVar(&c.AwsBucket, "bucket", config.S3.Bucket, "The s3 bucket")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	var kinds []string
+
+	switch c.Kind {
+	case "all":
+		kinds = []string{"releases", "commits"}
+	case "commits", "releases":
+		kinds = []string{c.Kind}
+	default:
+		c.Ui.Error(fmt.Sprintf("Error: invalid kind: %s", c.Kind))
+
+		return 1
+	}
+
+	if c.Output != "table" && c.Output != "json" {
+		c.Ui.Error(fmt.Sprintf("Error: invalid output format: %s", c.Output))
+
+		return 1
+	}
+
+	client := gitlab.NewClient(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
+
+	project, err := helper.GetProject(c.Project, client)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	s3client, err := storage.NewS3Client(c.AwsRegion, c.AwsEndPoint, c.AwsProfile)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: unable to load credentials, %s", err))
+
+		return 1
+	}
+
+	store := &storage.S3Storage{Client: s3client, Bucket: c.AwsBucket}
+
+	return ListArchivesOutput(c.Ui, store, project, kinds, c.Job, c.Metadata, c.Output)
+}
+
+// ListArchivesOutput writes the archives list, only the list is written to the
+// standard output so it can be piped to other scripts.
+func ListArchivesOutput(ui cli.Ui, s storage.Storage, project *gitlab.Project, kinds []string, job string, metadata bool, output string) int {
+	groups, err := ListArchives(s, project.Namespace.Path, project.Path, kinds, job, metadata)
+
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	content, err := FormatArchiveList(groups, output)
+
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	ui.Output(content)
+
+	return 0
+}
+
+func (c *S3ListCommand) Synopsis() string {
+	return "List the archives stored in a S3 bucket."
+}
+
+func (c *S3ListCommand) Help() string {
+	helpText := `
+Usage: gitlab-ci-helper s3:list
+
+  List the archives stored by s3:archive for a project, grouped by commit or
+  release, the most recent first
+
+Options:
+
+  -verbose            Add verbose information to the output
+  -project            The project reference (default: CI_PROJECT_ID)
+  -job                Only list the archives of this job (default: all jobs)
+  -kind               The archives to list: commits, releases or all (default: all)
+  -output             The output format: table or json (default: table)
+  -metadata           Retrieve the metadata attached to each archive, one request is
+                       sent per archive (default: true), use -metadata=false to disable
+  -region             The s3 region (default: AWS_REGION)
+  -endpoint           The s3 endpoint (default: AWS_ENDPOINT)
+  -profile            The aws credentials name (default: AWS_PROFILE, if not set default)
+  -bucket             The s3 bucket name (default: AWS_BUCKET)
+
+Credentials are retrieved from environment:
+
+  GITLAB_HOST         The gitlab host
+  GITLAB_TOKEN        The user's token
+  GITLAB_API_PATH     (optional) the api path, default to: "/api/v4"
+
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,210 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/rande/gitlab-ci-helper/storage"
+)
+
+type S3PruneCommand struct {
+	Ui         cli.Ui
+	Verbose    bool
+	Project    string
+	Job        string
+	TagMatcher string
+	Keep       int
+	KeepBy     string
+	DryRun     bool
+	BatchSize  int
+
+	// s3 settings
+	AwsRegion   string
+	AwsEndPoint string
+	AwsProfile  string
+	AwsBucket   string
+}
+
+func (c *S3PruneCommand) Run(args []string) int {
+
+	config, err := helper.NewConfig()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	flags := flag.NewFlagSet("s3:prune", flag.ContinueOnError)
+	flags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	flags.BoolVar(&c.Verbose, "verbose", false, "")
+	flags.StringVar(&c.Project, "project", os.Getenv("CI_PROJECT_ID"), "The project reference")
+	flags.StringVar(&c.Job, "job", "", "Only prune the archives of this job")
+	flags.StringVar(&c.TagMatcher, "tag-matcher", helper.SEMVER_PATTERN, "Regular expression to match tag (default: semver format)")
+	flags.IntVar(&c.Keep, "keep", 10, "The number of archives to keep")
+	flags.StringVar(&c.KeepBy, "keep-by", PRUNE_BY_BRANCH, "Keep the archives per branch or per job")
+	flags.BoolVar(&c.DryRun, "dry-run", false, "List the archives to delete without deleting them")
+	flags.IntVar(&c.BatchSize, "batch-size", 500, "The number of archives deleted per request")
+
+	flags.StringVar(&c.AwsRegion, "region", config.S3.Region, "The s3 region")
+	flags.StringVar(&c.AwsEndPoint, "endpoint", config.S3.Endpoint, "The s3 endpoint")
+	flags.StringVar(&c.AwsProfile, "profile", config.S3.Profile, "The aws credentials")
+	flags.StringVar(&c.AwsBucket, "bucket", config.S3.Bucket, "The s3 bucket")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if c.Keep < 1 {
+		c.Ui.Error(fmt.Sprintf("Error: %s", "the -keep option must be greater than 0"))
+
+		return 1
+	}
+
+	// a batch contains the archives and their manifests
+	if c.BatchSize < 1 || c.BatchSize*2 > storage.S3_MAX_DELETE_KEYS {
+		c.Ui.Error(fmt.Sprintf("Error: the -batch-size option must be between 1 and %d", storage.S3_MAX_DELETE_KEYS/2))
+
+		return 1
+	}
+
+	tagMatcher, err := NewPruneTagMatcher(c.TagMatcher)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: invalid tag matcher, %s", err.Error()))
+
+		return 1
+	}
+
+	client := gitlab.NewClient(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
+
+	project, err := helper.GetProject(c.Project, client)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	s3client, err := storage.NewS3Client(c.AwsRegion, c.AwsEndPoint, c.AwsProfile)
+
+	if err != nil {
+		c.Ui.Output(fmt.Sprintf("Unable to load credentials: %s", err))
+
+		return 1
+	}
+
+	pruner := &ArchivePruner{
+		Storage:    &storage.S3Storage{Client: s3client, Bucket: c.AwsBucket},
+		Namespace:  project.Namespace.Path,
+		Project:    project.Path,
+		Job:        c.Job,
+		TagMatcher: tagMatcher,
+		Keep:       c.Keep,
+		KeepBy:     c.KeepBy,
+	}
+
+	return Prune(c.Ui, pruner, fmt.Sprintf("s3://%s", c.AwsBucket), c.DryRun, c.BatchSize, c.Verbose)
+}
+
+// Prune displays the archives to delete and deletes them, unless dryRun is set.
+func Prune(ui cli.Ui, pruner *ArchivePruner, location string, dryRun bool, batchSize int, verbose bool) int {
+	plan, err := pruner.Plan()
+
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	if verbose {
+		for _, c := range plan.Keep {
+			ui.Output(fmt.Sprintf("Keep   %s/%s (ref: %s, date: %s)", location, c.Archive.Key, c.RefName, c.Object.LastModified.Format("2006-01-02 15:04:05")))
+		}
+	}
+
+	for _, c := range plan.Delete {
+		ui.Output(fmt.Sprintf("Delete %s/%s (ref: %s, date: %s)", location, c.Archive.Key, c.RefName, c.Object.LastModified.Format("2006-01-02 15:04:05")))
+	}
+
+	if dryRun {
+		ui.Output(fmt.Sprintf("Dry run: %d archive(s) to delete, %d archive(s) kept", len(plan.Delete), len(plan.Keep)))
+
+		return 0
+	}
+
+	err = pruner.Delete(plan.Delete, batchSize, func(count int) {
+		ui.Output(fmt.Sprintf("Deleted %d/%d archive(s)", count, len(plan.Delete)))
+	})
+
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	ui.Output(fmt.Sprintf("Done: %d archive(s) deleted, %d archive(s) kept", len(plan.Delete), len(plan.Keep)))
+
+	return 0
+}
+
+func (c *S3PruneCommand) Synopsis() string {
+	return "Delete old archives from a S3 bucket."
+}
+
+func (c *S3PruneCommand) Help() string {
+	helpText := `
+Usage: gitlab-ci-helper s3:prune
+
+  Delete the old archives stored by s3:archive in a S3 bucket
+
+Options:
+
+  -verbose            Add verbose information to the output, ie: the kept archives
+  -project            The project reference (default: CI_PROJECT_ID)
+  -job                Only prune the archives of this job (default: all jobs)
+  -keep               The number of archives to keep per group (default: 10)
+  -keep-by            How the archives are grouped (default: branch):
+                        branch: the last archives per branch and job are kept
+                        job:    the last archives per job are kept
+  -tag-matcher        The regular expression to match a tag (default: semver), the
+                       archives built from a matching tag are always kept, the
+                       expression must match the whole ref name, no tag is
+                       matched if empty
+  -dry-run            List the archives to delete without deleting them
+  -batch-size         The number of archives deleted per request (default: 500, max: 500)
+  -region             The s3 region (default: AWS_REGION)
+  -endpoint           The s3 endpoint (default: AWS_ENDPOINT)
+  -profile            The aws credentials name (default: AWS_PROFILE, if not set default)
+  -bucket             The s3 bucket name (default: AWS_BUCKET)
+
+Only the archives stored under commits/ are deleted, the releases/ archives are
+never deleted. The manifest of each archive is deleted with the archive.
+
+The branch and the tag of an archive are read from its metadata, with one
+request per archive, the archives without the Ref-Name metadata are kept. As the
+default -tag-matcher is not empty, the metadata is always read unless -keep-by job
+is used with -tag-matcher "".
+
+Credentials are retrieved from environment:
+
+  GITLAB_HOST         The gitlab host
+  GITLAB_TOKEN        The user's token
+  GITLAB_API_PATH     (optional) the api path, default to: "/api/v4"
+
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,221 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/rande/gitlab-ci-helper/storage"
+)
+
+type StorageArchiveCommand struct {
+	Ui           cli.Ui
+	Verbose      bool
+	Ref          string
+	RefName      string
+	Job          string
+	Project      string
+	IncludePaths helper.Paths
+	IgnorePaths  helper.Paths
+	IgnoreCVS    bool
+	ExcludeMode  string
+	ExcludeFrom  string
+	TagMatcher   string
+	Format       string
+	Stream       bool
+	PartSize     int64
+	Concurrency  int
+	Retries      int
+	Resume       bool
+	Options      ObjectOptions
+	Url          string
+}
+
+func (c *StorageArchiveCommand) Run(args []string) int {
+
+	flags := flag.NewFlagSet("storage:archive", flag.ContinueOnError)
+	flags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	flags.BoolVar(&c.Verbose, "verbose", false, "")
+	flags.StringVar(&c.Job, "job", helper.GetEnv("CI_JOB_NAME", os.Getenv("CI_BUILD_NAME")), "The job name")
+	flags.StringVar(&c.Ref, "ref", helper.GetEnv("CI_COMMIT_SHA", os.Getenv("CI_BUILD_REF")), "The reference (sha1)")
+	flags.StringVar(&c.RefName, "ref-name", helper.GetEnv("CI_COMMIT_REF_NAME", os.Getenv("CI_BUILD_REF_NAME")), "The reference name (tag or branch)")
+	flags.StringVar(&c.Project, "project", os.Getenv("CI_PROJECT_ID"), "The project reference")
+	flags.StringVar(&c.TagMatcher, "tag-matcher", helper.SEMVER_PATTERN, "Regular expression to match tag (default: semver format)")
+	flags.StringVar(&c.Format, "format", helper.FORMAT_ZIP, "The archive format: zip, tar.gz or tar.zst")
+	flags.StringVar(&c.Url, "url", os.Getenv("STORAGE_URL"), "The storage url")
+
+	flags.BoolVar(&c.IgnoreCVS, "ignore-cvs", true, "Ignore CVS files")
+	flags.StringVar(&c.ExcludeMode, "exclude-mode", helper.MATCH_GLOB, "The exclude rules syntax: glob or regexp")
+	flags.StringVar(&c.ExcludeFrom, "exclude-from", helper.IGNORE_FILE, "The file containing the exclude rules")
+	flags.BoolVar(&c.Stream, "stream", true, "Stream the zip file to the storage")
+	flags.Int64Var(&c.PartSize, "part-size", 16, "The multipart upload part size in MB")
+	flags.IntVar(&c.Concurrency, "concurrency", 4, "The number of parts uploaded in parallel")
+	flags.IntVar(&c.Retries, "retries", 5, "The number of retries per part")
+	flags.BoolVar(&c.Resume, "resume", false, "Keep a failed upload so a retry of the job resumes it")
+
+	c.Options.ArchiveFlags(flags)
+
+	c.IgnorePaths = make(helper.Paths, 0)
+	c.IncludePaths = make(helper.Paths, 0)
+
+	flags.Var(&c.IgnorePaths, "exclude", "-ignore path/to/ignore")
+	flags.Var(&c.IncludePaths, "include", "-include path/to/ignore")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	store, err := storage.New(c.Url)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	if len(c.Format) > 0 && !helper.IsArchiveFormat(c.Format) {
+		c.Ui.Error(fmt.Sprintf("Error: invalid archive format: %s", c.Format))
+
+		return 1
+	}
+
+	tagMatcher, err := regexp.Compile(c.TagMatcher)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: invalid tag matcher, %s", err.Error()))
+
+		return 1
+	}
+
+	config, err := helper.NewConfig()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	client := gitlab.NewClient(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
+
+	project, err := helper.GetProject(c.Project, client)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	if c.PartSize*1024*1024 < storage.S3_MIN_PART_SIZE {
+		c.Ui.Error(fmt.Sprintf("Error: the part size must be at least %d MB", storage.S3_MIN_PART_SIZE/1024/1024))
+
+		return 1
+	}
+
+	if len(c.IncludePaths) == 0 {
+		c.IncludePaths.Set("./") // add the current path
+	}
+
+	excludes, err := NewExcludeMatcher(c.ExcludeMode, c.IgnorePaths, c.ExcludeFrom, c.IgnoreCVS)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	if s3store, ok := store.(*storage.S3Storage); ok {
+		s3store.PartSize = c.PartSize * 1024 * 1024
+		s3store.Concurrency = c.Concurrency
+		s3store.MaxRetries = c.Retries
+		s3store.Resume = c.Resume
+		s3store.ResumeId = ResumeId(c.Job)
+	}
+
+	store, err = c.Options.Apply(store)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	archiver := &Archiver{
+		Ui:         c.Ui,
+		Storage:    store,
+		Location:   strings.SplitN(c.Url, "?", 2)[0],
+		Project:    project,
+		Ref:        c.Ref,
+		RefName:    c.RefName,
+		Job:        c.Job,
+		TagMatcher: tagMatcher,
+		Format:     c.Format,
+		Stream:     c.Stream,
+	}
+
+	return archiver.Archive(c.IncludePaths, excludes)
+}
+
+func (c *StorageArchiveCommand) Synopsis() string {
+	return "Send archive to a storage (s3, local directory)."
+}
+
+func (c *StorageArchiveCommand) Help() string {
+	helpText := fmt.Sprintf(`
+Usage: gitlab-ci-helper storage:archive
+
+  Send archive to a storage, the backend is selected from the url scheme
+
+Options:
+
+  -url                The storage url (default: STORAGE_URL)
+  -include            Path or glob pattern to include (one option per path)
+  -exclude            Rule to exclude files (one option per rule)
+  -exclude-from       The file containing the exclude rules, one rule per line
+                       (default: .ciarchiveignore, if the file exists)
+  -exclude-mode       The rules syntax: glob or regexp (default: glob)
+  -ignore-cvs         Exclude CVS files: .git .svn .bzr .hg
+  -verbose            Add verbose information to the output
+  -job                The job name (default: 9.x: CI_JOB_NAME and 8.x: CI_BUILD_NAME)
+  -ref                The reference (sha1) (default: 9.x: CI_COMMIT_SHA and 8.x: CI_BUILD_REF)
+  -ref-name           The reference name (default: 9.x: CI_COMMIT_REF_NAME and 8.x: CI_BUILD_REF_NAME)
+  -project            The project reference (default: CI_PROJECT_ID)
+  -tag-matcher        The regular expression to match a tag (default: semver)
+  -format             The archive format: zip, tar.gz or tar.zst (default: zip), the tar
+                       formats preserve the modes, the symlinks and the ownership
+  -stream             Stream the zip file to the storage without storing it in a
+                       temporary file (default: true), use -stream=false to disable
+  -part-size          The part size in MB used by s3 multipart uploads (default: 16, min: 5)
+  -concurrency        The number of parts uploaded in parallel (default: 4)
+  -retries            The number of retries per part, the delay between two attempts
+                       doubles after each failure (default: 5)
+  -resume             Keep a failed s3 upload so a retry of the job resumes it, the
+                       failed uploads are aborted otherwise (default: false)%s
+
+The exclude rules use the .gitignore syntax, see s3:archive.
+
+A manifest with the sha256 checksum of the archive and of each file is stored next
+to the archive (<key>.manifest.json).
+%s
+Credentials are retrieved from environment:
+
+  GITLAB_HOST         The gitlab host
+  GITLAB_TOKEN        The user's token
+  GITLAB_API_PATH     (optional) the api path, default to: "/api/v4"
+
+`, objectOptionsArchiveHelp, storageConfiguration)
+
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,291 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StorageArchiveCommand_Help(t *testing.T) {
+	c := &StorageArchiveCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.True(t, len(c.Help()) > 0)
+	assert.True(t, len(c.Synopsis()) > 0)
+}
+
+func Test_StorageArchiveCommand_InvalidRun(t *testing.T) {
+	c := &StorageArchiveCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.Equal(t, 1, c.Run([]string{"--foobar"}))
+}
+
+func Test_StorageArchiveCommand_Local(t *testing.T) {
+	root, err := ioutil.TempDir("", "gitlab_ci_helper_storage")
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(root)
+
+	target, err := ioutil.TempDir("", "gitlab_ci_helper_extract")
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(target)
+
+	for _, cmd := range []string{"archive", "extract"} {
+		fpProject, err := os.Open("../fixtures/project.json")
+		assert.NoError(t, err)
+
+		reqs := []*helper.FakeRequest{
+			{
+				Path:   "/api/v4/projects/3",
+				Method: "GET",
+				Response: &http.Response{
+					Body: fpProject,
+				},
+			},
+		}
+
+		helper.WrapperTestCommand(reqs, map[string]string{}, t, func(ts *httptest.Server) {
+			ui := &cli.MockUi{}
+			args := []string{"-url", "file://" + root, "-project", "3", "-job", "build", "-ref", "sha1", "-ref-name", "master"}
+
+			var code int
+			if cmd == "archive" {
+				code = (&StorageArchiveCommand{Ui: ui}).Run(append(args, "-include", "archive.go"))
+			} else {
+				code = (&StorageExtractCommand{Ui: ui}).Run(append(args, "-path", target))
+			}
+
+			assert.Equal(t, 0, code, ui.OutputWriter.String())
+		})
+	}
+
+	_, err = os.Stat(root + "/commits/diaspora/diaspora-project-site/sha1_build.zip")
+	assert.NoError(t, err)
+
+	_, err = os.Stat(target + "/archive.go")
+	assert.NoError(t, err)
+}
+
+func Test_StorageArchiveCommand_Local_Checksum(t *testing.T) {
+	root, err := ioutil.TempDir("", "gitlab_ci_helper_storage")
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(root)
+
+	target, err := ioutil.TempDir("", "gitlab_ci_helper_extract")
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(target)
+
+	key := "commits/diaspora/diaspora-project-site/sha1_build.zip"
+
+	for _, cmd := range []string{"archive", "extract"} {
+		fpProject, err := os.Open("../fixtures/project.json")
+		assert.NoError(t, err)
+
+		reqs := []*helper.FakeRequest{
+			{
+				Path:   "/api/v4/projects/3",
+				Method: "GET",
+				Response: &http.Response{
+					Body: fpProject,
+				},
+			},
+		}
+
+		helper.WrapperTestCommand(reqs, map[string]string{}, t, func(ts *httptest.Server) {
+			ui := &cli.MockUi{}
+			args := []string{"-url", "file://" + root, "-project", "3", "-job", "build", "-ref", "sha1", "-ref-name", "master"}
+
+			if cmd == "archive" {
+				assert.Equal(t, 0, (&StorageArchiveCommand{Ui: ui}).Run(append(args, "-include", "archive.go")))
+
+				return
+			}
+
+			// the checksum of the streamed archive is only stored in the manifest
+			object, err := (&storage.LocalStorage{Root: root}).Stat(key)
+			assert.NoError(t, err)
+			assert.Equal(t, "", object.Meta(META_SHA256))
+
+			// without the manifest, the archive cannot be verified
+			assert.NoError(t, os.Remove(root+"/"+key+MANIFEST_SUFFIX))
+
+			code := (&StorageExtractCommand{Ui: ui}).Run(append(args, "-path", target))
+
+			assert.Equal(t, 1, code)
+			assert.Contains(t, ui.ErrorWriter.String(), "no checksum available")
+		})
+	}
+
+	_, err = os.Stat(target + "/archive.go")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_StorageArchiveCommand_Local_Corrupted(t *testing.T) {
+	root, err := ioutil.TempDir("", "gitlab_ci_helper_storage")
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(root)
+
+	target, err := ioutil.TempDir("", "gitlab_ci_helper_extract")
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(target)
+
+	key := root + "/commits/diaspora/diaspora-project-site/sha1_build.zip"
+
+	for _, cmd := range []string{"archive", "extract"} {
+		fpProject, err := os.Open("../fixtures/project.json")
+		assert.NoError(t, err)
+
+		reqs := []*helper.FakeRequest{
+			{
+				Path:   "/api/v4/projects/3",
+				Method: "GET",
+				Response: &http.Response{
+					Body: fpProject,
+				},
+			},
+		}
+
+		helper.WrapperTestCommand(reqs, map[string]string{}, t, func(ts *httptest.Server) {
+			ui := &cli.MockUi{}
+			args := []string{"-url", "file://" + root, "-project", "3", "-job", "build", "-ref", "sha1", "-ref-name", "master"}
+
+			if cmd == "archive" {
+				assert.Equal(t, 0, (&StorageArchiveCommand{Ui: ui}).Run(append(args, "-include", "archive.go")))
+
+				return
+			}
+
+			// replace the archive with another valid archive
+			includes := make(helper.Paths, 0)
+			includes.Set("archive_lookup.go")
+			assert.NoError(t, helper.Zip(includes, nil, key))
+
+			code := (&StorageExtractCommand{Ui: ui}).Run(append(args, "-path", target))
+
+			assert.Equal(t, EXIT_CHECKSUM_MISMATCH, code)
+			assert.Contains(t, ui.ErrorWriter.String(), "Checksum mismatch for archive")
+		})
+	}
+
+	_, err = os.Stat(key + MANIFEST_SUFFIX)
+	assert.NoError(t, err)
+
+	_, err = os.Stat(target + "/archive_lookup.go")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_StorageArchiveCommand_Local_Tar(t *testing.T) {
+	root, err := ioutil.TempDir("", "gitlab_ci_helper_storage")
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(root)
+
+	target, err := ioutil.TempDir("", "gitlab_ci_helper_extract")
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(target)
+
+	for _, cmd := range []string{"archive", "extract"} {
+		fpProject, err := os.Open("../fixtures/project.json")
+		assert.NoError(t, err)
+
+		reqs := []*helper.FakeRequest{
+			{
+				Path:   "/api/v4/projects/3",
+				Method: "GET",
+				Response: &http.Response{
+					Body: fpProject,
+				},
+			},
+		}
+
+		helper.WrapperTestCommand(reqs, map[string]string{}, t, func(ts *httptest.Server) {
+			ui := &cli.MockUi{}
+			args := []string{"-url", "file://" + root, "-project", "3", "-job", "build", "-ref", "sha1", "-ref-name", "master"}
+
+			var code int
+			if cmd == "archive" {
+				code = (&StorageArchiveCommand{Ui: ui}).Run(append(args, "-include", "archive.go", "-format", "tar.zst"))
+			} else {
+				// the format is not provided, all formats are searched
+				code = (&StorageExtractCommand{Ui: ui}).Run(append(args, "-path", target))
+			}
+
+			assert.Equal(t, 0, code, ui.OutputWriter.String())
+		})
+	}
+
+	_, err = os.Stat(root + "/commits/diaspora/diaspora-project-site/sha1_build.tar.zst")
+	assert.NoError(t, err)
+
+	_, err = os.Stat(target + "/archive.go")
+	assert.NoError(t, err)
+}
+
+func Test_StorageArchiveCommand_Local_Encrypted(t *testing.T) {
+	root, err := ioutil.TempDir("", "gitlab_ci_helper_storage")
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(root)
+
+	target, err := ioutil.TempDir("", "gitlab_ci_helper_extract")
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(target)
+
+	key := "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+	for _, cmd := range []string{"archive", "extract-without-key", "extract"} {
+		fpProject, err := os.Open("../fixtures/project.json")
+		assert.NoError(t, err)
+
+		reqs := []*helper.FakeRequest{
+			{
+				Path:   "/api/v4/projects/3",
+				Method: "GET",
+				Response: &http.Response{
+					Body: fpProject,
+				},
+			},
+		}
+
+		helper.WrapperTestCommand(reqs, map[string]string{}, t, func(ts *httptest.Server) {
+			ui := &cli.MockUi{}
+			args := []string{"-url", "file://" + root, "-project", "3", "-job", "build", "-ref", "sha1", "-ref-name", "master", "-encryption-key", key}
+
+			switch cmd {
+			case "archive":
+				assert.Equal(t, 0, (&StorageArchiveCommand{Ui: ui}).Run(append(args, "-include", "archive.go")))
+			case "extract-without-key":
+				assert.Equal(t, 1, (&StorageExtractCommand{Ui: ui}).Run(append(args, "-path", target, "-encryption-key", "")))
+				assert.Contains(t, ui.ErrorWriter.String(), "the encryption key is required")
+			default:
+				assert.Equal(t, 0, (&StorageExtractCommand{Ui: ui}).Run(append(args, "-path", target)), ui.OutputWriter.String())
+				assert.Contains(t, ui.OutputWriter.String(), "Checksum verified")
+			}
+		})
+	}
+
+	_, err = os.Stat(target + "/archive.go")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,177 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/rande/gitlab-ci-helper/storage"
+)
+
+var storageConfiguration = `
+Storage url:
+
+  s3://bucket/prefix  Amazon S3 or any S3 compatible storage, the settings can be
+                      provided as query parameters: region, endpoint and profile
+                      (default: AWS_REGION, AWS_ENDPOINT and AWS_PROFILE)
+  file:///mnt/cache   A local directory, ie: a shared filesystem
+`
+
+type StorageExtractCommand struct {
+	Ui          cli.Ui
+	Verbose     bool
+	Ref         string
+	RefName     string
+	Job         string
+	Project     string
+	ExtractPath string
+	TagMatcher  string
+	Format      string
+	Strategy    string
+	ReportFile  string
+	Verify      bool
+	Options     ObjectOptions
+	Url         string
+}
+
+func (c *StorageExtractCommand) Run(args []string) int {
+
+	flags := flag.NewFlagSet("storage:extract", flag.ContinueOnError)
+	flags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	flags.BoolVar(&c.Verbose, "verbose", false, "")
+
+	flags.StringVar(&c.Job, "job", helper.GetEnv("CI_JOB_NAME", os.Getenv("CI_BUILD_NAME")), "The job name")
+	flags.StringVar(&c.Ref, "ref", helper.GetEnv("CI_COMMIT_SHA", os.Getenv("CI_BUILD_REF")), "The reference (sha1)")
+	flags.StringVar(&c.RefName, "ref-name", helper.GetEnv("CI_COMMIT_REF_NAME", os.Getenv("CI_BUILD_REF_NAME")), "The reference name (tag or branch)")
+	flags.StringVar(&c.Project, "project", os.Getenv("CI_PROJECT_ID"), "The project reference")
+	flags.StringVar(&c.ExtractPath, "path", "./", "The extract path")
+	flags.StringVar(&c.TagMatcher, "tag-matcher", helper.SEMVER_PATTERN, "Regular expression to match tag (default: semver format)")
+	flags.StringVar(&c.Format, "format", "", "The archive format, detected from the content if empty")
+	flags.StringVar(&c.Strategy, "strategy", LOOKUP_EXACT, "The lookup strategies, comma separated (exact, branch, default, release)")
+	flags.StringVar(&c.ReportFile, "report", "", "The file to store the key used to extract the archive")
+	flags.BoolVar(&c.Verify, "verify", true, "Verify the archive checksum before the extraction")
+
+	c.Options.ExtractFlags(flags)
+	flags.StringVar(&c.Url, "url", os.Getenv("STORAGE_URL"), "The storage url")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	c.Options.Warn = c.Ui.Warn
+
+	store, err := storage.New(c.Url)
+
+	if err == nil {
+		store, err = c.Options.Apply(store)
+	}
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	if len(c.Format) > 0 && !helper.IsArchiveFormat(c.Format) {
+		c.Ui.Error(fmt.Sprintf("Error: invalid archive format: %s", c.Format))
+
+		return 1
+	}
+
+	tagMatcher, err := regexp.Compile(c.TagMatcher)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: invalid tag matcher, %s", err.Error()))
+
+		return 1
+	}
+
+	config, err := helper.NewConfig()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	client := gitlab.NewClient(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
+
+	project, err := helper.GetProject(c.Project, client)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	archiver := &Archiver{
+		Ui:           c.Ui,
+		Storage:      store,
+		Location:     strings.SplitN(c.Url, "?", 2)[0],
+		Project:      project,
+		Ref:          c.Ref,
+		RefName:      c.RefName,
+		Job:          c.Job,
+		TagMatcher:   tagMatcher,
+		Format:       c.Format,
+		Verify:       c.Verify,
+		ReportPrefix: "STORAGE_EXTRACT_",
+	}
+
+	return archiver.Extract(strings.Split(c.Strategy, ","), c.ExtractPath, c.ReportFile)
+}
+
+func (c *StorageExtractCommand) Synopsis() string {
+	return "Extract archive from a storage (s3, local directory)."
+}
+
+func (c *StorageExtractCommand) Help() string {
+	helpText := fmt.Sprintf(`
+Usage: gitlab-ci-helper storage:extract
+
+  Extract archive from a storage, the backend is selected from the url scheme
+
+Options:
+
+  -url                The storage url (default: STORAGE_URL)
+  -path               The extract path (default: ./)
+  -verbose            Add verbose information to the output
+  -job                The job name (default: 9.x: CI_JOB_NAME and 8.x: CI_BUILD_NAME)
+  -ref                The reference (sha1) (default: 9.x: CI_COMMIT_SHA and 8.x: CI_BUILD_REF)
+  -ref-name           The reference name (default: 9.x: CI_COMMIT_REF_NAME and 8.x: CI_BUILD_REF_NAME)
+  -project            The project reference (default: CI_PROJECT_ID)
+  -tag-matcher        The regular expression to match a tag (default: semver)
+  -format             Only search archives with this format: zip, tar.gz or tar.zst
+                       (default: all formats), the format is detected on extraction
+  -strategy           The lookup strategies used to find the archive, comma separated
+                       (default: exact), see s3:extract
+  -report             Write the key and the strategy used into a dotenv file, ie:
+                        STORAGE_EXTRACT_KEY=commits/...
+                        STORAGE_EXTRACT_STRATEGY=branch
+  -verify             Verify the archive against its manifest before the extraction
+                       (default: true), exit code 2 if the archive is corrupted,
+                       fails if the archive has no checksum%s
+%s
+Credentials are retrieved from environment:
+
+  GITLAB_HOST         The gitlab host
+  GITLAB_TOKEN        The user's token
+  GITLAB_API_PATH     (optional) the api path, default to: "/api/v4"
+
+`, objectOptionsExtractHelp, storageConfiguration)
+
+	return strings.TrimSpace(helpText)
+}
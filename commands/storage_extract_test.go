@@ -0,0 +1,40 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StorageExtractCommand_Help(t *testing.T) {
+	c := &StorageExtractCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.True(t, len(c.Help()) > 0)
+	assert.True(t, len(c.Synopsis()) > 0)
+}
+
+func Test_StorageExtractCommand_InvalidRun(t *testing.T) {
+	c := &StorageExtractCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.Equal(t, 1, c.Run([]string{"--foobar"}))
+}
+
+func Test_StorageExtractCommand_InvalidUrl(t *testing.T) {
+	ui := &cli.MockUi{}
+	c := &StorageExtractCommand{
+		Ui: ui,
+	}
+
+	assert.Equal(t, 1, c.Run([]string{"-url", "ftp://host/path"}))
+	assert.Contains(t, ui.ErrorWriter.String(), "unsupported scheme")
+}
@@ -0,0 +1,218 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+)
+
+type VersionNextCommand struct {
+	Ui          cli.Ui
+	Verbose     bool
+	Project     string
+	Ref         string
+	Branch      string
+	Source      string
+	Dir         string
+	TagMatcher  string
+	Initial     string
+	PreReleases helper.Paths
+	Tag         bool
+}
+
+func (c *VersionNextCommand) Run(args []string) int {
+
+	flags := flag.NewFlagSet("version:next", flag.ContinueOnError)
+	flags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	c.PreReleases = make(helper.Paths, 0)
+
+	flags.BoolVar(&c.Verbose, "verbose", false, "")
+	flags.StringVar(&c.Project, "project", os.Getenv("CI_PROJECT_ID"), "The project")
+	flags.StringVar(&c.Ref, "ref", helper.GetEnv("CI_COMMIT_SHA", os.Getenv("CI_BUILD_REF")), "The commit to release")
+	flags.StringVar(&c.Branch, "branch", helper.GetEnv("CI_COMMIT_REF_NAME", os.Getenv("CI_BUILD_REF_NAME")), "The branch")
+	flags.StringVar(&c.Source, "source", "api", "The source of the tags and the commits: api or git")
+	flags.StringVar(&c.Dir, "dir", ".", "The git repository, used by the git source")
+	flags.StringVar(&c.TagMatcher, "tag-matcher", helper.SEMVER_PATTERN, "Regular expression to match tag (default: semver format)")
+	flags.StringVar(&c.Initial, "initial", "v0.1.0", "The version used if there is no release tag")
+	flags.Var(&c.PreReleases, "pre-release", "-pre-release branch=identifier")
+	flags.BoolVar(&c.Tag, "tag", false, "Create the tag with the API")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if c.Source != "api" && c.Source != "git" {
+		c.Ui.Error(fmt.Sprintf("Error: invalid source: %s", c.Source))
+
+		return 1
+	}
+
+	// the git source uses HEAD by default
+	if len(c.Ref) == 0 && (c.Source == "api" || c.Tag) {
+		c.Ui.Error("Error: the ref is required")
+
+		return 1
+	}
+
+	opts := &helper.NextVersionOptions{
+		Ref:         c.Ref,
+		Branch:      c.Branch,
+		Initial:     c.Initial,
+		PreReleases: map[string]string{},
+	}
+
+	var err error
+
+	if opts.TagMatcher, err = regexp.Compile(c.TagMatcher); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: invalid tag matcher, %s", err.Error()))
+
+		return 1
+	}
+
+	for _, p := range c.PreReleases {
+		parts := strings.SplitN(p, "=", 2)
+
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			c.Ui.Error(fmt.Sprintf("Error: invalid pre-release: %s, the format must be branch=identifier", p))
+
+			return 1
+		}
+
+		opts.PreReleases[parts[0]] = parts[1]
+	}
+
+	var source helper.VersionSource
+	var project *gitlab.Project
+	var client gitlab.Client
+
+	if c.Source == "api" || c.Tag {
+		config, err := helper.NewConfig()
+
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+			return 1
+		}
+
+		client = gitlab.NewClient(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
+
+		if project, err = helper.GetProject(c.Project, client); err != nil {
+			c.Ui.Error(fmt.Sprintf("Unable to fetch the project: %s", err.Error()))
+
+			return 1
+		}
+	}
+
+	if c.Source == "git" {
+		source = &helper.GitVersionSource{Dir: c.Dir}
+	} else {
+		source = &helper.ApiVersionSource{Project: project, Client: client}
+	}
+
+	next, err := helper.ComputeNextVersion(source, opts)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	if c.Verbose {
+		if next.Latest != nil {
+			c.Ui.Output(fmt.Sprintf("Latest release: %s, %d commits since the release", next.Latest, next.Commits))
+		} else {
+			c.Ui.Output("No release tag found, the initial version is used")
+		}
+	}
+
+	c.Ui.Output(next.Version.String())
+
+	if !c.Tag {
+		return 0
+	}
+
+	if !next.IsRelease() {
+		c.Ui.Output(fmt.Sprintf("No commit requires a release since %s, the tag is not created", next.Latest))
+
+		return 0
+	}
+
+	tag, err := client.CreateTag(project.Id, &gitlab.CreateTagOptions{
+		TagName: next.Version.String(),
+		Ref:     c.Ref,
+	})
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: unable to create the tag %s, %s", next.Version, err.Error()))
+
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Tag %s created on %s", tag.Name, tag.Target))
+
+	return 0
+}
+
+func (c *VersionNextCommand) Synopsis() string {
+	return "Compute the next semantic version from the conventional commits."
+}
+
+func (c *VersionNextCommand) Help() string {
+	helpText := `
+Usage: gitlab-ci-helper version:next [options]
+
+  Compute the next semantic version from the commits created since the
+  latest release tag, the pre-release tags are ignored. The commit messages
+  follow the conventional commits specification:
+    - feat!: or a BREAKING CHANGE: footer increments the major version,
+    - feat: increments the minor version,
+    - fix: and perf: increment the patch version,
+    - the other types do not require a release, the latest version is
+      displayed.
+
+  The -pre-release option adds a pre-release identifier with a counter on
+  the matching branches, ie: -pre-release develop=beta displays 1.3.0-beta.2
+  if the tag v1.3.0-beta.1 exists. The branch can be a pattern: feature/*.
+
+  The version is displayed on the standard output, the -tag option creates
+  the tag on the commit with the API.
+
+Options:
+
+  -project            The project, an id or a path (default: env var CI_PROJECT_ID)
+  -ref                The commit to release (default: 9.x: CI_COMMIT_SHA or
+                        8.x: CI_BUILD_REF)
+  -branch             The branch, used by the -pre-release option (default:
+                        9.x: CI_COMMIT_REF_NAME or 8.x: CI_BUILD_REF_NAME)
+  -source             The source of the tags and the commits: api or git
+                        (default: api), the git clone must contain the tags
+  -dir                The git repository used by the git source (default: .)
+  -tag-matcher        The regular expression to match a tag (default: semver)
+  -initial            The version used if there is no release tag (default: v0.1.0)
+  -pre-release        The pre-release identifier of a branch: branch=identifier,
+                        can be repeated
+  -tag                Create the tag with the API
+  -verbose            Add verbose information to the output
+
+Credentials are retrieved from environment, if the api source or -tag is used:
+
+  GITLAB_HOST         The gitlab host
+  GITLAB_TOKEN        The user's token
+  GITLAB_API_PATH     (optional) the api path, default to: "/api/v4"
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,97 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Version_Next(t *testing.T) {
+	fpProject, err := os.Open("../fixtures/project.json")
+	assert.NoError(t, err)
+
+	tags := &helper.FakeRequest{
+		Path:   "/api/v4/projects/3/repository/tags",
+		Method: "GET",
+		Response: &http.Response{
+			Body: ioutil.NopCloser(strings.NewReader(`[{"name": "v1.2.0"}, {"name": "v1.3.0-beta.1"}, {"name": "latest"}]`)),
+		},
+	}
+
+	compare := &helper.FakeRequest{
+		Path:   "/api/v4/projects/3/repository/compare",
+		Method: "GET",
+		Response: &http.Response{
+			Body: ioutil.NopCloser(strings.NewReader(`{"commits": [{"message": "fix: a bug"}, {"message": "feat: a feature"}]}`)),
+		},
+	}
+
+	create := &helper.FakeRequest{
+		Path:   "/api/v4/projects/3/repository/tags",
+		Method: "POST",
+		Response: &http.Response{
+			Body: ioutil.NopCloser(strings.NewReader(`{"name": "v1.3.0-beta.2", "target": "4f0ac8c2a1"}`)),
+		},
+	}
+
+	reqs := []*helper.FakeRequest{
+		{
+			Path:   "/api/v4/projects/3",
+			Method: "GET",
+			Response: &http.Response{
+				Body: fpProject,
+			},
+		},
+		tags, compare, create,
+	}
+
+	envs := map[string]string{
+		"CI_COMMIT_SHA":      "4f0ac8c2a1",
+		"CI_COMMIT_REF_NAME": "develop",
+	}
+
+	helper.WrapperTestCommand(reqs, envs, t, func(ts *httptest.Server) {
+		ui := &cli.MockUi{}
+		c := &VersionNextCommand{
+			Ui: ui,
+		}
+
+		code := c.Run([]string{"-project", "3", "-pre-release", "develop=beta", "-tag"})
+
+		assert.Equal(t, 0, code, ui.ErrorWriter.String())
+		assert.Equal(t, "v1.3.0-beta.2\nTag v1.3.0-beta.2 created on 4f0ac8c2a1\n", ui.OutputWriter.String())
+		assert.Equal(t, 1, create.Called)
+	})
+}
+
+func Test_Version_Next_Help(t *testing.T) {
+	c := &VersionNextCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.True(t, len(c.Help()) > 0)
+	assert.True(t, len(c.Synopsis()) > 0)
+}
+
+func Test_Version_Next_InvalidRun(t *testing.T) {
+	c := &VersionNextCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.Equal(t, 1, c.Run([]string{"--foobar"}))
+	assert.Equal(t, 1, c.Run([]string{"-source", "svn"}))
+	assert.Equal(t, 1, c.Run([]string{"-source", "git", "-pre-release", "develop"}))
+	assert.Equal(t, 1, c.Run([]string{"-source", "git", "-tag-matcher", "("}))
+}
@@ -5,39 +5,299 @@
 
 package gitlab_ci_helper
 
-import "os"
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// env var used to select the configuration file, the default files are
+	// ignored if it is set
+	CONFIG_FILE_ENV = "GITLAB_CI_HELPER_CONFIG"
+	// env var used to select the profile
+	CONFIG_PROFILE_ENV = "GITLAB_CI_HELPER_PROFILE"
+
+	DEFAULT_PROFILE = "default"
+)
+
+// ConfigFiles are loaded in this order, so the project settings override the
+// user settings. The files can be written in yaml or json.
+var ConfigFiles = []string{
+	"~/.config/gitlab-ci-helper/config.yml",
+	".gitlab-ci-helper.yml",
+	".gitlab-ci-helper.json",
+}
+
+// The env tag contains the env var overriding the file value, the secret tag
+// marks the values masked by the config:show command. The local tag marks the
+// hosts receiving the credentials, they are not read from the files of the
+// working directory: a file committed in the project could send the token of
+// the CI environment to another host.
 
 type GitLabConfig struct {
-	Host    string `json:"host"`
-	Token   string `json:"token"`
-	ApiPath string `json:"api_path"`
+	Host    string `json:"host" yaml:"host" env:"GITLAB_HOST" local:"false"`
+	Token   string `json:"token" yaml:"token" env:"GITLAB_TOKEN" secret:"true"`
+	ApiPath string `json:"api_path" yaml:"api_path" env:"GITLAB_API_PATH"`
+}
+
+type S3Config struct {
+	Region   string `json:"region" yaml:"region" env:"AWS_REGION"`
+	Endpoint string `json:"endpoint" yaml:"endpoint" env:"AWS_ENDPOINT" local:"false"`
+	Profile  string `json:"profile" yaml:"profile" env:"AWS_PROFILE"`
+	Bucket   string `json:"bucket" yaml:"bucket" env:"AWS_BUCKET"`
+}
+
+type FlowdockConfig struct {
+	Token string `json:"token" yaml:"token" env:"FLOWDOCK_SOURCE_TOKEN" secret:"true"`
+}
+
+type HipChatConfig struct {
+	Token  string `json:"token" yaml:"token" env:"HIPCHAT_TOKEN" secret:"true"`
+	Server string `json:"server" yaml:"server" env:"HIPCHAT_SERVER" local:"false"`
+}
+
+type SlackConfig struct {
+	WebhookUrl string `json:"webhook_url" yaml:"webhook_url" env:"SLACK_WEBHOOK_URL" secret:"true" local:"false"`
+	Token      string `json:"token" yaml:"token" env:"SLACK_TOKEN" secret:"true"`
+	Channel    string `json:"channel" yaml:"channel" env:"SLACK_CHANNEL"`
+}
+
+type MattermostConfig struct {
+	WebhookUrl string `json:"webhook_url" yaml:"webhook_url" env:"MATTERMOST_WEBHOOK_URL" secret:"true" local:"false"`
+	Url        string `json:"url" yaml:"url" env:"MATTERMOST_URL" local:"false"`
+	Token      string `json:"token" yaml:"token" env:"MATTERMOST_TOKEN" secret:"true"`
+	Channel    string `json:"channel" yaml:"channel" env:"MATTERMOST_CHANNEL"`
+}
+
+type WebhookConfig struct {
+	Url    string `json:"url" yaml:"url" env:"WEBHOOK_URL" local:"false"`
+	Secret string `json:"secret" yaml:"secret" env:"WEBHOOK_SECRET" secret:"true"`
 }
 
 type MailerConfig struct {
-	SubjectPrefix string   `json:"subject"`
-	Sender        string   `json:"sender"`
-	Dest          []string `json:"dest"`
-	Host          string   `json:"host"`
-	Username      string   `json:"username"`
-	Password      string   `json:"password"`
+	SubjectPrefix string   `json:"subject" yaml:"subject" env:"MAIL_SUBJECT"`
+	Sender        string   `json:"sender" yaml:"sender" env:"MAIL_SENDER"`
+	Dest          []string `json:"dest" yaml:"dest" env:"MAIL_DEST"`
+	Host          string   `json:"host" yaml:"host" env:"MAIL_HOST" local:"false"`
+	Username      string   `json:"username" yaml:"username" env:"MAIL_USERNAME"`
+	Password      string   `json:"password" yaml:"password" env:"MAIL_PASSWORD" secret:"true"`
 }
 
 type Config struct {
-	Gitlab *GitLabConfig `json:"gitlab"`
+	Gitlab     *GitLabConfig     `json:"gitlab" yaml:"gitlab"`
+	S3         *S3Config         `json:"s3" yaml:"s3"`
+	Flowdock   *FlowdockConfig   `json:"flowdock" yaml:"flowdock"`
+	HipChat    *HipChatConfig    `json:"hipchat" yaml:"hipchat"`
+	Slack      *SlackConfig      `json:"slack" yaml:"slack"`
+	Mattermost *MattermostConfig `json:"mattermost" yaml:"mattermost"`
+	Webhook    *WebhookConfig    `json:"webhook" yaml:"webhook"`
+	Mailer     *MailerConfig     `json:"mail" yaml:"mail"`
+
+	// the loaded profile and files
+	Profile string   `json:"-" yaml:"-"`
+	Files   []string `json:"-" yaml:"-"`
+}
+
+// configFile contains the settings shared by all profiles, and the profiles
+// overriding them.
+type configFile struct {
+	Config `yaml:",inline"`
+
+	Profiles map[string]*Config `yaml:"profiles"`
+}
+
+// NewConfig loads the configuration from the file and the profile selected
+// with the env vars.
+func NewConfig() (*Config, error) {
+	return LoadConfig(os.Getenv(CONFIG_FILE_ENV), os.Getenv(CONFIG_PROFILE_ENV))
+}
+
+// LoadConfig loads the configuration with the following precedence: env vars,
+// the selected profile and the shared settings of the files. If file is
+// empty, the default files are loaded if they exist.
+func LoadConfig(file, profile string) (*Config, error) {
+	config := &Config{
+		Gitlab:     &GitLabConfig{},
+		S3:         &S3Config{},
+		Flowdock:   &FlowdockConfig{},
+		HipChat:    &HipChatConfig{},
+		Slack:      &SlackConfig{},
+		Mattermost: &MattermostConfig{},
+		Webhook:    &WebhookConfig{},
+		Mailer:     &MailerConfig{},
+		Profile:    profile,
+	}
+
+	if len(config.Profile) == 0 {
+		config.Profile = DEFAULT_PROFILE
+	}
+
+	files := ConfigFiles
+	if len(file) > 0 {
+		files = []string{file}
+	}
+
+	found := config.Profile == DEFAULT_PROFILE
+
+	for _, name := range files {
+		path := expandHome(name)
+
+		data, err := ioutil.ReadFile(path)
+
+		if os.IsNotExist(err) && len(file) == 0 {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read the configuration file: %s, %s", path, err)
+		}
+
+		f := &configFile{}
+
+		if err := yaml.Unmarshal(data, f); err != nil {
+			return nil, fmt.Errorf("Unable to parse the configuration file: %s, %s", path, err)
+		}
+
+		// the explicit file and the user file are trusted, unlike the files
+		// of the working directory
+		local := len(file) == 0 && !strings.HasPrefix(name, "~/")
+
+		mergeConfig(reflect.ValueOf(config).Elem(), reflect.ValueOf(&f.Config).Elem(), local)
+
+		if p, ok := f.Profiles[config.Profile]; ok && p != nil {
+			mergeConfig(reflect.ValueOf(config).Elem(), reflect.ValueOf(p).Elem(), local)
+
+			found = true
+		}
+
+		config.Files = append(config.Files, path)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("Unable to find the profile %s in the configuration files: %v", config.Profile, config.Files)
+	}
+
+	loadEnv(reflect.ValueOf(config).Elem())
+
+	if config.Gitlab.ApiPath == "" {
+		config.Gitlab.ApiPath = "/api/v4"
+	}
+
+	if config.S3.Profile == "" {
+		config.S3.Profile = "default"
+	}
+
+	if config.HipChat.Server == "" {
+		config.HipChat.Server = "https://api.hipchat.com"
+	}
+
+	return config, nil
 }
 
-func NewConfig() *Config {
-	gitlab := &GitLabConfig{
-		Host:    os.Getenv("GITLAB_HOST"),
-		Token:   os.Getenv("GITLAB_TOKEN"),
-		ApiPath: os.Getenv("GITLAB_API_PATH"),
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(os.Getenv("HOME"), path[2:])
 	}
 
-	if gitlab.ApiPath == "" {
-		gitlab.ApiPath = "/api/v4"
+	return path
+}
+
+// mergeConfig copies the non empty values of src into dst, the sections are
+// merged recursively. The values tagged local:"false" are ignored if src is
+// a file of the working directory.
+func mergeConfig(dst, src reflect.Value, local bool) {
+	for i := 0; i < src.NumField(); i++ {
+		if len(src.Type().Field(i).PkgPath) > 0 || src.Type().Field(i).Tag.Get("yaml") == "-" {
+			continue
+		}
+
+		if local && src.Type().Field(i).Tag.Get("local") == "false" {
+			continue
+		}
+
+		sf, df := src.Field(i), dst.Field(i)
+
+		switch sf.Kind() {
+		case reflect.Ptr:
+			if sf.IsNil() {
+				continue
+			}
+
+			if df.IsNil() {
+				df.Set(reflect.New(df.Type().Elem()))
+			}
+
+			mergeConfig(df.Elem(), sf.Elem(), local)
+		case reflect.String, reflect.Slice:
+			if sf.Len() > 0 {
+				df.Set(sf)
+			}
+		}
+	}
+}
+
+// loadEnv overrides the values with the env vars declared in the env tags,
+// the lists are comma separated.
+func loadEnv(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+
+		if f.Kind() == reflect.Ptr && !f.IsNil() {
+			loadEnv(f.Elem())
+
+			continue
+		}
+
+		value := os.Getenv(v.Type().Field(i).Tag.Get("env"))
+
+		if len(v.Type().Field(i).Tag.Get("env")) == 0 || len(value) == 0 {
+			continue
+		}
+
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString(value)
+		case reflect.Slice:
+			f.Set(reflect.ValueOf(strings.Split(value, ",")))
+		}
 	}
+}
+
+// Masked returns a copy of the configuration with the secret values masked,
+// only the last characters of the long secrets are kept.
+func (c *Config) Masked() *Config {
+	masked := &Config{Profile: c.Profile, Files: c.Files}
+
+	mergeConfig(reflect.ValueOf(masked).Elem(), reflect.ValueOf(c).Elem(), false)
+	maskSecrets(reflect.ValueOf(masked).Elem())
+
+	return masked
+}
+
+func maskSecrets(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+
+		if f.Kind() == reflect.Ptr && !f.IsNil() {
+			maskSecrets(f.Elem())
+
+			continue
+		}
+
+		if v.Type().Field(i).Tag.Get("secret") != "true" || f.Kind() != reflect.String || f.Len() == 0 {
+			continue
+		}
 
-	return &Config{
-		Gitlab: gitlab,
+		if f.Len() > 12 {
+			f.SetString("****" + f.String()[f.Len()-4:])
+		} else {
+			f.SetString("****")
+		}
 	}
 }
@@ -0,0 +1,151 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testConfigFile = `
+gitlab:
+  host: https://gitlab.example.com
+  token: shared-token
+s3:
+  region: eu-west-1
+  bucket: artifacts
+mail:
+  dest: [dev@example.com]
+profiles:
+  production:
+    gitlab:
+      token: production-secret-token
+    s3:
+      bucket: artifacts-production
+`
+
+func writeTestConfig(t *testing.T, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "gitlab_ci_helper_config")
+	assert.NoError(t, err)
+
+	file := filepath.Join(dir, "config.yml")
+	assert.NoError(t, ioutil.WriteFile(file, []byte(content), 0644))
+
+	return file, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func Test_LoadConfig_Profiles(t *testing.T) {
+	file, clean := writeTestConfig(t, testConfigFile)
+	defer clean()
+
+	config, err := LoadConfig(file, "")
+	assert.NoError(t, err)
+	assert.Equal(t, DEFAULT_PROFILE, config.Profile)
+	assert.Equal(t, []string{file}, config.Files)
+	assert.Equal(t, "https://gitlab.example.com", config.Gitlab.Host)
+	assert.Equal(t, "shared-token", config.Gitlab.Token)
+	assert.Equal(t, "/api/v4", config.Gitlab.ApiPath)
+	assert.Equal(t, "artifacts", config.S3.Bucket)
+	assert.Equal(t, "default", config.S3.Profile)
+	assert.Equal(t, []string{"dev@example.com"}, config.Mailer.Dest)
+
+	config, err = LoadConfig(file, "production")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://gitlab.example.com", config.Gitlab.Host)
+	assert.Equal(t, "production-secret-token", config.Gitlab.Token)
+	assert.Equal(t, "eu-west-1", config.S3.Region)
+	assert.Equal(t, "artifacts-production", config.S3.Bucket)
+
+	_, err = LoadConfig(file, "staging")
+	assert.Error(t, err)
+}
+
+func Test_LoadConfig_Env(t *testing.T) {
+	file, clean := writeTestConfig(t, testConfigFile)
+	defer clean()
+
+	os.Setenv("AWS_BUCKET", "env-bucket")
+	os.Setenv("MAIL_DEST", "a@example.com,b@example.com")
+	defer os.Unsetenv("AWS_BUCKET")
+	defer os.Unsetenv("MAIL_DEST")
+
+	config, err := LoadConfig(file, "production")
+	assert.NoError(t, err)
+	assert.Equal(t, "env-bucket", config.S3.Bucket)
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, config.Mailer.Dest)
+}
+
+func Test_LoadConfig_Local(t *testing.T) {
+	file, clean := writeTestConfig(t, testConfigFile+`
+webhook:
+  url: https://attacker.example.com
+hipchat:
+  server: https://hipchat.attacker.example.com
+`)
+	defer clean()
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+
+	home := os.Getenv("HOME")
+	defer os.Setenv("HOME", home)
+
+	dir := filepath.Dir(file)
+	os.Setenv("HOME", dir)
+	os.Chdir(dir)
+	os.Rename(file, filepath.Join(dir, ".gitlab-ci-helper.yml"))
+
+	// the hosts receiving the credentials are not read from the working
+	// directory
+	config, err := LoadConfig("", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{".gitlab-ci-helper.yml"}, config.Files)
+	assert.Equal(t, "", config.Gitlab.Host)
+	assert.Equal(t, "shared-token", config.Gitlab.Token)
+	assert.Equal(t, "", config.Webhook.Url)
+	assert.Equal(t, "https://api.hipchat.com", config.HipChat.Server)
+	assert.Equal(t, "artifacts", config.S3.Bucket)
+
+	// the explicit file is trusted
+	config, err = LoadConfig(".gitlab-ci-helper.yml", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://gitlab.example.com", config.Gitlab.Host)
+	assert.Equal(t, "https://attacker.example.com", config.Webhook.Url)
+	assert.Equal(t, "https://hipchat.attacker.example.com", config.HipChat.Server)
+}
+
+func Test_LoadConfig_Invalid(t *testing.T) {
+	file, clean := writeTestConfig(t, "gitlab: [")
+	defer clean()
+
+	_, err := LoadConfig(file, "")
+	assert.Error(t, err)
+
+	_, err = LoadConfig(file+".missing", "")
+	assert.Error(t, err)
+}
+
+func Test_Config_Masked(t *testing.T) {
+	file, clean := writeTestConfig(t, testConfigFile)
+	defer clean()
+
+	config, err := LoadConfig(file, "production")
+	assert.NoError(t, err)
+
+	masked := config.Masked()
+	assert.Equal(t, "****oken", masked.Gitlab.Token)
+	assert.Equal(t, "https://gitlab.example.com", masked.Gitlab.Host)
+	assert.Equal(t, "production-secret-token", config.Gitlab.Token)
+
+	config.Gitlab.Token = "short"
+	assert.Equal(t, "****", config.Masked().Gitlab.Token)
+}
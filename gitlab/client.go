@@ -0,0 +1,275 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package gitlab is a minimal client for the GitLab v4 API, it only covers
+// the resources used by the helper commands.
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client is the interface used by the commands to talk to GitLab, so they can
+// be tested against a fake implementation.
+type Client interface {
+	// the project id can be the numeric id or the path with namespace
+	Project(id string) (*Project, error)
+	Projects(opts *ProjectListOptions) ([]*Project, *Response, error)
+
+	Pipelines(projectId int, opts *PipelineListOptions) ([]*Pipeline, *Response, error)
+	Pipeline(projectId, pipelineId int) (*Pipeline, error)
+	CreatePipeline(projectId int, opts *CreatePipelineOptions) (*Pipeline, error)
+	TriggerPipeline(projectId int, opts *TriggerPipelineOptions) (*Pipeline, error)
+	PipelineJobs(projectId, pipelineId int, opts *ListOptions) ([]*Job, *Response, error)
+	PipelineBridges(projectId, pipelineId int, opts *ListOptions) ([]*Bridge, *Response, error)
+
+	Jobs(projectId int, opts *JobListOptions) ([]*Job, *Response, error)
+	Job(projectId, jobId int) (*Job, error)
+	JobArtifacts(projectId, jobId int) (io.ReadCloser, error)
+	RefArtifacts(projectId int, ref, job string) (io.ReadCloser, error)
+
+	Commits(projectId int, opts *CommitListOptions) ([]*Commit, *Response, error)
+	Commit(projectId int, sha string) (*Commit, error)
+	CommitDiff(projectId int, sha string, opts *ListOptions) ([]*Diff, *Response, error)
+	Compare(projectId int, from, to string) (*Comparison, error)
+
+	Tags(projectId int, opts *TagListOptions) ([]*Tag, *Response, error)
+	CreateTag(projectId int, opts *CreateTagOptions) (*Tag, error)
+
+	MergeRequests(projectId int, opts *MergeRequestListOptions) ([]*MergeRequest, *Response, error)
+	MergeRequest(projectId, iid int) (*MergeRequest, error)
+
+	Releases(projectId int, opts *ListOptions) ([]*Release, *Response, error)
+	Release(projectId int, tag string) (*Release, error)
+	CreateRelease(projectId int, opts *ReleaseOptions) (*Release, error)
+	UpdateRelease(projectId int, tag string, opts *ReleaseOptions) (*Release, error)
+	CreateReleaseLink(projectId int, tag string, link *ReleaseLink) (*ReleaseLink, error)
+
+	Variables(projectId int, opts *ListOptions) ([]*Variable, *Response, error)
+	Variable(projectId int, key string) (*Variable, error)
+}
+
+var _ Client = (*HttpClient)(nil)
+
+// ListOptions contains the pagination parameters shared by the list
+// endpoints, GitLab uses a page size of 20 by default.
+type ListOptions struct {
+	Page    int
+	PerPage int
+}
+
+func (o *ListOptions) values() url.Values {
+	v := url.Values{}
+
+	if o == nil {
+		return v
+	}
+
+	if o.Page > 0 {
+		v.Set("page", strconv.Itoa(o.Page))
+	}
+
+	if o.PerPage > 0 {
+		v.Set("per_page", strconv.Itoa(o.PerPage))
+	}
+
+	return v
+}
+
+// Response contains the pagination headers of a list response, NextPage is 0
+// if there is no more page to read.
+type Response struct {
+	StatusCode int
+	Page       int
+	NextPage   int
+	PerPage    int
+	Total      int
+	TotalPages int
+}
+
+func newResponse(resp *http.Response) *Response {
+	header := func(name string) int {
+		v, _ := strconv.Atoi(resp.Header.Get(name))
+
+		return v
+	}
+
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Page:       header("X-Page"),
+		NextPage:   header("X-Next-Page"),
+		PerPage:    header("X-Per-Page"),
+		Total:      header("X-Total"),
+		TotalPages: header("X-Total-Pages"),
+	}
+}
+
+// HttpClient implements the Client interface with the GitLab REST API.
+type HttpClient struct {
+	BaseUrl string
+	ApiPath string
+	Token   string
+	Client  *http.Client
+
+	// number of retries when the rate limit is reached
+	MaxRetries int
+	// maximum delay to wait before a retry
+	MaxRetryWait time.Duration
+
+	sleep func(d time.Duration)
+}
+
+func NewClient(baseUrl, apiPath, token string) *HttpClient {
+	return &HttpClient{
+		BaseUrl:      strings.TrimRight(baseUrl, "/"),
+		ApiPath:      apiPath,
+		Token:        token,
+		Client:       &http.Client{},
+		MaxRetries:   3,
+		MaxRetryWait: 60 * time.Second,
+		sleep:        time.Sleep,
+	}
+}
+
+// do sends the request and returns the response if the status code is a
+// 2xx one, the request is retried if the rate limit is reached.
+func (c *HttpClient) do(method, path string, params url.Values, body interface{}) (*http.Response, error) {
+	u := c.BaseUrl + c.ApiPath + path
+
+	if len(params) > 0 {
+		u = fmt.Sprintf("%s?%s", u, params.Encode())
+	}
+
+	var data []byte
+
+	if body != nil {
+		var err error
+
+		if data, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+
+	httpClient := c.Client
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(method, u, bytes.NewReader(data))
+
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("PRIVATE-TOKEN", c.Token)
+		req.Header.Set("Accept", "application/json")
+
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := httpClient.Do(req)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return resp, nil
+		}
+
+		err = newErrorResponse(method, path, resp)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusTooManyRequests || attempt >= c.MaxRetries {
+			return nil, err
+		}
+
+		c.wait(retryAfter(resp.Header, attempt))
+	}
+}
+
+func (c *HttpClient) wait(d time.Duration) {
+	if c.MaxRetryWait > 0 && d > c.MaxRetryWait {
+		d = c.MaxRetryWait
+	}
+
+	if c.sleep == nil {
+		c.sleep = time.Sleep
+	}
+
+	c.sleep(d)
+}
+
+// retryAfter computes the delay from the rate limit headers sent by GitLab,
+// an exponential delay is used if the headers are missing.
+func retryAfter(header http.Header, attempt int) time.Duration {
+	if v, err := strconv.Atoi(header.Get("Retry-After")); err == nil && v >= 0 {
+		return time.Duration(v) * time.Second
+	}
+
+	if v, err := strconv.ParseInt(header.Get("RateLimit-Reset"), 10, 64); err == nil {
+		if d := time.Until(time.Unix(v, 0)); d > 0 {
+			return d
+		}
+	}
+
+	return time.Duration(1<<uint(attempt)) * time.Second
+}
+
+// get decodes the json payload of the resource into v.
+func (c *HttpClient) get(path string, params url.Values, v interface{}) (*Response, error) {
+	return c.send("GET", path, params, nil, v)
+}
+
+func (c *HttpClient) send(method, path string, params url.Values, body, v interface{}) (*Response, error) {
+	resp, err := c.do(method, path, params, body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return nil, fmt.Errorf("Unable to decode the response from %s, err: %s", path, err)
+		}
+	}
+
+	return newResponse(resp), nil
+}
+
+// download returns the body of the resource, the caller must close it.
+func (c *HttpClient) download(path string, params url.Values) (io.ReadCloser, error) {
+	resp, err := c.do("GET", path, params, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+func setString(params url.Values, name, value string) {
+	if len(value) > 0 {
+		params.Set(name, value)
+	}
+}
+
+func setBool(params url.Values, name string, value bool) {
+	if value {
+		params.Set(name, "true")
+	}
+}
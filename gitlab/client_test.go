@@ -0,0 +1,215 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(handler http.HandlerFunc) (*HttpClient, *httptest.Server) {
+	ts := httptest.NewServer(handler)
+
+	return NewClient(ts.URL, "/api/v4", "token"), ts
+}
+
+func Test_HttpClient_Pagination(t *testing.T) {
+	client, ts := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token", r.Header.Get("PRIVATE-TOKEN"))
+		assert.Equal(t, "/api/v4/projects/3/pipelines", r.URL.Path)
+		assert.Equal(t, "2", r.URL.Query().Get("page"))
+		assert.Equal(t, "master", r.URL.Query().Get("ref"))
+
+		w.Header().Set("X-Page", "2")
+		w.Header().Set("X-Next-Page", "3")
+		w.Header().Set("X-Total-Pages", "4")
+		w.Write([]byte(`[{"id": 48, "status": "success"}]`))
+	})
+
+	defer ts.Close()
+
+	pipelines, resp, err := client.Pipelines(3, &PipelineListOptions{ListOptions: ListOptions{Page: 2}, Ref: "master"})
+
+	assert.NoError(t, err)
+	assert.Len(t, pipelines, 1)
+	assert.Equal(t, 48, pipelines[0].Id)
+	assert.Equal(t, 2, resp.Page)
+	assert.Equal(t, 3, resp.NextPage)
+	assert.Equal(t, 4, resp.TotalPages)
+}
+
+func Test_HttpClient_Project_Path(t *testing.T) {
+	client, ts := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v4/projects/group%2Fsubgroup%2Fproject", r.URL.EscapedPath())
+
+		w.Write([]byte(`{"id": 3, "path_with_namespace": "group/subgroup/project"}`))
+	})
+
+	defer ts.Close()
+
+	project, err := client.Project("group/subgroup/project")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, project.Id)
+}
+
+func Test_HttpClient_ErrorResponse(t *testing.T) {
+	client, ts := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "404 Project Not Found"}`))
+	})
+
+	defer ts.Close()
+
+	_, err := client.Project("3")
+
+	assert.True(t, IsNotFound(err))
+	assert.False(t, IsUnauthorized(err))
+	assert.Equal(t, "Invalid response from GET /projects/3, status: 404, message: 404 Project Not Found", err.Error())
+}
+
+func Test_HttpClient_ErrorResponse_Validation(t *testing.T) {
+	client, ts := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message": {"tag_name": ["is missing"], "name": ["is too long"]}}`))
+	})
+
+	defer ts.Close()
+
+	_, err := client.CreateRelease(3, &ReleaseOptions{})
+
+	assert.Equal(t, "name is too long, tag_name is missing", err.(*ErrorResponse).Message)
+}
+
+func Test_HttpClient_RateLimit(t *testing.T) {
+	calls, limited := 0, 2
+
+	client, ts := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		if calls <= limited {
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			return
+		}
+
+		w.Write([]byte(`{"id": 69, "name": "rubocop"}`))
+	})
+
+	defer ts.Close()
+
+	waits := []time.Duration{}
+	client.sleep = func(d time.Duration) {
+		waits = append(waits, d)
+	}
+
+	job, err := client.Job(3, 69)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "rubocop", job.Name)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []time.Duration{60 * time.Second, 60 * time.Second}, waits)
+
+	// the retries are exhausted
+	calls, limited = 0, 10
+
+	_, err = client.Job(3, 69)
+
+	assert.True(t, IsRateLimited(err))
+	assert.Equal(t, 1+client.MaxRetries, calls)
+}
+
+func Test_HttpClient_CreateRelease(t *testing.T) {
+	client, ts := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/v4/projects/3/releases", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		data, _ := ioutil.ReadAll(r.Body)
+
+		payload := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(data, &payload))
+		assert.Equal(t, "v1.0.0", payload["tag_name"])
+		assert.NotContains(t, payload, "ref")
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write(data)
+	})
+
+	defer ts.Close()
+
+	release, err := client.CreateRelease(3, &ReleaseOptions{
+		TagName: "v1.0.0",
+		Assets: &ReleaseAssets{
+			Links: []*ReleaseLink{{Name: "archive", Url: "https://example.com/v1.0.0.zip"}},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.0.0", release.TagName)
+	assert.Equal(t, "archive", release.Assets.Links[0].Name)
+}
+
+func Test_HttpClient_CreateReleaseLink(t *testing.T) {
+	client, ts := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/v4/projects/3/releases/v1.0.0/assets/links", r.URL.Path)
+
+		payload := map[string]interface{}{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, "build (zip)", payload["name"])
+		assert.Equal(t, "package", payload["link_type"])
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 2, "name": "build (zip)", "url": "https://example.com/v1.0.0_build.zip", "link_type": "package"}`))
+	})
+
+	defer ts.Close()
+
+	link, err := client.CreateReleaseLink(3, "v1.0.0", &ReleaseLink{
+		Name:     "build (zip)",
+		Url:      "https://example.com/v1.0.0_build.zip",
+		LinkType: "package",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, link.Id)
+}
+
+func Test_HttpClient_TriggerPipeline(t *testing.T) {
+	client, ts := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/v4/projects/3/trigger/pipeline", r.URL.Path)
+
+		payload := map[string]interface{}{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, "trigger-token", payload["token"])
+		assert.Equal(t, "master", payload["ref"])
+		assert.Equal(t, map[string]interface{}{"DEPLOY": "true"}, payload["variables"])
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 20, "ref": "master", "status": "created"}`))
+	})
+
+	defer ts.Close()
+
+	pipeline, err := client.TriggerPipeline(3, &TriggerPipelineOptions{
+		Token:     "trigger-token",
+		Ref:       "master",
+		Variables: map[string]string{"DEPLOY": "true"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 20, pipeline.Id)
+	assert.Equal(t, "created", pipeline.Status)
+}
@@ -0,0 +1,104 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab
+
+import (
+	"fmt"
+	"net/url"
+)
+
+type Commit struct {
+	Id             string   `json:"id"`
+	ShortId        string   `json:"short_id"`
+	Title          string   `json:"title"`
+	Message        string   `json:"message"`
+	AuthorName     string   `json:"author_name"`
+	AuthorEmail    string   `json:"author_email"`
+	AuthoredDate   string   `json:"authored_date"`
+	CommitterName  string   `json:"committer_name"`
+	CommitterEmail string   `json:"committer_email"`
+	CommittedDate  string   `json:"committed_date"`
+	CreatedAt      string   `json:"created_at"`
+	ParentIds      []string `json:"parent_ids"`
+	WebUrl         string   `json:"web_url"`
+}
+
+type Diff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+	Diff        string `json:"diff"`
+}
+
+// Comparison contains the commits and the diffs between two references.
+type Comparison struct {
+	Commit  *Commit   `json:"commit"`
+	Commits []*Commit `json:"commits"`
+	Diffs   []*Diff   `json:"diffs"`
+}
+
+type CommitListOptions struct {
+	ListOptions
+
+	RefName string
+	Since   string
+	Until   string
+	Path    string
+}
+
+func (c *HttpClient) Commits(projectId int, opts *CommitListOptions) ([]*Commit, *Response, error) {
+	if opts == nil {
+		opts = &CommitListOptions{}
+	}
+
+	params := opts.ListOptions.values()
+	setString(params, "ref_name", opts.RefName)
+	setString(params, "since", opts.Since)
+	setString(params, "until", opts.Until)
+	setString(params, "path", opts.Path)
+
+	commits := []*Commit{}
+
+	resp, err := c.get(fmt.Sprintf("/projects/%d/repository/commits", projectId), params, &commits)
+
+	return commits, resp, err
+}
+
+func (c *HttpClient) Commit(projectId int, sha string) (*Commit, error) {
+	commit := &Commit{}
+
+	if _, err := c.get(fmt.Sprintf("/projects/%d/repository/commits/%s", projectId, url.PathEscape(sha)), nil, commit); err != nil {
+		return nil, err
+	}
+
+	return commit, nil
+}
+
+func (c *HttpClient) CommitDiff(projectId int, sha string, opts *ListOptions) ([]*Diff, *Response, error) {
+	diffs := []*Diff{}
+
+	resp, err := c.get(fmt.Sprintf("/projects/%d/repository/commits/%s/diff", projectId, url.PathEscape(sha)), opts.values(), &diffs)
+
+	return diffs, resp, err
+}
+
+// Compare returns the commits and the diffs between the two references, the
+// result is not paginated.
+func (c *HttpClient) Compare(projectId int, from, to string) (*Comparison, error) {
+	params := url.Values{}
+	params.Set("from", from)
+	params.Set("to", to)
+
+	comparison := &Comparison{}
+
+	if _, err := c.get(fmt.Sprintf("/projects/%d/repository/compare", projectId), params, comparison); err != nil {
+		return nil, err
+	}
+
+	return comparison, nil
+}
@@ -0,0 +1,109 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ErrorResponse is returned when the API answers with a non 2xx status code,
+// the message is extracted from the json payload if available.
+type ErrorResponse struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+}
+
+func (e *ErrorResponse) Error() string {
+	if len(e.Message) == 0 {
+		return fmt.Sprintf("Invalid response from %s %s, status: %d", e.Method, e.Path, e.StatusCode)
+	}
+
+	return fmt.Sprintf("Invalid response from %s %s, status: %d, message: %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+func newErrorResponse(method, path string, resp *http.Response) *ErrorResponse {
+	e := &ErrorResponse{
+		Method:     method,
+		Path:       path,
+		StatusCode: resp.StatusCode,
+	}
+
+	data, _ := ioutil.ReadAll(resp.Body)
+
+	payload := map[string]interface{}{}
+
+	if err := json.Unmarshal(data, &payload); err != nil {
+		e.Message = strings.TrimSpace(string(data))
+
+		return e
+	}
+
+	messages := []string{}
+	for _, name := range []string{"message", "error", "error_description"} {
+		if v, ok := payload[name]; ok {
+			messages = append(messages, formatMessage(v))
+		}
+	}
+
+	e.Message = strings.Join(messages, ", ")
+
+	return e
+}
+
+// formatMessage flattens the validation errors, ie: {"name": ["is too long"]}
+func formatMessage(v interface{}) string {
+	switch m := v.(type) {
+	case string:
+		return m
+	case []interface{}:
+		parts := []string{}
+		for _, p := range m {
+			parts = append(parts, formatMessage(p))
+		}
+
+		return strings.Join(parts, ", ")
+	case map[string]interface{}:
+		parts := []string{}
+		for k, p := range m {
+			parts = append(parts, fmt.Sprintf("%s %s", k, formatMessage(p)))
+		}
+
+		sort.Strings(parts)
+
+		return strings.Join(parts, ", ")
+	}
+
+	return fmt.Sprintf("%v", v)
+}
+
+func hasStatus(err error, code int) bool {
+	e, ok := err.(*ErrorResponse)
+
+	return ok && e.StatusCode == code
+}
+
+// IsNotFound returns true if the resource does not exist, or if the token
+// is not allowed to see it as GitLab does not make the difference.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized) || hasStatus(err, http.StatusForbidden)
+}
+
+// IsRateLimited returns true if the rate limit is still reached once the
+// retries are exhausted.
+func IsRateLimited(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
@@ -0,0 +1,110 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+)
+
+type User struct {
+	Id       int    `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+}
+
+type Runner struct {
+	Id          int      `json:"id"`
+	Description string   `json:"description"`
+	Active      bool     `json:"active"`
+	IsShared    bool     `json:"is_shared"`
+	TagList     []string `json:"tag_list"`
+}
+
+type ArtifactsFile struct {
+	Filename string `json:"filename"`
+	Size     int    `json:"size"`
+}
+
+type Job struct {
+	Id            int           `json:"id"`
+	Name          string        `json:"name"`
+	Stage         string        `json:"stage"`
+	Status        string        `json:"status"`
+	Ref           string        `json:"ref"`
+	Tag           bool          `json:"tag"`
+	AllowFailure  bool          `json:"allow_failure"`
+	Coverage      float64       `json:"coverage"`
+	Duration      float64       `json:"duration"`
+	WebUrl        string        `json:"web_url"`
+	CreatedAt     string        `json:"created_at"`
+	StartedAt     string        `json:"started_at"`
+	FinishedAt    string        `json:"finished_at"`
+	ArtifactsFile ArtifactsFile `json:"artifacts_file"`
+	Commit        *Commit       `json:"commit"`
+	Pipeline      *Pipeline     `json:"pipeline"`
+	Runner        *Runner       `json:"runner"`
+	User          *User         `json:"user"`
+}
+
+func (j *Job) PipelineId() int {
+	if j.Pipeline == nil {
+		return 0
+	}
+
+	return j.Pipeline.Id
+}
+
+type JobListOptions struct {
+	ListOptions
+
+	// created, pending, running, failed, success, canceled, skipped or manual
+	Scope []string
+}
+
+func (c *HttpClient) Jobs(projectId int, opts *JobListOptions) ([]*Job, *Response, error) {
+	if opts == nil {
+		opts = &JobListOptions{}
+	}
+
+	params := opts.ListOptions.values()
+	for _, scope := range opts.Scope {
+		params.Add("scope[]", scope)
+	}
+
+	jobs := []*Job{}
+
+	resp, err := c.get(fmt.Sprintf("/projects/%d/jobs", projectId), params, &jobs)
+
+	return jobs, resp, err
+}
+
+func (c *HttpClient) Job(projectId, jobId int) (*Job, error) {
+	job := &Job{}
+
+	if _, err := c.get(fmt.Sprintf("/projects/%d/jobs/%d", projectId, jobId), nil, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// JobArtifacts returns the artifacts archive of the job, the caller must
+// close the reader.
+func (c *HttpClient) JobArtifacts(projectId, jobId int) (io.ReadCloser, error) {
+	return c.download(fmt.Sprintf("/projects/%d/jobs/%d/artifacts", projectId, jobId), nil)
+}
+
+// RefArtifacts returns the artifacts archive of the latest successful job
+// for the reference (branch or tag), the caller must close the reader.
+func (c *HttpClient) RefArtifacts(projectId int, ref, job string) (io.ReadCloser, error) {
+	params := url.Values{}
+	params.Set("job", job)
+
+	return c.download(fmt.Sprintf("/projects/%d/jobs/artifacts/%s/download", projectId, url.PathEscape(ref)), params)
+}
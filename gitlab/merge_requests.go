@@ -0,0 +1,69 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab
+
+import "fmt"
+
+type MergeRequest struct {
+	Id              int      `json:"id"`
+	Iid             int      `json:"iid"`
+	ProjectId       int      `json:"project_id"`
+	Title           string   `json:"title"`
+	Description     string   `json:"description"`
+	State           string   `json:"state"`
+	SourceBranch    string   `json:"source_branch"`
+	TargetBranch    string   `json:"target_branch"`
+	Sha             string   `json:"sha"`
+	MergeCommitSha  string   `json:"merge_commit_sha"`
+	SquashCommitSha string   `json:"squash_commit_sha"`
+	Author          *User    `json:"author"`
+	Labels          []string `json:"labels"`
+	WebUrl          string   `json:"web_url"`
+	CreatedAt       string   `json:"created_at"`
+	MergedAt        string   `json:"merged_at"`
+}
+
+type MergeRequestListOptions struct {
+	ListOptions
+
+	// opened, closed, locked or merged
+	State        string
+	SourceBranch string
+	TargetBranch string
+	UpdatedAfter string
+	OrderBy      string
+	Sort         string
+}
+
+func (c *HttpClient) MergeRequests(projectId int, opts *MergeRequestListOptions) ([]*MergeRequest, *Response, error) {
+	if opts == nil {
+		opts = &MergeRequestListOptions{}
+	}
+
+	params := opts.ListOptions.values()
+	setString(params, "state", opts.State)
+	setString(params, "source_branch", opts.SourceBranch)
+	setString(params, "target_branch", opts.TargetBranch)
+	setString(params, "updated_after", opts.UpdatedAfter)
+	setString(params, "order_by", opts.OrderBy)
+	setString(params, "sort", opts.Sort)
+
+	mergeRequests := []*MergeRequest{}
+
+	resp, err := c.get(fmt.Sprintf("/projects/%d/merge_requests", projectId), params, &mergeRequests)
+
+	return mergeRequests, resp, err
+}
+
+func (c *HttpClient) MergeRequest(projectId, iid int) (*MergeRequest, error) {
+	mergeRequest := &MergeRequest{}
+
+	if _, err := c.get(fmt.Sprintf("/projects/%d/merge_requests/%d", projectId, iid), nil, mergeRequest); err != nil {
+		return nil, err
+	}
+
+	return mergeRequest, nil
+}
@@ -0,0 +1,137 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab
+
+import "fmt"
+
+type Pipeline struct {
+	Id         int     `json:"id"`
+	Iid        int     `json:"iid"`
+	ProjectId  int     `json:"project_id"`
+	Sha        string  `json:"sha"`
+	Ref        string  `json:"ref"`
+	Tag        bool    `json:"tag"`
+	Status     string  `json:"status"`
+	Source     string  `json:"source"`
+	WebUrl     string  `json:"web_url"`
+	User       *User   `json:"user"`
+	Duration   float64 `json:"duration"`
+	CreatedAt  string  `json:"created_at"`
+	UpdatedAt  string  `json:"updated_at"`
+	StartedAt  string  `json:"started_at"`
+	FinishedAt string  `json:"finished_at"`
+}
+
+// Bridge is a trigger job, it creates a downstream pipeline in the same or in
+// another project.
+type Bridge struct {
+	Id                 int       `json:"id"`
+	Name               string    `json:"name"`
+	Stage              string    `json:"stage"`
+	Status             string    `json:"status"`
+	WebUrl             string    `json:"web_url"`
+	Pipeline           *Pipeline `json:"pipeline"`
+	DownstreamPipeline *Pipeline `json:"downstream_pipeline"`
+}
+
+type PipelineVariable struct {
+	Key          string `json:"key"`
+	Value        string `json:"value"`
+	VariableType string `json:"variable_type,omitempty"`
+}
+
+// CreatePipelineOptions is the payload used to create a pipeline with the
+// API token.
+type CreatePipelineOptions struct {
+	Ref       string              `json:"ref"`
+	Variables []*PipelineVariable `json:"variables,omitempty"`
+}
+
+// TriggerPipelineOptions is the payload used to create a pipeline with a
+// trigger token, the CI_JOB_TOKEN can also be used to link the pipeline to
+// the current job.
+type TriggerPipelineOptions struct {
+	Token     string            `json:"token"`
+	Ref       string            `json:"ref"`
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+type PipelineListOptions struct {
+	ListOptions
+
+	Ref     string
+	Sha     string
+	Status  string
+	Source  string
+	OrderBy string
+	Sort    string
+}
+
+func (c *HttpClient) Pipelines(projectId int, opts *PipelineListOptions) ([]*Pipeline, *Response, error) {
+	if opts == nil {
+		opts = &PipelineListOptions{}
+	}
+
+	params := opts.ListOptions.values()
+	setString(params, "ref", opts.Ref)
+	setString(params, "sha", opts.Sha)
+	setString(params, "status", opts.Status)
+	setString(params, "source", opts.Source)
+	setString(params, "order_by", opts.OrderBy)
+	setString(params, "sort", opts.Sort)
+
+	pipelines := []*Pipeline{}
+
+	resp, err := c.get(fmt.Sprintf("/projects/%d/pipelines", projectId), params, &pipelines)
+
+	return pipelines, resp, err
+}
+
+func (c *HttpClient) Pipeline(projectId, pipelineId int) (*Pipeline, error) {
+	pipeline := &Pipeline{}
+
+	if _, err := c.get(fmt.Sprintf("/projects/%d/pipelines/%d", projectId, pipelineId), nil, pipeline); err != nil {
+		return nil, err
+	}
+
+	return pipeline, nil
+}
+
+func (c *HttpClient) CreatePipeline(projectId int, opts *CreatePipelineOptions) (*Pipeline, error) {
+	pipeline := &Pipeline{}
+
+	if _, err := c.send("POST", fmt.Sprintf("/projects/%d/pipeline", projectId), nil, opts, pipeline); err != nil {
+		return nil, err
+	}
+
+	return pipeline, nil
+}
+
+func (c *HttpClient) TriggerPipeline(projectId int, opts *TriggerPipelineOptions) (*Pipeline, error) {
+	pipeline := &Pipeline{}
+
+	if _, err := c.send("POST", fmt.Sprintf("/projects/%d/trigger/pipeline", projectId), nil, opts, pipeline); err != nil {
+		return nil, err
+	}
+
+	return pipeline, nil
+}
+
+func (c *HttpClient) PipelineJobs(projectId, pipelineId int, opts *ListOptions) ([]*Job, *Response, error) {
+	jobs := []*Job{}
+
+	resp, err := c.get(fmt.Sprintf("/projects/%d/pipelines/%d/jobs", projectId, pipelineId), opts.values(), &jobs)
+
+	return jobs, resp, err
+}
+
+func (c *HttpClient) PipelineBridges(projectId, pipelineId int, opts *ListOptions) ([]*Bridge, *Response, error) {
+	bridges := []*Bridge{}
+
+	resp, err := c.get(fmt.Sprintf("/projects/%d/pipelines/%d/bridges", projectId, pipelineId), opts.values(), &bridges)
+
+	return bridges, resp, err
+}
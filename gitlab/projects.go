@@ -0,0 +1,76 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab
+
+import (
+	"fmt"
+	"net/url"
+)
+
+type Namespace struct {
+	Id       int    `json:"id"`
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Kind     string `json:"kind"`
+	FullPath string `json:"full_path"`
+}
+
+type Project struct {
+	Id                int        `json:"id"`
+	Name              string     `json:"name"`
+	NameWithNamespace string     `json:"name_with_namespace"`
+	Description       string     `json:"description"`
+	DefaultBranch     string     `json:"default_branch"`
+	Path              string     `json:"path"`
+	PathWithNamespace string     `json:"path_with_namespace"`
+	Namespace         *Namespace `json:"namespace"`
+	Owner             *User      `json:"owner"`
+	Visibility        string     `json:"visibility"`
+	Archived          bool       `json:"archived"`
+	WebUrl            string     `json:"web_url"`
+	SshUrlToRepo      string     `json:"ssh_url_to_repo"`
+	HttpUrlToRepo     string     `json:"http_url_to_repo"`
+	TagList           []string   `json:"tag_list"`
+}
+
+type ProjectListOptions struct {
+	ListOptions
+
+	Search     string
+	Membership bool
+	Simple     bool
+	OrderBy    string
+	Sort       string
+}
+
+func (c *HttpClient) Project(id string) (*Project, error) {
+	project := &Project{}
+
+	if _, err := c.get(fmt.Sprintf("/projects/%s", url.PathEscape(id)), nil, project); err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func (c *HttpClient) Projects(opts *ProjectListOptions) ([]*Project, *Response, error) {
+	if opts == nil {
+		opts = &ProjectListOptions{}
+	}
+
+	params := opts.ListOptions.values()
+	setString(params, "search", opts.Search)
+	setString(params, "order_by", opts.OrderBy)
+	setString(params, "sort", opts.Sort)
+	setBool(params, "membership", opts.Membership)
+	setBool(params, "simple", opts.Simple)
+
+	projects := []*Project{}
+
+	resp, err := c.get("/projects", params, &projects)
+
+	return projects, resp, err
+}
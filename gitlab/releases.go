@@ -0,0 +1,93 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab
+
+import (
+	"fmt"
+	"net/url"
+)
+
+type ReleaseLink struct {
+	Id       int    `json:"id,omitempty"`
+	Name     string `json:"name"`
+	Url      string `json:"url"`
+	LinkType string `json:"link_type,omitempty"`
+}
+
+type ReleaseAssets struct {
+	Links []*ReleaseLink `json:"links"`
+}
+
+type Release struct {
+	TagName     string         `json:"tag_name"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	CreatedAt   string         `json:"created_at"`
+	ReleasedAt  string         `json:"released_at"`
+	Author      *User          `json:"author"`
+	Commit      *Commit        `json:"commit"`
+	Assets      *ReleaseAssets `json:"assets"`
+}
+
+// ReleaseOptions is the payload used to create or update a release, the
+// assets and the ref are only used on creation.
+type ReleaseOptions struct {
+	TagName     string         `json:"tag_name,omitempty"`
+	Name        string         `json:"name,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Ref         string         `json:"ref,omitempty"`
+	ReleasedAt  string         `json:"released_at,omitempty"`
+	Assets      *ReleaseAssets `json:"assets,omitempty"`
+}
+
+func (c *HttpClient) Releases(projectId int, opts *ListOptions) ([]*Release, *Response, error) {
+	releases := []*Release{}
+
+	resp, err := c.get(fmt.Sprintf("/projects/%d/releases", projectId), opts.values(), &releases)
+
+	return releases, resp, err
+}
+
+func (c *HttpClient) Release(projectId int, tag string) (*Release, error) {
+	release := &Release{}
+
+	if _, err := c.get(fmt.Sprintf("/projects/%d/releases/%s", projectId, url.PathEscape(tag)), nil, release); err != nil {
+		return nil, err
+	}
+
+	return release, nil
+}
+
+func (c *HttpClient) CreateRelease(projectId int, opts *ReleaseOptions) (*Release, error) {
+	release := &Release{}
+
+	if _, err := c.send("POST", fmt.Sprintf("/projects/%d/releases", projectId), nil, opts, release); err != nil {
+		return nil, err
+	}
+
+	return release, nil
+}
+
+func (c *HttpClient) UpdateRelease(projectId int, tag string, opts *ReleaseOptions) (*Release, error) {
+	release := &Release{}
+
+	if _, err := c.send("PUT", fmt.Sprintf("/projects/%d/releases/%s", projectId, url.PathEscape(tag)), nil, opts, release); err != nil {
+		return nil, err
+	}
+
+	return release, nil
+}
+
+// CreateReleaseLink adds an asset link to an existing release.
+func (c *HttpClient) CreateReleaseLink(projectId int, tag string, link *ReleaseLink) (*ReleaseLink, error) {
+	created := &ReleaseLink{}
+
+	if _, err := c.send("POST", fmt.Sprintf("/projects/%d/releases/%s/assets/links", projectId, url.PathEscape(tag)), nil, link, created); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
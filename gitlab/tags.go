@@ -0,0 +1,60 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab
+
+import "fmt"
+
+type Tag struct {
+	Name      string  `json:"name"`
+	Message   string  `json:"message"`
+	Target    string  `json:"target"`
+	Protected bool    `json:"protected"`
+	Commit    *Commit `json:"commit"`
+}
+
+type TagListOptions struct {
+	ListOptions
+
+	// name, updated or version
+	OrderBy string
+	Sort    string
+	Search  string
+}
+
+// CreateTagOptions is the payload used to create a tag, the tag is annotated
+// if a message is provided.
+type CreateTagOptions struct {
+	TagName string `json:"tag_name"`
+	Ref     string `json:"ref"`
+	Message string `json:"message,omitempty"`
+}
+
+func (c *HttpClient) Tags(projectId int, opts *TagListOptions) ([]*Tag, *Response, error) {
+	if opts == nil {
+		opts = &TagListOptions{}
+	}
+
+	params := opts.ListOptions.values()
+	setString(params, "order_by", opts.OrderBy)
+	setString(params, "sort", opts.Sort)
+	setString(params, "search", opts.Search)
+
+	tags := []*Tag{}
+
+	resp, err := c.get(fmt.Sprintf("/projects/%d/repository/tags", projectId), params, &tags)
+
+	return tags, resp, err
+}
+
+func (c *HttpClient) CreateTag(projectId int, opts *CreateTagOptions) (*Tag, error) {
+	tag := &Tag{}
+
+	if _, err := c.send("POST", fmt.Sprintf("/projects/%d/repository/tags", projectId), nil, opts, tag); err != nil {
+		return nil, err
+	}
+
+	return tag, nil
+}
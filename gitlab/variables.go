@@ -0,0 +1,38 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab
+
+import (
+	"fmt"
+	"net/url"
+)
+
+type Variable struct {
+	Key              string `json:"key"`
+	Value            string `json:"value"`
+	VariableType     string `json:"variable_type"`
+	Protected        bool   `json:"protected"`
+	Masked           bool   `json:"masked"`
+	EnvironmentScope string `json:"environment_scope"`
+}
+
+func (c *HttpClient) Variables(projectId int, opts *ListOptions) ([]*Variable, *Response, error) {
+	variables := []*Variable{}
+
+	resp, err := c.get(fmt.Sprintf("/projects/%d/variables", projectId), opts.values(), &variables)
+
+	return variables, resp, err
+}
+
+func (c *HttpClient) Variable(projectId int, key string) (*Variable, error) {
+	variable := &Variable{}
+
+	if _, err := c.get(fmt.Sprintf("/projects/%d/variables/%s", projectId, url.PathEscape(key)), nil, variable); err != nil {
+		return nil, err
+	}
+
+	return variable, nil
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"path"
 	"regexp"
 	"strconv"
 	"strings"
@@ -20,7 +21,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/session"
-	gitlab "gopkg.in/plouc/go-gitlab-client.v1"
+	"github.com/rande/gitlab-ci-helper/gitlab"
 )
 
 type Paths []string
@@ -35,7 +36,14 @@ func (p *Paths) Set(value string) error {
 	return nil
 }
 
-var SemVersion = regexp.MustCompile("(v|)[0-9]{1,}\\.[0-9]{1,}\\.[0-9]{1,}(-[A-Za-z]*|)")
+// SEMVER_PATTERN is the default pattern of the -tag-matcher options, it
+// matches the release tags. The pattern is not anchored, so the tags
+// containing a version are matched, ie: release-1.2.0, the strict format is
+// checked by ParseVersion when the versions are compared.
+const SEMVER_PATTERN = "(v|)[0-9]{1,}\\.[0-9]{1,}\\.[0-9]{1,}(-[A-Za-z]*|)"
+
+// SemVersion is the compiled SEMVER_PATTERN.
+var SemVersion = regexp.MustCompile(SEMVER_PATTERN)
 
 func GetAwsCredentials(profile string) (*credentials.Credentials, error) {
 	sess := session.Must(session.NewSession())
@@ -63,58 +71,95 @@ func GetAwsCredentials(profile string) (*credentials.Credentials, error) {
 	return chainProvider, nil
 }
 
-func GetProject(p string, client *gitlab.Gitlab) (*gitlab.Project, error) {
-	pId, err := strconv.ParseInt(p, 10, 32)
+// GetProject retrieves the project from its id or from its full path, ie:
+// namespace/project or group/subgroup/project. If the project does not
+// exist, the projects with the same name are suggested.
+func GetProject(p string, client gitlab.Client) (*gitlab.Project, error) {
+	_, err := strconv.ParseInt(p, 10, 32)
+	numeric := err == nil
 
-	if err != nil {
-		// invalid build id, search from a project list
-		paths := strings.Split(p, "/")
+	if !numeric {
+		paths := strings.Split(strings.Trim(p, "/"), "/")
 
-		if len(paths) != 2 {
-			return nil, fmt.Errorf("Error: Invalid project format, must be namespace/project-name, project=%s, err=%s", p, err)
+		if len(paths) < 2 {
+			return nil, fmt.Errorf("Error: Invalid project format, must be namespace/project-name, project=%s", p)
 		}
 
-		projects, _ := client.Projects()
+		p = strings.Join(paths, "/")
+	}
 
-		try := ""
-		for _, p := range projects {
-			if p.Name == paths[1] {
-				try = fmt.Sprintf("%s/%s", p.Namespace.Name, p.Name)
-			}
-			if p.Name == paths[1] && p.Namespace.Name == paths[0] {
-				pId = int64(p.Id)
+	project, err := client.Project(p)
 
-				break
-			}
-		}
+	if err == nil {
+		return project, nil
+	}
 
-		if pId == 0 {
-			extra := ""
-			if len(try) > 0 {
-				extra = fmt.Sprintf("\nDid you mean: %s ?", try)
-			}
+	if !gitlab.IsNotFound(err) {
+		return nil, errors.New("Error: " + err.Error())
+	}
 
-			return nil, fmt.Errorf("Unable to find the project: %s/%s.%s", paths[0], paths[1], extra)
-		}
+	if numeric {
+		return nil, fmt.Errorf("Unable to find the project: %s", p)
 	}
 
-	project, err := client.Project(strconv.FormatInt(pId, 10))
+	suggestions, err := searchProjects(path.Base(p), client)
 
 	if err != nil {
 		return nil, errors.New("Error: " + err.Error())
 	}
 
-	return project, err
+	extra := ""
+	if len(suggestions) > 0 {
+		extra = fmt.Sprintf("\nDid you mean: %s ?", strings.Join(suggestions, ", "))
+	}
+
+	return nil, fmt.Errorf("Unable to find the project: %s.%s", p, extra)
+}
+
+// searchProjects returns the path of the projects matching the name, all the
+// pages are read as the search also matches partial names.
+func searchProjects(name string, client gitlab.Client) ([]string, error) {
+	paths := []string{}
+
+	opts := &gitlab.ProjectListOptions{
+		ListOptions: gitlab.ListOptions{Page: 1, PerPage: 100},
+		Search:      name,
+		Simple:      true,
+	}
+
+	for opts.Page > 0 {
+		projects, resp, err := client.Projects(opts)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range projects {
+			if p.Path == name || p.Name == name {
+				paths = append(paths, p.PathWithNamespace)
+			}
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	return paths, nil
 }
 
-func GetBuild(project *gitlab.Project, buildId string, client *gitlab.Gitlab) (*gitlab.Build, error) {
-	build, err := client.ProjectBuild(strconv.FormatInt(int64(project.Id), 10), buildId)
+func GetJob(project *gitlab.Project, jobId string, client gitlab.Client) (*gitlab.Job, error) {
+	id, err := strconv.Atoi(jobId)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid job id: %s", jobId)
+	}
+
+	job, err := client.Job(project.Id, id)
 
 	if err != nil {
-		return nil, fmt.Errorf("Error: %s.\nUnable to find the build (projectId:%d, buildId:%s)", err.Error(), project.Id, buildId)
+		return nil, fmt.Errorf("Error: %s.\nUnable to find the job (projectId:%d, jobId:%s)", err.Error(), project.Id, jobId)
 	}
 
-	return build, err
+	return job, err
 }
 
 func GetEnv(name, deflt string) string {
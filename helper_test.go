@@ -1,9 +1,13 @@
 package gitlab_ci_helper
 
 import (
-	"github.com/stretchr/testify/assert"
+	"fmt"
 	"os"
+	"strconv"
 	"testing"
+
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/stretchr/testify/assert"
 )
 
 func Test_S3_Upload_Paths(t *testing.T) {
@@ -50,3 +54,75 @@ func Test_Path(t *testing.T) {
 
 	assert.Equal(t, "[bonjour la terre!]", p.String())
 }
+
+type fakeProjectClient struct {
+	gitlab.Client
+
+	projects []*gitlab.Project
+	err      error
+	searches []string
+}
+
+func (f *fakeProjectClient) Project(id string) (*gitlab.Project, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	for _, p := range f.projects {
+		if strconv.Itoa(p.Id) == id || p.PathWithNamespace == id {
+			return p, nil
+		}
+	}
+
+	return nil, &gitlab.ErrorResponse{StatusCode: 404}
+}
+
+func (f *fakeProjectClient) Projects(opts *gitlab.ProjectListOptions) ([]*gitlab.Project, *gitlab.Response, error) {
+	f.searches = append(f.searches, fmt.Sprintf("%s:%d", opts.Search, opts.Page))
+
+	// one project per page
+	resp := &gitlab.Response{}
+	if opts.Page < len(f.projects) {
+		resp.NextPage = opts.Page + 1
+	}
+
+	return f.projects[opts.Page-1 : opts.Page], resp, nil
+}
+
+func Test_GetProject(t *testing.T) {
+	client := &fakeProjectClient{
+		projects: []*gitlab.Project{
+			{Id: 3, Name: "Site", Path: "site", PathWithNamespace: "diaspora/site"},
+			{Id: 4, Name: "Site", Path: "site", PathWithNamespace: "group/subgroup/site"},
+			{Id: 5, Name: "Other", Path: "other", PathWithNamespace: "group/other"},
+		},
+	}
+
+	project, err := GetProject("3", client)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, project.Id)
+
+	project, err = GetProject("group/subgroup/site", client)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, project.Id)
+
+	_, err = GetProject("site", client)
+	assert.Contains(t, err.Error(), "Invalid project format")
+
+	_, err = GetProject("42", client)
+	assert.Equal(t, "Unable to find the project: 42", err.Error())
+	assert.Empty(t, client.searches)
+
+	_, err = GetProject("group/site", client)
+	assert.Equal(t, "Unable to find the project: group/site.\nDid you mean: diaspora/site, group/subgroup/site ?", err.Error())
+	assert.Equal(t, []string{"site:1", "site:2", "site:3"}, client.searches)
+}
+
+func Test_GetProject_Error(t *testing.T) {
+	client := &fakeProjectClient{
+		err: &gitlab.ErrorResponse{Method: "GET", Path: "/projects/group%2Fsite", StatusCode: 401},
+	}
+
+	_, err := GetProject("group/site", client)
+	assert.Equal(t, "Error: Invalid response from GET /projects/group%2Fsite, status: 401", err.Error())
+}
@@ -6,52 +6,77 @@
 package flowdock
 
 import (
-	"bytes"
-	"encoding/json"
 	"flag"
 	"fmt"
-	"html/template"
-	"net/http"
-	"os"
-	"strconv"
 	"strings"
 
 	"github.com/mitchellh/cli"
-	gitlab "gopkg.in/plouc/go-gitlab-client.v1"
 	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/rande/gitlab-ci-helper/integrations/notifier"
 )
 
+var statusTemplate = `<h3>{{ .Project.Namespace.Name }} / {{ .Project.Name }}</h3>
+
+    <ul>
+        <li><b>Project:</b> <a href="{{ .Project.WebUrl }}">{{ .Project.WebUrl }}</a></li>
+    </ul>
+
+    <hr />
+    <ul>
+        {{ if .Commit }}
+        <li><b>Author:</b> {{ .Commit.AuthorName }}</li>
+        <li><b>Title:</b> {{ .Commit.Title }}</li>
+        {{ end }}
+        <li><b>Builds commit:</b> <a href="{{ .Url }}">{{ .Url }}</a></li>
+    </ul>
+
+    <table class="build-status" style="width: 100%">
+        <thead>
+            <tr>
+                <th style="padding: 4px; text-align: left;">Status</th>
+                <th style="padding: 4px; text-align: left;">Name</th>
+                <th style="padding: 4px; text-align: left;">Stage</th>
+            </tr>
+        </thead>
+        <tbody>
+            {{ range .Jobs }}
+                <tr>
+                    <td style="padding: 4px;">
+                        <a href="{{ $.JobUrl . }}" style="color: {{ flowdockColor .Status }}">&#10026; #{{ .Id }} - {{ .Status }}</a>
+
+                        {{ if gt .ArtifactsFile.Size 0 }}
+                             <a href="{{ $.JobUrl . }}/artifacts/browse">&#128230;</a>
+                        {{ end }}
+                    </td>
+
+                    <td style="padding: 4px;">{{ .Name }}</td>
+                    <td style="padding: 4px;">{{ .Stage }}</td>
+                </tr>
+            {{ end }}
+        </tbody>
+    </table>
+`
+
 type CiFlowdockStatusCommand struct {
-	Ui           cli.Ui
-	Verbose      bool
-	Last         bool
-	BuildRef     string
-	BuildName    string
-	BuildRefName string
-	Project      string
+	notifier.StatusOptions
+
+	Ui      cli.Ui
+	Verbose bool
 }
 
 func (c *CiFlowdockStatusCommand) Run(args []string) int {
 
-	config := &FlowdockConfig{
-		Server: "https://api.flowdock.com",
-	}
+	settings, err := helper.NewConfig()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
 
-	levels := map[string]int{
-		"pending":  BUILD_PENDING,
-		"running":  BUILD_RUNNING,
-		"success":  BUILD_SUCCESS,
-		"failed":   BUILD_FAILED,
-		"canceled": BUILD_CANCELLED,
+		return 1
 	}
 
-	// flowdock colors: red, green, yellow, cyan, orange, grey, black, lime, purple, blue
-	colors := map[int]string{
-		BUILD_PENDING:   "black",
-		BUILD_RUNNING:   "orange",
-		BUILD_SUCCESS:   "green",
-		BUILD_FAILED:    "red",
-		BUILD_CANCELLED: "grey",
+	config := &FlowdockConfig{
+		Server: FLOWDOCK_API_URL,
 	}
 
 	cmdFlags := flag.NewFlagSet("flowdock:thread", flag.ContinueOnError)
@@ -59,13 +84,12 @@ func (c *CiFlowdockStatusCommand) Run(args []string) int {
 		c.Ui.Output(c.Help())
 	}
 
+	n := notifier.New(c.Ui)
+	n.Flags(cmdFlags)
+	c.StatusOptions.Flags(cmdFlags)
+
 	cmdFlags.BoolVar(&c.Verbose, "verbose", false, "")
-	cmdFlags.BoolVar(&c.Last, "last", false, "")
-	cmdFlags.StringVar(&config.Token, "token", os.Getenv("FLOWDOCK_SOURCE_TOKEN"), "The room's token (default: env var FLOWDOCK_SOURCE_TOKEN)")
-	cmdFlags.StringVar(&c.BuildRef, "ref", helper.GetEnv("CI_COMMIT_SHA", os.Getenv("CI_BUILD_REF")), "The commit related to the build (default: env var CI_COMMIT_SHA/CI_BUILD_REF)")
-	cmdFlags.StringVar(&c.Project, "project", os.Getenv("CI_PROJECT_ID"), "The project related to the build (default: env var CI_PROJECT_ID)")
-	cmdFlags.StringVar(&c.BuildName, "name", helper.GetEnv("CI_JOB_NAME", os.Getenv("CI_BUILD_NAME")), "The build's name (default: env var CI_BUILD_NAME)")
-	cmdFlags.StringVar(&c.BuildRefName, "ref-name", helper.GetEnv("CI_COMMIT_REF_NAME", os.Getenv("CI_BUILD_REF_NAME")), "The reference name (default: env var CI_COMMIT_REF_NAME/CI_BUILD_REF_NAME)")
+	cmdFlags.StringVar(&config.Token, "token", settings.Flowdock.Token, "The room's token (default: env var FLOWDOCK_SOURCE_TOKEN)")
 
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
@@ -83,20 +107,9 @@ func (c *CiFlowdockStatusCommand) Run(args []string) int {
 	config.Organization = args[0]
 	config.Flow = args[1]
 
-	gitlabConfig := helper.NewConfig()
-	gitLabClient := gitlab.NewGitlab(gitlabConfig.Gitlab.Host, gitlabConfig.Gitlab.ApiPath, gitlabConfig.Gitlab.Token)
+	gitLabClient := gitlab.NewClient(settings.Gitlab.Host, settings.Gitlab.ApiPath, settings.Gitlab.Token)
 
-	project, err := helper.GetProject(c.Project, gitLabClient)
-
-	if err != nil {
-		c.Ui.Error(fmt.Sprintf("Unable to fetch the project: %s", err.Error()))
-
-		return 1
-	}
-
-	c.Ui.Output(fmt.Sprintf("Found project: %s/%s (id: %d)", project.Namespace.Name, project.Name, project.Id))
-
-	builds, err := gitLabClient.ProjectCommitBuilds(strconv.FormatInt(int64(project.Id), 10), c.BuildRef)
+	report, err := c.Report(gitLabClient, c.Ui)
 
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
@@ -104,92 +117,8 @@ func (c *CiFlowdockStatusCommand) Run(args []string) int {
 		return 1
 	}
 
-	var level = 0
-	var status = "-"
-
-	activeBuilds := make(map[string]*gitlab.Build, 0)
-
-	// filter builds to only get active ones
-	for _, b := range builds {
-		if ib, ok := activeBuilds[b.Name]; !ok {
-			activeBuilds[b.Name] = b
-		} else {
-			if ib.Id > b.Id {
-				activeBuilds[b.Name] = ib
-			}
-		}
-	}
-
-	// found the valid status build
-	for _, b := range activeBuilds {
-		if levels[b.Status] > level {
-			level = levels[b.Status]
-			status = b.Status
-		}
-	}
-
-	// if it the last pass with no previous error, we force the final job to be green
-	// the command should be the last one (reporting ...)
-	if c.Last && level == BUILD_RUNNING {
-		status = "success"
-		level = BUILD_SUCCESS
-
-		activeBuilds[c.BuildName].Status = "success"
-	}
-
-	tpl := `<h3>{{ .Project.Namespace.Name }} / {{ .Project.Name }}</h3>
-
-    <ul>
-        <li><b>Project:</b> <a href="{{ .Project.WebUrl }}">{{ .Project.WebUrl }}</a></li>
-    </ul>
-
-    <hr />
-    <ul>
-        <li><b>Author:</b> {{ .Build.Commit.Author_Name }}</li>
-        <li><b>Title:</b> {{ .Build.Commit.Title }}</li>
-        <li><b>Builds commit:</b> <a href="{{ .Project.WebUrl }}/commit/{{ .Build.Commit.Short_Id }}/pipelines">{{ .Project.WebUrl }}/commit/{{ .Build.Commit.Id }}/pipelines</a></li>
-    </ul>
-
-    <table class="build-status" style="width: 100%">
-        <thead>
-            <tr>
-                <th style="padding: 4px; text-align: left;">Status</th>
-                <th style="padding: 4px; text-align: left;">Name</th>
-                <th style="padding: 4px; text-align: left;">Stage</th>
-            </tr>
-        </thead>
-        <tbody>
-            {{ range .Builds }}
-                <tr>
-                    <td style="padding: 4px;">
-                        {{ $k := index $.Levels .Status }}
-                        {{ $color := index $.Colors $k }}
-
-                        <a href="{{ $.Project.WebUrl }}/builds/{{ .Id }}" style="color: {{ $color }}">&#10026;</style> #{{ .Id }} - {{ .Status }}</a>
-
-                        {{ if gt .ArtifactsFile.Size 0 }}
-                             <a href="{{ $.Project.WebUrl }}/builds/{{ .Id }}/artifacts/browse">&#128230;</a>
-                        {{ end }}
-                    </td>
-
-                    <td style="padding: 4px;">{{ .Name }}</td>
-                    <td style="padding: 4px;">{{ .Stage }}</td>
-                </tr>
-            {{ end }}
-        </tbody>
-    </table>
-`
-
-	t, err := template.New("foo").Parse(tpl)
-
-	body := bytes.NewBuffer([]byte(""))
-
-	err = t.Execute(body, map[string]interface{}{
-		"Builds":  activeBuilds,
-		"Build":   builds[0],
-		"Project": project,
-		"Levels":  levels,
-		"Colors":  colors,
+	body, err := notifier.RenderHtml(statusTemplate, report, map[string]interface{}{
+		"flowdockColor": StatusColor,
 	})
 
 	if err != nil {
@@ -204,12 +133,12 @@ func (c *CiFlowdockStatusCommand) Run(args []string) int {
 		Title:      fmt.Sprintf("Update status, job:%s", c.BuildName),
 		Thread: &FlowdockThread{
 			Status: &FlowdockThreadStatus{
-				Color: colors[level],
-				Value: status,
+				Color: StatusColor(report.Status),
+				Value: report.Status,
 			},
-			Title:       fmt.Sprintf("Jobs for %s - %s", project.Name, c.BuildRefName),
-			Body:        body.String(),
-			ExternalUrl: fmt.Sprintf("%s/commit/%s/pipelines", project.WebUrl, c.BuildRef),
+			Title:       fmt.Sprintf("Jobs for %s - %s", report.Project.Name, c.BuildRefName),
+			Body:        body,
+			ExternalUrl: report.Url(),
 		},
 		Author: &FlowdockAuthor{
 			Name:   "GitlabCi",
@@ -220,24 +149,22 @@ func (c *CiFlowdockStatusCommand) Run(args []string) int {
 	message.Token = config.Token
 	message.Flow = config.Flow
 
-	buf := bytes.NewBuffer([]byte(""))
-	e := json.NewEncoder(buf)
-
-	e.Encode(message)
-
-	client := &http.Client{}
-
-	r, _ := http.NewRequest("POST", fmt.Sprintf("%s/flows/%s/%s/messages", config.Server, config.Organization, config.Flow), buf)
-	r.Header.Add("Content-Type", "application/json")
-
-	_, err = client.Do(r)
+	_, err = n.Send(&notifier.Notification{
+		Url:     fmt.Sprintf("%s/flows/%s/%s/messages", config.Server, config.Organization, config.Flow),
+		Body:    message,
+		Secrets: []string{config.Token},
+	})
 
 	if err != nil {
-		c.Ui.Error(fmt.Sprintf("Err: %s", err.Error()))
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
 
 		return 1
 	}
 
+	if c.Verbose && !n.DryRun {
+		c.Ui.Output(fmt.Sprintf("Status sent (status: %s)", report.Status))
+	}
+
 	return 0
 }
 
@@ -252,7 +179,7 @@ Usage: gitlab-ci-helper flowdock:message [options] organisation flow
   Build a flowdock thread from the current build. Information are retrieved from
   environment variables.
 
-  The external thread id is: gitlab:sha1
+  The external thread id is: gitlab:sha1:ref_name
 
   You can use the -last option to indicate that the current job is the last one.
 
@@ -261,15 +188,9 @@ Arguments:
   flow                The flow reference
 
 Options:
-  -ref                The commit related to the build (default:
-                        9.x: CI_COMMIT_SHA or 8.x: CI_BUILD_REF)
-  -project            The project related to the build (default: env var CI_PROJECT_ID)
-  -name               The build's name (default:
-                        9.x: CI_JOB_NAME or 8.x: CI_BUILD_NAME)
-  -ref-name           The reference name (default:
-                        9.x: CI_COMMIT_REF_NAME or 8.x: CI_BUILD_REF_NAME)
-  -last               Indicate if the current build is the last one
+%s
   -token              The flow's token (default: env var FLOWDOCK_SOURCE_TOKEN)
+%s
   -verbose            Add verbose information to the output
 
 %s
@@ -280,7 +201,7 @@ Gitlab's credentials are retrieved from environment:
   GITLAB_TOKEN        The user's token
   GITLAB_API_PATH     (optional) the api path, default to: "/api/v4"
 
-`, flowdockConfiguration)
+`, notifier.StatusHelpOptions, notifier.HelpOptions, flowdockConfiguration)
 
 	return strings.TrimSpace(helpText)
 }
@@ -6,12 +6,63 @@
 package flowdock
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
 
 	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
 	"github.com/stretchr/testify/assert"
 )
 
+func Test_CiFlowdockStatusCommand_DryRun(t *testing.T) {
+	fpProject, err := os.Open("../../fixtures/project.json")
+	assert.NoError(t, err)
+
+	fpJobs, err := os.Open("../../fixtures/pipeline_12_jobs.json")
+	assert.NoError(t, err)
+
+	reqs := []*helper.FakeRequest{
+		{
+			Path:   "/api/v4/projects/3",
+			Method: "GET",
+			Response: &http.Response{
+				Body: fpProject,
+			},
+		},
+		{
+			Path:   "/api/v4/projects/3/pipelines/12/jobs",
+			Method: "GET",
+			Response: &http.Response{
+				Body: fpJobs,
+			},
+		},
+	}
+
+	envs := map[string]string{
+		"FLOWDOCK_SOURCE_TOKEN": "secret-token",
+	}
+
+	helper.WrapperTestCommand(reqs, envs, t, func(ts *httptest.Server) {
+		ui := &cli.MockUi{}
+		c := &CiFlowdockStatusCommand{
+			Ui: ui,
+		}
+
+		code := c.Run([]string{"-dry-run", "-project", "3", "-pipeline", "12", "-ref-name", "master", "acme", "ci"})
+
+		assert.Equal(t, 0, code, ui.ErrorWriter.String())
+
+		output := ui.OutputWriter.String()
+
+		assert.Contains(t, output, "Dry run, POST https://api.flowdock.com/flows/acme/ci/messages")
+		assert.Contains(t, output, `"status":{"color":"red","value":"failed"}`)
+		assert.Contains(t, output, "Administrator")
+		assert.NotContains(t, output, "secret-token")
+	})
+}
+
 func Test_CiFlowdockStatusCommand_Help(t *testing.T) {
 	c := &CiFlowdockStatusCommand{
 		Ui: &cli.MockUi{},
@@ -6,15 +6,13 @@
 package flowdock
 
 import (
-	"bytes"
-	"encoding/json"
 	"flag"
 	"fmt"
-	"net/http"
-	"os"
 	"strings"
 
 	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/integrations/notifier"
 )
 
 type CiFlowdockMessageCommand struct {
@@ -24,8 +22,16 @@ type CiFlowdockMessageCommand struct {
 
 func (c *CiFlowdockMessageCommand) Run(args []string) int {
 
+	settings, err := helper.NewConfig()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
 	config := &FlowdockConfig{
-		Server: "https://api.flowdock.com",
+		Server: FLOWDOCK_API_URL,
 	}
 
 	message := &FlowdockMessage{}
@@ -35,8 +41,14 @@ func (c *CiFlowdockMessageCommand) Run(args []string) int {
 		c.Ui.Output(c.Help())
 	}
 
+	n := notifier.New(c.Ui)
+	n.Flags(cmdFlags)
+
+	m := &notifier.MessageOptions{}
+	m.Flags(cmdFlags)
+
 	cmdFlags.BoolVar(&c.Verbose, "verbose", false, "")
-	cmdFlags.StringVar(&config.Token, "token", os.Getenv("FLOWDOCK_SOURCE_TOKEN"), "The room's token (default: env var FLOWDOCK_SOURCE_TOKEN)")
+	cmdFlags.StringVar(&config.Token, "token", settings.Flowdock.Token, "The room's token (default: env var FLOWDOCK_SOURCE_TOKEN)")
 
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
@@ -54,29 +66,35 @@ func (c *CiFlowdockMessageCommand) Run(args []string) int {
 	config.Organization = args[0]
 	config.Flow = args[1]
 
-	message.Event = "message"
-	message.Content = args[2]
-	message.Token = config.Token
-	message.Flow = config.Flow
-
-	buf := bytes.NewBuffer([]byte(""))
-	e := json.NewEncoder(buf)
+	content, err := m.Message(args[2])
 
-	e.Encode(message)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
 
-	client := &http.Client{}
+		return 1
+	}
 
-	r, _ := http.NewRequest("POST", fmt.Sprintf("%s/flows/%s/%s/messages", config.Server, config.Organization, config.Flow), buf)
-	r.Header.Add("Content-Type", "application/json")
+	message.Event = "message"
+	message.Content = content
+	message.Token = config.Token
+	message.Flow = config.Flow
 
-	_, err := client.Do(r)
+	_, err = n.Send(&notifier.Notification{
+		Url:     fmt.Sprintf("%s/flows/%s/%s/messages", config.Server, config.Organization, config.Flow),
+		Body:    message,
+		Secrets: []string{config.Token},
+	})
 
 	if err != nil {
-		c.Ui.Error(fmt.Sprintf("Err: %s", err.Error()))
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
 
 		return 1
 	}
 
+	if c.Verbose && !n.DryRun {
+		c.Ui.Output("Message sent")
+	}
+
 	return 0
 }
 
@@ -89,7 +107,10 @@ func (c *CiFlowdockMessageCommand) Help() string {
 Usage: gitlab-ci-helper flowdock:message [options] organisation flow message
 
   Build a flowdock thread from the current build. Information are retrieved from
-  environment variables.
+  environment variables. The message is sent as is, or rendered as a template
+  with the -template option.
+
+%s
 
 Arguments:
   organisation        The organisation name
@@ -98,11 +119,13 @@ Arguments:
 
 Options:
   -token              The flow's token (default: env var FLOWDOCK_SOURCE_TOKEN)
+%s
+%s
   -verbose            Add verbose information to the output
 
 %s
 
-`, flowdockConfiguration)
+`, notifier.TemplateHelp, notifier.MessageHelpOptions, notifier.HelpOptions, flowdockConfiguration)
 
 	return strings.TrimSpace(helpText)
 }
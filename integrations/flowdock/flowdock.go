@@ -5,13 +5,26 @@
 
 package flowdock
 
-const (
-	BUILD_PENDING   = 0
-	BUILD_SUCCESS   = 1
-	BUILD_RUNNING   = 2
-	BUILD_FAILED    = 3
-	BUILD_CANCELLED = 4
-)
+const FLOWDOCK_API_URL = "https://api.flowdock.com"
+
+// flowdock colors: red, green, yellow, cyan, orange, grey, black, lime, purple, blue
+var colors = map[string]string{
+	"pending":  "black",
+	"running":  "orange",
+	"success":  "green",
+	"failed":   "red",
+	"canceled": "grey",
+	"skipped":  "grey",
+	"manual":   "blue",
+}
+
+func StatusColor(status string) string {
+	if color, ok := colors[status]; ok {
+		return color
+	}
+
+	return colors["pending"]
+}
 
 var flowdockConfiguration = `
 Configuration:
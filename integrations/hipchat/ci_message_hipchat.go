@@ -6,17 +6,14 @@
 package hipchat
 
 import (
-	"bytes"
-	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/mitchellh/cli"
 	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/integrations/notifier"
 )
 
 type HipChatConfig struct {
@@ -53,14 +50,22 @@ type CiNotificationHipchatCommand struct {
 
 func (c *CiNotificationHipchatCommand) Run(args []string) int {
 
+	settings, err := helper.NewConfig()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
 	config := &HipChatConfig{}
 	message := &HipChatMessage{
-	//Card: &HipChatCard{
-	//	Description: &HipChatDescription {
-	//		Value: 1,
-	//		Format: "html",
-	//	},
-	//},
+		//Card: &HipChatCard{
+		//	Description: &HipChatDescription {
+		//		Value: 1,
+		//		Format: "html",
+		//	},
+		//},
 	}
 
 	cmdFlags := flag.NewFlagSet("ci:notification:hipchat", flag.ContinueOnError)
@@ -68,9 +73,15 @@ func (c *CiNotificationHipchatCommand) Run(args []string) int {
 		c.Ui.Output(c.Help())
 	}
 
+	n := notifier.New(c.Ui)
+	n.Flags(cmdFlags)
+
+	m := &notifier.MessageOptions{}
+	m.Flags(cmdFlags)
+
 	cmdFlags.BoolVar(&c.Verbose, "verbose", false, "")
-	cmdFlags.StringVar(&config.Token, "token", os.Getenv("HIPCHAT_TOKEN"), "The room's token (default: env var HIPCHAT_TOKEN)")
-	cmdFlags.StringVar(&config.Server, "server", helper.GetEnv("HIPCHAT_SERVER", "https://api.hipchat.com"), "The hipchat server, default to env var HIPCHAT_SERVER, then https://api.hipchat.com")
+	cmdFlags.StringVar(&config.Token, "token", settings.HipChat.Token, "The room's token (default: env var HIPCHAT_TOKEN)")
+	cmdFlags.StringVar(&config.Server, "server", settings.HipChat.Server, "The hipchat server, default to env var HIPCHAT_SERVER, then https://api.hipchat.com")
 
 	cmdFlags.StringVar(&message.Color, "color", "gray", "The message color (default: gray, values: yellow, green, red, purple, gray, random)")
 	cmdFlags.BoolVar(&message.Notify, "notify", false, "Whether this message should trigger a user notification (default: false)")
@@ -94,23 +105,32 @@ func (c *CiNotificationHipchatCommand) Run(args []string) int {
 	}
 
 	config.Room = args[0]
-	message.Message = args[1]
 
-	buf := bytes.NewBuffer([]byte(""))
-	e := json.NewEncoder(buf)
+	if message.Message, err = m.Message(args[1]); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
 
-	e.Encode(message)
+		return 1
+	}
 
-	client := &http.Client{}
-	r, _ := http.NewRequest("POST", fmt.Sprintf("%s/v2/room/%s/notification", config.Server, config.Room), buf)
-	r.Header.Add("Authorization", fmt.Sprintf("Bearer %s", config.Token))
-	r.Header.Add("Content-Type", "application/json")
+	header := http.Header{}
+	header.Set("Authorization", fmt.Sprintf("Bearer %s", config.Token))
 
-	resp, _ := client.Do(r)
+	_, err = n.Send(&notifier.Notification{
+		Url:     fmt.Sprintf("%s/v2/room/%s/notification", config.Server, config.Room),
+		Header:  header,
+		Body:    message,
+		Secrets: []string{config.Token},
+	})
 
-	io.Copy(os.Stdout, resp.Body)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
 
-	resp.Body.Close()
+		return 1
+	}
+
+	if c.Verbose && !n.DryRun {
+		c.Ui.Output("Message sent")
+	}
 
 	return 0
 }
@@ -120,10 +140,13 @@ func (c *CiNotificationHipchatCommand) Synopsis() string {
 }
 
 func (c *CiNotificationHipchatCommand) Help() string {
-	helpText := `
+	helpText := fmt.Sprintf(`
 Usage: gitlab-ci-helper ci:notify:hipchat [options] room message
 
-  Send a message to one HipChat's room
+  Send a message to one HipChat's room. The message is sent as is, or rendered
+  as a template with the -template option.
+
+%s
 
 Arguments:
   room                The room reference
@@ -134,7 +157,10 @@ Options:
   -notify             Whether this message should trigger a user notification (default: false)
   -token              The room's token (default: env var HIPCHAT_TOKEN)
   -server             The hipchat server, default to env var HIPCHAT_SERVER, then https://api.hipchat.com
+%s
+%s
   -verbose            Add verbose information to the output
-`
+`, notifier.TemplateHelp, notifier.MessageHelpOptions, notifier.HelpOptions)
+
 	return strings.TrimSpace(helpText)
 }
@@ -6,12 +6,59 @@
 package hipchat
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/mitchellh/cli"
 	"github.com/stretchr/testify/assert"
 )
 
+func Test_CiNotificationHipchatCommand(t *testing.T) {
+	message := &HipChatMessage{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/room/ci/notification", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(message))
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	ui := &cli.MockUi{}
+	c := &CiNotificationHipchatCommand{
+		Ui: ui,
+	}
+
+	code := c.Run([]string{"-server", ts.URL, "-token", "token", "-color", "green", "-verbose", "ci", "Hello {{"})
+
+	assert.Equal(t, 0, code, ui.ErrorWriter.String())
+	assert.Equal(t, "Hello {{", message.Message)
+	assert.Equal(t, "green", message.Color)
+	assert.Contains(t, ui.OutputWriter.String(), "Message sent")
+}
+
+func Test_CiNotificationHipchatCommand_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error": {"message": "Invalid token"}}`))
+	}))
+	defer ts.Close()
+
+	ui := &cli.MockUi{}
+	c := &CiNotificationHipchatCommand{
+		Ui: ui,
+	}
+
+	assert.Equal(t, 1, c.Run([]string{"-server", ts.URL, "-token", "token", "ci", "Hello"}))
+	assert.Contains(t, ui.ErrorWriter.String(), "status: 401")
+
+	// the server is not reachable
+	assert.Equal(t, 1, c.Run([]string{"-server", "http://127.0.0.1:0", "-retries", "0", "ci", "Hello"}))
+}
+
 func Test_CiNotificationHipchatCommand_Help(t *testing.T) {
 	c := &CiNotificationHipchatCommand{
 		Ui: &cli.MockUi{},
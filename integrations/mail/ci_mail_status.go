@@ -0,0 +1,200 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package mail
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/rande/gitlab-ci-helper/integrations/notifier"
+)
+
+var statusTemplate = `<h3>{{ .Project.NameWithNamespace }} - {{ .RefName }}: <span style="color: {{ color .Status }}">{{ .Status }}</span></h3>
+
+<ul>
+    <li><b>Project:</b> <a href="{{ .Project.WebUrl }}">{{ .Project.WebUrl }}</a></li>
+    {{ if .Commit }}
+    <li><b>Author:</b> {{ .Commit.AuthorName }}</li>
+    <li><b>Title:</b> {{ .Commit.Title }}</li>
+    {{ end }}
+    <li><b>Pipelines:</b> <a href="{{ .Url }}">{{ .Url }}</a></li>
+</ul>
+
+<table style="width: 100%; border-collapse: collapse;">
+    <thead>
+        <tr>
+            <th style="padding: 4px; text-align: left;">Status</th>
+            <th style="padding: 4px; text-align: left;">Name</th>
+            <th style="padding: 4px; text-align: left;">Stage</th>
+        </tr>
+    </thead>
+    <tbody>
+        {{ range .Jobs }}
+        <tr>
+            <td style="padding: 4px;">
+                <a href="{{ $.JobUrl . }}" style="color: {{ color .Status }}">&#10026; #{{ .Id }} - {{ .Status }}</a>
+                {{ if gt .ArtifactsFile.Size 0 }}
+                <a href="{{ $.JobUrl . }}/artifacts/browse">&#128230;</a>
+                {{ end }}
+            </td>
+            <td style="padding: 4px;">{{ .Name }}</td>
+            <td style="padding: 4px;">{{ .Stage }}</td>
+        </tr>
+        {{ end }}
+    </tbody>
+</table>
+`
+
+type MailStatusCommand struct {
+	notifier.StatusOptions
+
+	Ui            cli.Ui
+	Verbose       bool
+	Insecure      bool
+	DryRun        bool
+	From          string
+	To            helper.Paths
+	SubjectPrefix string
+	Template      string
+}
+
+func (c *MailStatusCommand) Run(args []string) int {
+
+	config, err := helper.NewConfig()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	cmdFlags := flag.NewFlagSet("mail:status", flag.ContinueOnError)
+	cmdFlags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	c.To = make(helper.Paths, 0)
+
+	c.StatusOptions.Flags(cmdFlags)
+
+	cmdFlags.BoolVar(&c.Verbose, "verbose", false, "")
+	cmdFlags.BoolVar(&c.Insecure, "insecure", false, "Skip the TLS certificate verification")
+	cmdFlags.BoolVar(&c.DryRun, "dry-run", false, "Print the email instead of sending it")
+	cmdFlags.StringVar(&c.From, "from", config.Mailer.Sender, "The sender address")
+	cmdFlags.Var(&c.To, "to", "The recipient address")
+	cmdFlags.StringVar(&c.SubjectPrefix, "subject-prefix", config.Mailer.SubjectPrefix, "The subject prefix")
+	cmdFlags.StringVar(&c.Template, "template", "", "The html template file")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.To) == 0 {
+		c.To = config.Mailer.Dest
+	}
+
+	tpl := statusTemplate
+
+	if len(c.Template) > 0 {
+		data, err := ioutil.ReadFile(c.Template)
+
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error: unable to read the template, %s", err.Error()))
+
+			return 1
+		}
+
+		tpl = string(data)
+	}
+
+	client := gitlab.NewClient(config.Gitlab.Host, config.Gitlab.ApiPath, config.Gitlab.Token)
+
+	report, err := c.Report(client, c.Ui)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	body, err := notifier.RenderHtml(tpl, report, nil)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: invalid template, %s", err.Error()))
+
+		return 1
+	}
+
+	message := &Message{
+		From:    c.From,
+		To:      c.To,
+		Subject: strings.TrimSpace(fmt.Sprintf("%s %s", c.SubjectPrefix, report.Title())),
+		Html:    body,
+	}
+
+	if c.DryRun {
+		c.Ui.Output(fmt.Sprintf("Dry run, email to %s (subject: %s)\n\n%s", strings.Join(message.To, ", "), message.Subject, message.Html))
+
+		return 0
+	}
+
+	if c.Verbose {
+		c.Ui.Output(fmt.Sprintf("Send email to %s (subject: %s)", strings.Join(message.To, ", "), message.Subject))
+	}
+
+	if err := Send(config.Mailer, message, c.Insecure); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: unable to send the email, %s", err.Error()))
+
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Email sent to %s (status: %s)", strings.Join(message.To, ", "), report.Status))
+
+	return 0
+}
+
+func (c *MailStatusCommand) Synopsis() string {
+	return "Send the pipeline status by email."
+}
+
+func (c *MailStatusCommand) Help() string {
+	helpText := fmt.Sprintf(`
+Usage: gitlab-ci-helper mail:status [options]
+
+  Send an email with the status of the pipeline jobs, only the latest attempt of
+  each job is reported. Information are retrieved from environment variables.
+
+  You can use the -last option to indicate that the current job is the last one.
+
+Options:
+%s
+  -from               The sender address (default: env var MAIL_SENDER)
+  -to                 The recipient address, can be repeated (default: env var MAIL_DEST)
+  -subject-prefix     The subject prefix (default: env var MAIL_SUBJECT)
+  -template           The html template file, the template receives the report:
+                        Project, Commit, Ref, RefName, PipelineId, Status, Jobs,
+                        Url and JobUrl, and the color function
+  -insecure           Skip the TLS certificate verification
+  -dry-run            Print the email instead of sending it
+  -verbose            Add verbose information to the output
+
+%s
+
+Gitlab's credentials are retrieved from environment:
+
+  GITLAB_HOST         The gitlab host
+  GITLAB_TOKEN        The user's token
+  GITLAB_API_PATH     (optional) the api path, default to: "/api/v4"
+
+`, notifier.StatusHelpOptions, mailConfiguration)
+
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,133 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package mail
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MailStatusCommand(t *testing.T) {
+	fpProject, err := os.Open("../../fixtures/project.json")
+	assert.NoError(t, err)
+
+	fpJobs, err := os.Open("../../fixtures/pipeline_12_jobs.json")
+	assert.NoError(t, err)
+
+	reqs := []*helper.FakeRequest{
+		{
+			Path:   "/api/v4/projects/3",
+			Method: "GET",
+			Response: &http.Response{
+				Body: fpProject,
+			},
+		},
+		{
+			Path:   "/api/v4/projects/3/pipelines/12/jobs",
+			Method: "GET",
+			Response: &http.Response{
+				Body: fpJobs,
+			},
+		},
+	}
+
+	s := newFakeSmtpServer(t)
+
+	envs := map[string]string{
+		"MAIL_HOST":    s.Addr(),
+		"MAIL_SENDER":  "ci@example.com",
+		"MAIL_DEST":    "dev@example.com",
+		"MAIL_SUBJECT": "[CI]",
+	}
+
+	helper.WrapperTestCommand(reqs, envs, t, func(ts *httptest.Server) {
+		ui := &cli.MockUi{}
+		c := &MailStatusCommand{
+			Ui: ui,
+		}
+
+		code := c.Run([]string{"-project", "3", "-pipeline", "12", "-ref", "889935cf4d3e7558ae6c0d4dd62e20ea600f5a57", "-ref-name", "master"})
+
+		assert.Equal(t, 0, code, ui.ErrorWriter.String())
+		assert.Contains(t, ui.OutputWriter.String(), "Email sent to dev@example.com (status: failed)")
+	})
+
+	s.Wait()
+
+	m, body := s.Body(t)
+
+	assert.Equal(t, "[CI] Diaspora / Diaspora Project Site - master: failed", m.Header.Get("Subject"))
+	assert.Contains(t, body, "#69 - canceled")
+	assert.Contains(t, body, "<td style=\"padding: 4px;\">brakeman</td>")
+	assert.Contains(t, body, "<li><b>Author:</b> Administrator</li>")
+}
+
+func Test_MailStatusCommand_DryRun(t *testing.T) {
+	fpProject, err := os.Open("../../fixtures/project.json")
+	assert.NoError(t, err)
+
+	fpJobs, err := os.Open("../../fixtures/pipeline_12_jobs.json")
+	assert.NoError(t, err)
+
+	reqs := []*helper.FakeRequest{
+		{
+			Path:   "/api/v4/projects/3",
+			Method: "GET",
+			Response: &http.Response{
+				Body: fpProject,
+			},
+		},
+		{
+			Path:   "/api/v4/projects/3/pipelines/12/jobs",
+			Method: "GET",
+			Response: &http.Response{
+				Body: fpJobs,
+			},
+		},
+	}
+
+	envs := map[string]string{
+		"MAIL_HOST": "localhost:0",
+		"MAIL_DEST": "dev@example.com",
+	}
+
+	helper.WrapperTestCommand(reqs, envs, t, func(ts *httptest.Server) {
+		ui := &cli.MockUi{}
+		c := &MailStatusCommand{
+			Ui: ui,
+		}
+
+		code := c.Run([]string{"-dry-run", "-project", "3", "-pipeline", "12", "-ref-name", "master"})
+
+		assert.Equal(t, 0, code, ui.ErrorWriter.String())
+		assert.Contains(t, ui.OutputWriter.String(), "Dry run, email to dev@example.com (subject: Diaspora / Diaspora Project Site - master: failed)")
+		assert.Contains(t, ui.OutputWriter.String(), "http://example.com/diaspora/diaspora-project-site/-/pipelines/12")
+		assert.NotContains(t, ui.OutputWriter.String(), "Email sent")
+	})
+}
+
+func Test_MailStatusCommand_Help(t *testing.T) {
+	c := &MailStatusCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.True(t, len(c.Help()) > 0)
+	assert.True(t, len(c.Synopsis()) > 0)
+}
+
+func Test_MailStatusCommand_InvalidRun(t *testing.T) {
+	c := &MailStatusCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.Equal(t, 1, c.Run([]string{"--foobar"}))
+}
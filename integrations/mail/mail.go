@@ -0,0 +1,143 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package mail
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	helper "github.com/rande/gitlab-ci-helper"
+)
+
+var mailConfiguration = `
+Configuration:
+
+  The SMTP settings are retrieved from the configuration file (mail section)
+  or from the environment:
+
+  MAIL_HOST           The SMTP server, ie: smtp.example.com:587 (default port: 25)
+  MAIL_USERNAME       The SMTP username, the authentication is skipped if empty
+  MAIL_PASSWORD       The SMTP password
+  MAIL_SENDER         The sender address
+  MAIL_DEST           The recipients, comma separated
+  MAIL_SUBJECT        The subject prefix
+
+  STARTTLS is used if the server supports it.
+`
+
+// Message is a html email.
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Html    string
+}
+
+// Bytes returns the message in the RFC 5322 format, the body is base64
+// encoded so any character can be used.
+func (m *Message) Bytes() []byte {
+	buf := bytes.NewBuffer([]byte(""))
+
+	fmt.Fprintf(buf, "From: %s\r\n", m.From)
+	fmt.Fprintf(buf, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(buf, "Content-Type: text/html; charset=utf-8\r\n")
+	fmt.Fprintf(buf, "Content-Transfer-Encoding: base64\r\n\r\n")
+
+	body := base64.StdEncoding.EncodeToString([]byte(m.Html))
+
+	for len(body) > 76 {
+		buf.WriteString(body[:76] + "\r\n")
+		body = body[76:]
+	}
+
+	buf.WriteString(body + "\r\n")
+
+	return buf.Bytes()
+}
+
+// Send delivers the message with the SMTP server, the connection is upgraded
+// with STARTTLS if the server supports it.
+func Send(config *helper.MailerConfig, message *Message, insecure bool) error {
+	if len(config.Host) == 0 {
+		return errors.New("the SMTP host is not configured")
+	}
+
+	if len(message.From) == 0 || len(message.To) == 0 {
+		return errors.New("the sender and at least one recipient are required")
+	}
+
+	addr := config.Host
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "25")
+	}
+
+	host, _, _ := net.SplitHostPort(addr)
+
+	conn, err := net.DialTimeout("tcp", addr, 30*time.Second)
+
+	if err != nil {
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, host)
+
+	if err != nil {
+		conn.Close()
+
+		return err
+	}
+
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host, InsecureSkipVerify: insecure}); err != nil {
+			return err
+		}
+	}
+
+	if len(config.Username) > 0 {
+		if err := client.Auth(smtp.PlainAuth("", config.Username, config.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(message.From); err != nil {
+		return err
+	}
+
+	for _, to := range message.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(message.Bytes()); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
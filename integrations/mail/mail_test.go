@@ -0,0 +1,146 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package mail
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSmtpServer is a SMTP stand-in accepting one connection, the received
+// commands and message are stored once the connection is closed.
+type fakeSmtpServer struct {
+	listener net.Listener
+	done     chan struct{}
+
+	Auth string
+	From string
+	To   []string
+	Data []byte
+}
+
+func newFakeSmtpServer(t *testing.T) *fakeSmtpServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	s := &fakeSmtpServer{listener: l, done: make(chan struct{})}
+
+	go s.serve()
+
+	return s
+}
+
+func (s *fakeSmtpServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeSmtpServer) Wait() {
+	<-s.done
+	s.listener.Close()
+}
+
+func (s *fakeSmtpServer) serve() {
+	defer close(s.done)
+
+	conn, err := s.listener.Accept()
+
+	if err != nil {
+		return
+	}
+
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP")
+
+	for {
+		line, err := tp.ReadLine()
+
+		if err != nil {
+			return
+		}
+
+		switch strings.ToUpper(strings.SplitN(line, " ", 2)[0]) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-localhost")
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			s.Auth = line
+			tp.PrintfLine("235 2.7.0 Authentication successful")
+		case "MAIL":
+			s.From = line
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			s.To = append(s.To, line)
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 Go ahead")
+			s.Data, _ = tp.ReadDotBytes()
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 Bye")
+
+			return
+		default:
+			tp.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// Body decodes the html body of the received message.
+func (s *fakeSmtpServer) Body(t *testing.T) (*mail.Message, string) {
+	m, err := mail.ReadMessage(strings.NewReader(string(s.Data)))
+	assert.NoError(t, err)
+
+	data, err := ioutil.ReadAll(base64.NewDecoder(base64.StdEncoding, m.Body))
+	assert.NoError(t, err)
+
+	return m, string(data)
+}
+
+func Test_Send(t *testing.T) {
+	s := newFakeSmtpServer(t)
+
+	config := &helper.MailerConfig{
+		Host:     s.Addr(),
+		Username: "user",
+		Password: "secret",
+	}
+
+	message := &Message{
+		From:    "ci@example.com",
+		To:      []string{"dev@example.com", "qa@example.com"},
+		Subject: "[CI] Pipeline réussi",
+		Html:    "<p>" + strings.Repeat("Hello ", 50) + "</p>",
+	}
+
+	assert.NoError(t, Send(config, message, false))
+
+	s.Wait()
+
+	assert.Equal(t, "AUTH PLAIN "+base64.StdEncoding.EncodeToString([]byte("\x00user\x00secret")), s.Auth)
+	assert.Equal(t, "MAIL FROM:<ci@example.com>", s.From)
+	assert.Equal(t, []string{"RCPT TO:<dev@example.com>", "RCPT TO:<qa@example.com>"}, s.To)
+
+	m, body := s.Body(t)
+
+	assert.Equal(t, "dev@example.com, qa@example.com", m.Header.Get("To"))
+	assert.Equal(t, "=?utf-8?q?[CI]_Pipeline_r=C3=A9ussi?=", m.Header.Get("Subject"))
+	assert.Equal(t, message.Html, body)
+}
+
+func Test_Send_Invalid(t *testing.T) {
+	assert.Error(t, Send(&helper.MailerConfig{}, &Message{From: "ci@example.com", To: []string{"dev@example.com"}}, false))
+	assert.Error(t, Send(&helper.MailerConfig{Host: "127.0.0.1:25"}, &Message{From: "ci@example.com"}, false))
+}
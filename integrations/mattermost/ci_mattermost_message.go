@@ -0,0 +1,141 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package mattermost
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/integrations/notifier"
+)
+
+type MattermostMessageCommand struct {
+	Ui         cli.Ui
+	Verbose    bool
+	WebhookUrl string
+	Url        string
+	Token      string
+	Channel    string
+	Username   string
+	IconUrl    string
+}
+
+func (c *MattermostMessageCommand) Run(args []string) int {
+
+	settings, err := helper.NewConfig()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	cmdFlags := flag.NewFlagSet("mattermost:message", flag.ContinueOnError)
+	cmdFlags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	n := notifier.New(c.Ui)
+	n.Flags(cmdFlags)
+
+	m := &notifier.MessageOptions{}
+	m.Flags(cmdFlags)
+
+	cmdFlags.BoolVar(&c.Verbose, "verbose", false, "")
+	cmdFlags.StringVar(&c.WebhookUrl, "webhook", settings.Mattermost.WebhookUrl, "The incoming webhook url")
+	cmdFlags.StringVar(&c.Url, "url", settings.Mattermost.Url, "The server url")
+	cmdFlags.StringVar(&c.Token, "token", settings.Mattermost.Token, "The access token")
+	cmdFlags.StringVar(&c.Channel, "channel", settings.Mattermost.Channel, "The channel")
+	cmdFlags.StringVar(&c.Username, "username", "", "The username displayed with the message")
+	cmdFlags.StringVar(&c.IconUrl, "icon", "", "The icon displayed with the message")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = cmdFlags.Args()
+
+	if len(args) != 1 {
+		c.Ui.Error("Invalid number of arguments\n")
+		cmdFlags.Usage()
+
+		return 1
+	}
+
+	if len(c.Token) > 0 && (len(c.Url) == 0 || len(c.Channel) == 0) {
+		c.Ui.Error("Error: the server url and the channel are required with a token")
+
+		return 1
+	}
+
+	text, err := m.Message(args[0])
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	message := &Message{
+		Channel:  c.Channel,
+		Text:     text,
+		Username: c.Username,
+		IconUrl:  c.IconUrl,
+	}
+
+	client := NewClient(n, c.WebhookUrl, c.Url, c.Token)
+
+	if _, err := client.Post(message); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	if c.Verbose && !n.DryRun {
+		c.Ui.Output("Message sent")
+	}
+
+	return 0
+}
+
+func (c *MattermostMessageCommand) Synopsis() string {
+	return "Send a message to a Mattermost channel."
+}
+
+func (c *MattermostMessageCommand) Help() string {
+	helpText := fmt.Sprintf(`
+Usage: gitlab-ci-helper mattermost:message [options] message
+
+  Send a message to a Mattermost channel, the message can use the markdown
+  format. The message is sent as is, or rendered as a template with the
+  -template option.
+
+%s
+
+Arguments:
+  message             The message to send
+
+Options:
+  -webhook            The incoming webhook url (default: env var MATTERMOST_WEBHOOK_URL)
+  -url                The server url, required with a token (default: env var MATTERMOST_URL)
+  -token              The access token, used instead of the webhook if set
+                        (default: env var MATTERMOST_TOKEN)
+  -channel            The channel, the channel id is required with a token
+                        (default: env var MATTERMOST_CHANNEL)
+  -username           The username displayed with the message, webhook only
+  -icon               The url of the icon displayed with the message, webhook only
+%s
+%s
+  -verbose            Add verbose information to the output
+
+%s
+`, notifier.TemplateHelp, notifier.MessageHelpOptions, notifier.HelpOptions, mattermostConfiguration)
+
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,89 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package mattermost
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MattermostStatusCommand_Token(t *testing.T) {
+	fpProject, err := os.Open("../../fixtures/project.json")
+	assert.NoError(t, err)
+
+	fpJobs, err := os.Open("../../fixtures/pipeline_12_jobs.json")
+	assert.NoError(t, err)
+
+	reqs := []*helper.FakeRequest{
+		{
+			Path:   "/api/v4/projects/3",
+			Method: "GET",
+			Response: &http.Response{
+				Body: fpProject,
+			},
+		},
+		{
+			Path:   "/api/v4/projects/3/pipelines/12/jobs",
+			Method: "GET",
+			Response: &http.Response{
+				Body: fpJobs,
+			},
+		},
+	}
+
+	post := &Post{}
+
+	server := newFakeServer(t, post)
+	defer server.Close()
+
+	envs := map[string]string{
+		"MATTERMOST_URL":     server.URL,
+		"MATTERMOST_TOKEN":   "token",
+		"MATTERMOST_CHANNEL": "c123",
+	}
+
+	helper.WrapperTestCommand(reqs, envs, t, func(ts *httptest.Server) {
+		ui := &cli.MockUi{}
+		c := &MattermostStatusCommand{
+			Ui: ui,
+		}
+
+		code := c.Run([]string{"-project", "3", "-pipeline", "12", "-ref", "889935cf4d3e7558ae6c0d4dd62e20ea600f5a57", "-ref-name", "master"})
+
+		assert.Equal(t, 0, code, ui.ErrorWriter.String())
+		assert.Contains(t, ui.OutputWriter.String(), "Status message updated (status: failed)")
+	})
+
+	attachment := post.Props["attachments"].([]interface{})[0].(map[string]interface{})
+
+	assert.Equal(t, "gitlab:3:pipeline:12", post.Props[STATUS_KEY_PROP])
+	assert.Equal(t, "#db3b21", attachment["color"])
+	assert.Equal(t, "Diaspora / Diaspora Project Site - master: failed", attachment["title"])
+	assert.Contains(t, attachment["text"], "| :no_entry_sign: [#69 - canceled](http://example.com/diaspora/diaspora-project-site/-/jobs/69) | rubocop | test |")
+}
+
+func Test_MattermostStatusCommand_Help(t *testing.T) {
+	c := &MattermostStatusCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.True(t, len(c.Help()) > 0)
+	assert.True(t, len(c.Synopsis()) > 0)
+}
+
+func Test_MattermostStatusCommand_InvalidRun(t *testing.T) {
+	c := &MattermostStatusCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.Equal(t, 1, c.Run([]string{"--foobar"}))
+}
@@ -0,0 +1,251 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package mattermost
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/rande/gitlab-ci-helper/integrations/notifier"
+	"github.com/rande/gitlab-ci-helper/integrations/slack"
+)
+
+// post property used to find the status message of a pipeline
+const STATUS_KEY_PROP = "gitlab_ci_helper_key"
+
+var mattermostConfiguration = `
+Configuration:
+
+  The Mattermost settings are retrieved from the configuration file (mattermost
+  section) or from the environment:
+
+  MATTERMOST_WEBHOOK_URL The incoming webhook url, used if no token is set
+  MATTERMOST_URL      The server url, ie: https://mattermost.example.com
+  MATTERMOST_TOKEN    The bot or personal access token
+  MATTERMOST_CHANNEL  The channel, the channel id is required with a token
+
+  Incoming webhooks can only post new messages. With a token, the status
+  message is updated as the pipeline progresses.
+`
+
+// Message is the incoming webhook payload, the attachments use the Slack
+// format.
+type Message struct {
+	Channel     string                 `json:"channel,omitempty"`
+	Text        string                 `json:"text"`
+	Username    string                 `json:"username,omitempty"`
+	IconUrl     string                 `json:"icon_url,omitempty"`
+	Attachments []*slack.Attachment    `json:"attachments,omitempty"`
+	Props       map[string]interface{} `json:"props,omitempty"`
+}
+
+type Post struct {
+	Id        string                 `json:"id,omitempty"`
+	ChannelId string                 `json:"channel_id,omitempty"`
+	Message   string                 `json:"message"`
+	Props     map[string]interface{} `json:"props,omitempty"`
+}
+
+type postList struct {
+	Order []string         `json:"order"`
+	Posts map[string]*Post `json:"posts"`
+}
+
+// Client sends the messages with the REST API if a token is set, or with the
+// incoming webhook.
+type Client struct {
+	Notifier   *notifier.Notifier
+	WebhookUrl string
+	Url        string
+	Token      string
+}
+
+func NewClient(n *notifier.Notifier, webhookUrl, serverUrl, token string) *Client {
+	return &Client{
+		Notifier:   n,
+		WebhookUrl: webhookUrl,
+		Url:        strings.TrimRight(serverUrl, "/"),
+		Token:      token,
+	}
+}
+
+// CanUpdate returns true if the messages can be updated, this is only
+// possible with a token.
+func (c *Client) CanUpdate() bool {
+	return len(c.Token) > 0
+}
+
+// Post sends a new message, the post id is only returned with a token.
+func (c *Client) Post(m *Message) (string, error) {
+	if len(c.Token) > 0 {
+		post := newPost(m)
+		post.ChannelId = m.Channel
+
+		created := &Post{}
+
+		if err := c.call("POST", "/posts", nil, post, created); err != nil {
+			return "", err
+		}
+
+		return created.Id, nil
+	}
+
+	if len(c.WebhookUrl) == 0 {
+		return "", errors.New("the webhook url or the token is required")
+	}
+
+	return "", c.webhook(m)
+}
+
+// Update replaces the content of the post.
+func (c *Client) Update(id string, m *Message) error {
+	return c.call("PUT", fmt.Sprintf("/posts/%s/patch", url.PathEscape(id)), nil, newPost(m), &Post{})
+}
+
+// FindPost searches the latest posts of the channel for the one with the key
+// in its properties, an empty id is returned if not found.
+func (c *Client) FindPost(channel, key string) (string, error) {
+	params := url.Values{}
+	params.Set("per_page", "100")
+
+	list := &postList{}
+
+	if err := c.call("GET", fmt.Sprintf("/channels/%s/posts", url.PathEscape(channel)), params, nil, list); err != nil {
+		return "", err
+	}
+
+	for _, id := range list.Order {
+		if p, ok := list.Posts[id]; ok && p.Props[STATUS_KEY_PROP] == key {
+			return p.Id, nil
+		}
+	}
+
+	return "", nil
+}
+
+func newPost(m *Message) *Post {
+	props := map[string]interface{}{}
+
+	for k, v := range m.Props {
+		props[k] = v
+	}
+
+	if len(m.Attachments) > 0 {
+		props["attachments"] = m.Attachments
+	}
+
+	return &Post{
+		Message: m.Text,
+		Props:   props,
+	}
+}
+
+func (c *Client) webhook(m *Message) error {
+	_, err := c.Notifier.Send(&notifier.Notification{
+		Url:     c.WebhookUrl,
+		Body:    m,
+		Secrets: []string{c.WebhookUrl},
+	})
+
+	return err
+}
+
+func (c *Client) call(method, path string, params url.Values, body, v interface{}) error {
+	u := c.Url + "/api/v4" + path
+
+	if len(params) > 0 {
+		u = fmt.Sprintf("%s?%s", u, params.Encode())
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+c.Token)
+
+	resp, err := c.Notifier.Send(&notifier.Notification{
+		Method:  method,
+		Url:     u,
+		Header:  header,
+		Body:    body,
+		Secrets: []string{c.Token},
+	})
+
+	if err != nil && resp != nil {
+		// the api error contains a readable message
+		apiErr := &struct {
+			Message string `json:"message"`
+		}{}
+
+		resp.Decode(apiErr)
+
+		return fmt.Errorf("Invalid response from %s %s, status: %d, message: %s", method, path, resp.StatusCode, apiErr.Message)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if resp.DryRun {
+		return nil
+	}
+
+	if err := resp.Decode(v); err != nil {
+		return fmt.Errorf("Unable to decode the response from %s, err: %s", path, err)
+	}
+
+	return nil
+}
+
+// NewStatusMessage builds the message of the report, the jobs are listed in
+// a markdown table like the Flowdock thread.
+func NewStatusMessage(r *notifier.StatusReport) *Message {
+	buf := bytes.NewBuffer([]byte(""))
+
+	buf.WriteString("| Status | Name | Stage |\n")
+	buf.WriteString("|:-------|:-----|:------|\n")
+
+	for _, j := range r.Jobs {
+		fmt.Fprintf(buf, "| %s [#%d - %s](%s)%s | %s | %s |\n", slack.StatusEmoji(j.Status), j.Id, j.Status, r.JobUrl(j), artifactsLink(r, j), escape(j.Name), escape(j.Stage))
+	}
+
+	attachment := &slack.Attachment{
+		Fallback:  r.Title(),
+		Color:     r.Color(),
+		Title:     r.Title(),
+		TitleLink: r.Url(),
+		Text:      buf.String(),
+	}
+
+	if r.Commit != nil {
+		attachment.Fields = []*slack.Field{
+			{Title: "Author", Value: r.Commit.AuthorName, Short: true},
+			{Title: "Commit", Value: fmt.Sprintf("%s (%s)", r.Commit.Title, r.Commit.ShortId), Short: true},
+		}
+	}
+
+	return &Message{
+		Attachments: []*slack.Attachment{attachment},
+		Props: map[string]interface{}{
+			STATUS_KEY_PROP: r.Key(),
+		},
+	}
+}
+
+func artifactsLink(r *notifier.StatusReport, j *gitlab.Job) string {
+	if j.ArtifactsFile.Size == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" [:package:](%s/artifacts/browse)", r.JobUrl(j))
+}
+
+// escape prevents the pipe characters from breaking the table.
+func escape(s string) string {
+	return strings.Replace(s, "|", "\\|", -1)
+}
@@ -0,0 +1,105 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package mattermost
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/rande/gitlab-ci-helper/integrations/notifier"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFakeServer returns a server with one previous status post in the c123
+// channel, the updated post is decoded into post.
+func newFakeServer(t *testing.T, post *Post) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v4/channels/c123/posts":
+			w.Write([]byte(`{"order": ["p2", "p1"], "posts": {
+				"p1": {"id": "p1", "message": "", "props": {"gitlab_ci_helper_key": "gitlab:3:pipeline:12"}},
+				"p2": {"id": "p2", "message": "hello"}
+			}}`))
+		case "POST /api/v4/posts":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(post))
+
+			post.Id = "p3"
+
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(post)
+		case "PUT /api/v4/posts/p1/patch":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(post))
+
+			post.Id = "p1"
+
+			json.NewEncoder(w).Encode(post)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Unable to find the route"}`))
+		}
+	}))
+}
+
+func Test_Client_Token(t *testing.T) {
+	post := &Post{}
+
+	ts := newFakeServer(t, post)
+	defer ts.Close()
+
+	client := NewClient(notifier.New(&cli.MockUi{}), "", ts.URL+"/", "token")
+
+	assert.True(t, client.CanUpdate())
+
+	id, err := client.Post(&Message{Channel: "c123", Text: "Hello"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "p3", id)
+	assert.Equal(t, "c123", post.ChannelId)
+	assert.Equal(t, "Hello", post.Message)
+
+	id, err = client.FindPost("c123", "gitlab:3:pipeline:12")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "p1", id)
+
+	id, err = client.FindPost("c123", "gitlab:3:pipeline:13")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "", id)
+
+	_, err = client.FindPost("c456", "gitlab:3:pipeline:12")
+
+	assert.EqualError(t, err, "Invalid response from GET /channels/c456/posts, status: 404, message: Unable to find the route")
+}
+
+func Test_Client_Webhook(t *testing.T) {
+	message := &Message{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(message))
+
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	client := NewClient(notifier.New(&cli.MockUi{}), ts.URL+"/hooks/xxx", "", "")
+
+	_, err := client.Post(&Message{Channel: "town-square", Text: "Hello", Username: "ci"})
+
+	assert.NoError(t, err)
+	assert.False(t, client.CanUpdate())
+	assert.Equal(t, "Hello", message.Text)
+	assert.Equal(t, "ci", message.Username)
+
+	_, err = NewClient(notifier.New(&cli.MockUi{}), "", "", "").Post(&Message{Text: "Hello"})
+
+	assert.Error(t, err)
+}
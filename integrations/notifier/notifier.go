@@ -0,0 +1,224 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package notifier contains the logic shared by the integrations: the http
+// calls with retries, the pipeline status and the message templates.
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/cli"
+)
+
+// HelpOptions documents the options registered by Flags.
+var HelpOptions = `  -retries            The number of retries on network errors, 429 and 5xx
+                        responses (default: 3)
+  -retry-delay        The delay before the first retry, doubled after each
+                        attempt (default: 2s)
+  -dry-run            Print the requests instead of sending them, the read
+                        requests are still sent`
+
+// Notification is an http call to an integration.
+type Notification struct {
+	Method string
+	Url    string
+	Header http.Header
+
+	// the body is sent as is if it is a []byte, it is json encoded otherwise
+	Body interface{}
+
+	// the values masked in the dry run output and in the errors
+	Secrets []string
+}
+
+type Response struct {
+	StatusCode int
+	Body       []byte
+
+	// the request has not been sent
+	DryRun bool
+}
+
+// Decode decodes the json body into v.
+func (r *Response) Decode(v interface{}) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+type Notifier struct {
+	Ui      cli.Ui
+	Client  *http.Client
+	Retries int
+	Delay   time.Duration
+	DryRun  bool
+
+	sleep func(d time.Duration)
+}
+
+func New(ui cli.Ui) *Notifier {
+	return &Notifier{
+		Ui:      ui,
+		Client:  &http.Client{Timeout: 30 * time.Second},
+		Retries: 3,
+		Delay:   2 * time.Second,
+		sleep:   time.Sleep,
+	}
+}
+
+// Flags registers the -retries, -retry-delay and -dry-run options.
+func (n *Notifier) Flags(fs *flag.FlagSet) {
+	fs.IntVar(&n.Retries, "retries", n.Retries, "The number of retries")
+	fs.DurationVar(&n.Delay, "retry-delay", n.Delay, "The delay before the first retry")
+	fs.BoolVar(&n.DryRun, "dry-run", false, "Print the requests instead of sending them")
+}
+
+// Send sends the notification and checks the response status code, the
+// network errors, the 429 and 5xx responses are retried with an exponential
+// delay. In dry run mode, only the GET requests are sent.
+func (n *Notifier) Send(notification *Notification) (*Response, error) {
+	body, err := encode(notification.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	method := notification.Method
+	if len(method) == 0 {
+		method = "POST"
+	}
+
+	if n.DryRun && method != "GET" {
+		n.print(method, notification, body)
+
+		return &Response{DryRun: true}, nil
+	}
+
+	delay := n.Delay
+
+	for attempt := 0; ; attempt++ {
+		resp, err := n.send(method, notification, body)
+
+		retry := err != nil && (resp == nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500)
+
+		if !retry || attempt >= n.Retries {
+			if err != nil {
+				return resp, errors.New(mask(err.Error(), notification.Secrets))
+			}
+
+			return resp, nil
+		}
+
+		if n.sleep == nil {
+			n.sleep = time.Sleep
+		}
+
+		n.sleep(delay)
+		delay *= 2
+	}
+}
+
+func (n *Notifier) send(method string, notification *Notification, body []byte) (*Response, error) {
+	req, err := http.NewRequest(method, notification.Url, bytes.NewReader(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range notification.Header {
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+
+	if body != nil && len(req.Header.Get("Content-Type")) == 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := n.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Response{StatusCode: resp.StatusCode, Body: data}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return r, fmt.Errorf("Invalid response from %s %s, status: %d, message: %s", method, notification.Url, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	return r, nil
+}
+
+func (n *Notifier) print(method string, notification *Notification, body []byte) {
+	buf := bytes.NewBuffer([]byte(""))
+
+	fmt.Fprintf(buf, "Dry run, %s %s\n", method, notification.Url)
+
+	names := []string{}
+	for name := range notification.Header {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, v := range notification.Header[name] {
+			if http.CanonicalHeaderKey(name) == "Authorization" {
+				v = "****"
+			}
+
+			fmt.Fprintf(buf, "%s: %s\n", name, v)
+		}
+	}
+
+	if body != nil {
+		fmt.Fprintf(buf, "\n%s", body)
+	}
+
+	n.Ui.Output(mask(strings.TrimSpace(buf.String()), notification.Secrets))
+}
+
+func encode(body interface{}) ([]byte, error) {
+	switch b := body.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return b, nil
+	default:
+		return json.Marshal(b)
+	}
+}
+
+func mask(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if len(secret) > 0 {
+			s = strings.Replace(s, secret, "****", -1)
+		}
+	}
+
+	return s
+}
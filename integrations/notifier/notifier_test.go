@@ -0,0 +1,111 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package notifier
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Send_Retry(t *testing.T) {
+	calls := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		if calls < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer ts.Close()
+
+	delays := []time.Duration{}
+
+	n := New(&cli.MockUi{})
+	n.Delay = time.Second
+	n.sleep = func(d time.Duration) {
+		delays = append(delays, d)
+	}
+
+	resp, err := n.Send(&Notification{Url: ts.URL, Body: map[string]string{"foo": "bar"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, delays)
+
+	v := map[string]bool{}
+
+	assert.NoError(t, resp.Decode(&v))
+	assert.True(t, v["ok"])
+}
+
+func Test_Send_NoRetry(t *testing.T) {
+	calls := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid payload, token: secret"))
+	}))
+	defer ts.Close()
+
+	n := New(&cli.MockUi{})
+	n.sleep = func(d time.Duration) {}
+
+	resp, err := n.Send(&Notification{Url: ts.URL, Body: []byte("{}"), Secrets: []string{"secret"}})
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.EqualError(t, err, "Invalid response from POST "+ts.URL+", status: 400, message: invalid payload, token: ****")
+	assert.Equal(t, 1, calls)
+}
+
+func Test_Send_DryRun(t *testing.T) {
+	calls := 0
+	var body []byte
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer ts.Close()
+
+	ui := &cli.MockUi{}
+
+	n := New(ui)
+	n.DryRun = true
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer token")
+	header.Set("X-Key", "secret")
+
+	resp, err := n.Send(&Notification{Url: ts.URL, Header: header, Body: []byte(`{"key": "secret"}`), Secrets: []string{"secret"}})
+
+	assert.NoError(t, err)
+	assert.True(t, resp.DryRun)
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, "Dry run, POST "+ts.URL+"\nAuthorization: ****\nX-Key: ****\n\n{\"key\": \"****\"}\n", ui.OutputWriter.String())
+
+	// read requests are still sent
+	resp, err = n.Send(&Notification{Method: "GET", Url: ts.URL})
+
+	assert.NoError(t, err)
+	assert.False(t, resp.DryRun)
+	assert.Equal(t, 1, calls)
+	assert.Empty(t, body)
+}
@@ -0,0 +1,184 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package notifier
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/rande/gitlab-ci-helper/pipeline"
+)
+
+// StatusHelpOptions documents the options registered by StatusOptions.Flags.
+var StatusHelpOptions = `  -ref                The commit related to the build (default:
+                        9.x: CI_COMMIT_SHA or 8.x: CI_BUILD_REF)
+  -project            The project related to the build (default: env var CI_PROJECT_ID)
+  -name               The build's name (default:
+                        9.x: CI_JOB_NAME or 8.x: CI_BUILD_NAME)
+  -ref-name           The reference name (default:
+                        9.x: CI_COMMIT_REF_NAME or 8.x: CI_BUILD_REF_NAME)
+  -pipeline           The pipeline to report (default: env var CI_PIPELINE_ID), all
+                        the pipelines of the commit are reported if empty
+  -last               Indicate if the current build is the last one`
+
+var statusColors = map[string]string{
+	"pending":  "#555555",
+	"success":  "#1aaa55",
+	"running":  "#fc9403",
+	"failed":   "#db3b21",
+	"canceled": "#999999",
+	"skipped":  "#999999",
+	"manual":   "#1f78d1",
+}
+
+// StatusReport contains the latest attempt of each job of the pipeline, it is
+// the data sent by the status commands.
+type StatusReport struct {
+	Project    *gitlab.Project
+	Commit     *gitlab.Commit
+	Ref        string
+	RefName    string
+	PipelineId string
+	Status     string
+	Warnings   bool
+	Stages     []*pipeline.Stage
+	Jobs       []*gitlab.Job
+}
+
+// NewStatusReport computes the status of the pipeline and of its stages.
+func NewStatusReport(project *gitlab.Project, ref, refName, pipelineId string, jobs []*gitlab.Job) *StatusReport {
+	report := &StatusReport{
+		Project:    project,
+		Ref:        ref,
+		RefName:    refName,
+		PipelineId: pipelineId,
+	}
+
+	report.set(pipeline.Aggregate(jobs))
+
+	if len(jobs) > 0 {
+		report.Commit = jobs[0].Commit
+	}
+
+	return report
+}
+
+// Finish marks the reporting job as successful, the command must be the last
+// one of the pipeline.
+func (r *StatusReport) Finish(name string) {
+	s := pipeline.Aggregate(r.Jobs)
+	s.Finish(name)
+
+	r.set(s)
+}
+
+func (r *StatusReport) set(s *pipeline.Status) {
+	r.Status = s.Status
+	r.Warnings = s.Warnings
+	r.Stages = s.Stages
+	r.Jobs = s.Jobs
+}
+
+// Key identifies the status message of the pipeline, so it can be updated.
+func (r *StatusReport) Key() string {
+	if len(r.PipelineId) > 0 {
+		return fmt.Sprintf("gitlab:%d:pipeline:%s", r.Project.Id, r.PipelineId)
+	}
+
+	return fmt.Sprintf("gitlab:%d:commit:%s", r.Project.Id, r.Ref)
+}
+
+func (r *StatusReport) Url() string {
+	if len(r.PipelineId) > 0 {
+		return fmt.Sprintf("%s/-/pipelines/%s", r.Project.WebUrl, r.PipelineId)
+	}
+
+	return fmt.Sprintf("%s/commit/%s/pipelines", r.Project.WebUrl, r.Ref)
+}
+
+func (r *StatusReport) Title() string {
+	return fmt.Sprintf("%s - %s: %s", r.Project.NameWithNamespace, r.RefName, r.Status)
+}
+
+func (r *StatusReport) Color() string {
+	return StatusColor(r.Status)
+}
+
+func (r *StatusReport) JobUrl(j *gitlab.Job) string {
+	if len(j.WebUrl) > 0 {
+		return j.WebUrl
+	}
+
+	return fmt.Sprintf("%s/-/jobs/%d", r.Project.WebUrl, j.Id)
+}
+
+// StatusColor returns the GitLab color of the status.
+func StatusColor(status string) string {
+	if color, ok := statusColors[status]; ok {
+		return color
+	}
+
+	return statusColors["pending"]
+}
+
+// StatusOptions contains the options used to retrieve the pipeline status.
+type StatusOptions struct {
+	Last         bool
+	BuildRef     string
+	BuildName    string
+	BuildRefName string
+	PipelineId   string
+	Project      string
+}
+
+// Flags registers the options, the default values are retrieved from the
+// GitLab CI env vars.
+func (o *StatusOptions) Flags(fs *flag.FlagSet) {
+	fs.BoolVar(&o.Last, "last", false, "")
+	fs.StringVar(&o.BuildRef, "ref", helper.GetEnv("CI_COMMIT_SHA", os.Getenv("CI_BUILD_REF")), "The commit related to the build")
+	fs.StringVar(&o.Project, "project", os.Getenv("CI_PROJECT_ID"), "The project related to the build")
+	fs.StringVar(&o.BuildName, "name", helper.GetEnv("CI_JOB_NAME", os.Getenv("CI_BUILD_NAME")), "The build's name")
+	fs.StringVar(&o.BuildRefName, "ref-name", helper.GetEnv("CI_COMMIT_REF_NAME", os.Getenv("CI_BUILD_REF_NAME")), "The reference name")
+	fs.StringVar(&o.PipelineId, "pipeline", os.Getenv("CI_PIPELINE_ID"), "The pipeline to report")
+}
+
+// Report retrieves the jobs of the pipeline, or of all the pipelines of the
+// commit if no pipeline is provided.
+func (o *StatusOptions) Report(client gitlab.Client, ui cli.Ui) (*StatusReport, error) {
+	project, err := helper.GetProject(o.Project, client)
+
+	if err != nil {
+		return nil, fmt.Errorf("Unable to fetch the project: %s", err)
+	}
+
+	ui.Output(fmt.Sprintf("Found project: %s/%s (id: %d)", project.Namespace.Name, project.Name, project.Id))
+
+	var jobs []*gitlab.Job
+
+	if len(o.PipelineId) > 0 {
+		jobs, err = helper.GetPipelineJobs(project, o.PipelineId, client)
+	} else {
+		jobs, err = helper.GetCommitJobs(project, o.BuildRef, client)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	report := NewStatusReport(project, o.BuildRef, o.BuildRefName, o.PipelineId, jobs)
+
+	// if it the last pass with no previous error, the current job is the
+	// reporting one and it is considered as successful
+	if o.Last {
+		report.Finish(o.BuildName)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,41 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package notifier
+
+import (
+	"testing"
+
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StatusReport(t *testing.T) {
+	project := &gitlab.Project{Id: 3, WebUrl: "http://example.com/foo/bar", NameWithNamespace: "Foo / Bar"}
+
+	report := NewStatusReport(project, "sha", "master", "", []*gitlab.Job{
+		{Id: 1, Name: "test", Status: "failed"},
+		{Id: 2, Name: "test", Status: "success"},
+		{Id: 3, Name: "notify", Status: "running"},
+	})
+
+	assert.Equal(t, "running", report.Status)
+	assert.Len(t, report.Stages, 1)
+	assert.Equal(t, "#fc9403", report.Color())
+	assert.Equal(t, "gitlab:3:commit:sha", report.Key())
+	assert.Equal(t, "http://example.com/foo/bar/commit/sha/pipelines", report.Url())
+	assert.Equal(t, "http://example.com/foo/bar/-/jobs/3", report.JobUrl(report.Jobs[1]))
+
+	report.Finish("notify")
+
+	assert.Equal(t, "success", report.Status)
+	assert.Equal(t, "success", report.Jobs[1].Status)
+	assert.Equal(t, "Foo / Bar - master: success", report.Title())
+
+	report.PipelineId = "12"
+
+	assert.Equal(t, "gitlab:3:pipeline:12", report.Key())
+	assert.Equal(t, "http://example.com/foo/bar/-/pipelines/12", report.Url())
+}
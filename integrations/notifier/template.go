@@ -0,0 +1,86 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package notifier
+
+import (
+	"flag"
+
+	helper "github.com/rande/gitlab-ci-helper"
+)
+
+// TemplateHelp documents the data and the functions available in the
+// templates.
+var TemplateHelp = `  The templates use the Go template syntax and receive:
+    .Meta               The build information, the ci:meta data
+    .Report             The pipeline status: Project, Commit, Ref, RefName,
+                          PipelineId, Status, Warnings, Stages and Jobs (latest
+                          attempt of each job), nil if the pipeline is not
+                          retrieved
+    .Report.Url         The pipeline url
+    .Report.JobUrl      The url of a job, ie: {{ .Report.JobUrl $job }}
+
+  and the functions:
+    json                Encode a value, ie: {"status": {{ json .Report.Status }}}
+    env                 Return an env var, ie: {{ env "CI_ENVIRONMENT_NAME" }}
+    color               Return the GitLab color of a status`
+
+// MessageHelpOptions documents the option of the message commands.
+var MessageHelpOptions = `  -template           Render the message as a template, the pipeline status
+                        (.Report) is not available (default: false)`
+
+// MessageOptions contains the option of the message commands, the message
+// is sent as is unless it is rendered as a template.
+type MessageOptions struct {
+	Template bool
+}
+
+func (o *MessageOptions) Flags(fs *flag.FlagSet) {
+	fs.BoolVar(&o.Template, "template", false, "Render the message as a template")
+}
+
+// Message returns the message to send.
+func (o *MessageOptions) Message(message string) (string, error) {
+	if !o.Template {
+		return message, nil
+	}
+
+	return Render(message, NewPayload(nil), nil)
+}
+
+// Payload contains the data available in the templates, the report is nil if
+// the pipeline is not retrieved.
+type Payload struct {
+	Meta   *helper.Meta
+	Report *StatusReport
+}
+
+func NewPayload(report *StatusReport) *Payload {
+	return &Payload{
+		Meta:   helper.NewMeta(),
+		Report: report,
+	}
+}
+
+// Render executes a text template with the helper functions and the color
+// function, the extra functions are added to the default ones.
+func Render(tpl string, data interface{}, extra map[string]interface{}) (string, error) {
+	return helper.Render(tpl, data, withColor(extra))
+}
+
+// RenderHtml executes a html template, the values are escaped.
+func RenderHtml(tpl string, data interface{}, extra map[string]interface{}) (string, error) {
+	return helper.RenderHtml(tpl, data, withColor(extra))
+}
+
+func withColor(extra map[string]interface{}) map[string]interface{} {
+	funcs := map[string]interface{}{"color": StatusColor}
+
+	for name, f := range extra {
+		funcs[name] = f
+	}
+
+	return funcs
+}
@@ -0,0 +1,137 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package slack
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/integrations/notifier"
+)
+
+type SlackMessageCommand struct {
+	Ui         cli.Ui
+	Verbose    bool
+	WebhookUrl string
+	Token      string
+	Channel    string
+	Username   string
+	IconUrl    string
+}
+
+func (c *SlackMessageCommand) Run(args []string) int {
+
+	settings, err := helper.NewConfig()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	cmdFlags := flag.NewFlagSet("slack:message", flag.ContinueOnError)
+	cmdFlags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	n := notifier.New(c.Ui)
+	n.Flags(cmdFlags)
+
+	m := &notifier.MessageOptions{}
+	m.Flags(cmdFlags)
+
+	cmdFlags.BoolVar(&c.Verbose, "verbose", false, "")
+	cmdFlags.StringVar(&c.WebhookUrl, "webhook", settings.Slack.WebhookUrl, "The incoming webhook url")
+	cmdFlags.StringVar(&c.Token, "token", settings.Slack.Token, "The bot token")
+	cmdFlags.StringVar(&c.Channel, "channel", settings.Slack.Channel, "The channel")
+	cmdFlags.StringVar(&c.Username, "username", "", "The username displayed with the message")
+	cmdFlags.StringVar(&c.IconUrl, "icon", "", "The icon displayed with the message")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = cmdFlags.Args()
+
+	if len(args) != 1 {
+		c.Ui.Error("Invalid number of arguments\n")
+		cmdFlags.Usage()
+
+		return 1
+	}
+
+	if len(c.Token) > 0 && len(c.Channel) == 0 {
+		c.Ui.Error("Error: the channel is required with a bot token")
+
+		return 1
+	}
+
+	text, err := m.Message(args[0])
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	message := &Message{
+		Channel:  c.Channel,
+		Text:     text,
+		Username: c.Username,
+		IconUrl:  c.IconUrl,
+	}
+
+	client := NewClient(n, c.WebhookUrl, c.Token)
+
+	if _, err := client.Post(message); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	if c.Verbose && !n.DryRun {
+		c.Ui.Output("Message sent")
+	}
+
+	return 0
+}
+
+func (c *SlackMessageCommand) Synopsis() string {
+	return "Send a message to a Slack channel."
+}
+
+func (c *SlackMessageCommand) Help() string {
+	helpText := fmt.Sprintf(`
+Usage: gitlab-ci-helper slack:message [options] message
+
+  Send a message to a Slack channel, the message can use the mrkdwn format.
+  The message is sent as is, or rendered as a template with the -template
+  option.
+
+%s
+
+Arguments:
+  message             The message to send
+
+Options:
+  -webhook            The incoming webhook url (default: env var SLACK_WEBHOOK_URL)
+  -token              The bot token, used instead of the webhook if set
+                        (default: env var SLACK_TOKEN)
+  -channel            The channel, required with a bot token (default: env var SLACK_CHANNEL)
+  -username           The username displayed with the message
+  -icon               The url of the icon displayed with the message
+%s
+%s
+  -verbose            Add verbose information to the output
+
+%s
+`, notifier.TemplateHelp, notifier.MessageHelpOptions, notifier.HelpOptions, slackConfiguration)
+
+	return strings.TrimSpace(helpText)
+}
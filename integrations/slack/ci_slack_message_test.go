@@ -0,0 +1,64 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package slack
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SlackMessageCommand_DryRun(t *testing.T) {
+	envs := map[string]string{
+		"CI_JOB_ID": "42",
+	}
+
+	helper.WrapperTestCommand([]*helper.FakeRequest{}, envs, t, func(ts *httptest.Server) {
+		ui := &cli.MockUi{}
+		c := &SlackMessageCommand{
+			Ui: ui,
+		}
+
+		code := c.Run([]string{"-dry-run", "-template", "-webhook", "http://localhost/hook", "Job {{ .Meta.Build.Id }} done"})
+
+		assert.Equal(t, 0, code, ui.ErrorWriter.String())
+		assert.Contains(t, ui.OutputWriter.String(), `"text":"Job 42 done"`)
+
+		// the message is sent as is by default
+		ui = &cli.MockUi{}
+		c = &SlackMessageCommand{
+			Ui: ui,
+		}
+
+		code = c.Run([]string{"-dry-run", "-webhook", "http://localhost/hook", "Use {{ .Meta }}"})
+
+		assert.Equal(t, 0, code, ui.ErrorWriter.String())
+		assert.Contains(t, ui.OutputWriter.String(), `"text":"Use {{ .Meta }}"`)
+	})
+}
+
+func Test_SlackMessageCommand_Help(t *testing.T) {
+	c := &SlackMessageCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.True(t, len(c.Help()) > 0)
+	assert.True(t, len(c.Synopsis()) > 0)
+}
+
+func Test_SlackMessageCommand_InvalidRun(t *testing.T) {
+	c := &SlackMessageCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.Equal(t, 1, c.Run([]string{"--foobar"}))
+	assert.Equal(t, 1, c.Run([]string{}))
+	assert.Equal(t, 1, c.Run([]string{"-token", "xoxb-token", "Hello"}))
+	assert.Equal(t, 1, c.Run([]string{"-webhook", "http://localhost/hook", "{{ .Foo"}))
+}
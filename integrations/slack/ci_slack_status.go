@@ -0,0 +1,171 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package slack
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/rande/gitlab-ci-helper/integrations/notifier"
+)
+
+type SlackStatusCommand struct {
+	notifier.StatusOptions
+
+	Ui         cli.Ui
+	Verbose    bool
+	Update     bool
+	WebhookUrl string
+	Token      string
+	Channel    string
+	Username   string
+	IconUrl    string
+}
+
+func (c *SlackStatusCommand) Run(args []string) int {
+
+	settings, err := helper.NewConfig()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	cmdFlags := flag.NewFlagSet("slack:status", flag.ContinueOnError)
+	cmdFlags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	n := notifier.New(c.Ui)
+	n.Flags(cmdFlags)
+	c.StatusOptions.Flags(cmdFlags)
+
+	cmdFlags.BoolVar(&c.Verbose, "verbose", false, "")
+	cmdFlags.BoolVar(&c.Update, "update", true, "Update the previous status message")
+	cmdFlags.StringVar(&c.WebhookUrl, "webhook", settings.Slack.WebhookUrl, "The incoming webhook url")
+	cmdFlags.StringVar(&c.Token, "token", settings.Slack.Token, "The bot token")
+	cmdFlags.StringVar(&c.Channel, "channel", settings.Slack.Channel, "The channel")
+	cmdFlags.StringVar(&c.Username, "username", "", "The username displayed with the message")
+	cmdFlags.StringVar(&c.IconUrl, "icon", "", "The icon displayed with the message")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.Token) > 0 && len(c.Channel) == 0 {
+		c.Ui.Error("Error: the channel is required with a bot token")
+
+		return 1
+	}
+
+	gitLabClient := gitlab.NewClient(settings.Gitlab.Host, settings.Gitlab.ApiPath, settings.Gitlab.Token)
+
+	report, err := c.Report(gitLabClient, c.Ui)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	message := NewStatusMessage(report)
+	message.Channel = c.Channel
+	message.Username = c.Username
+	message.IconUrl = c.IconUrl
+
+	client := NewClient(n, c.WebhookUrl, c.Token)
+
+	if c.Update && client.CanUpdate() {
+		ts, err := client.FindMessage(c.Channel, report.Key())
+
+		if err != nil {
+			// the status is still sent, a new message is better than no message
+			c.Ui.Output(fmt.Sprintf("Unable to find the previous status message, %s", err.Error()))
+		}
+
+		if len(ts) > 0 {
+			message.Ts = ts
+
+			if c.Verbose {
+				c.Ui.Output(fmt.Sprintf("Update the message %s (key: %s)", ts, report.Key()))
+			}
+
+			if err := client.Update(message); err != nil {
+				c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+				return 1
+			}
+
+			if !n.DryRun {
+				c.Ui.Output(fmt.Sprintf("Status message updated (status: %s)", report.Status))
+			}
+
+			return 0
+		}
+	}
+
+	if _, err := client.Post(message); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	if !n.DryRun {
+		c.Ui.Output(fmt.Sprintf("Status message sent (status: %s)", report.Status))
+	}
+
+	return 0
+}
+
+func (c *SlackStatusCommand) Synopsis() string {
+	return "Send the pipeline status to a Slack channel."
+}
+
+func (c *SlackStatusCommand) Help() string {
+	helpText := fmt.Sprintf(`
+Usage: gitlab-ci-helper slack:status [options]
+
+  Send the status of the pipeline jobs to a Slack channel, only the latest
+  attempt of each job is reported. Information are retrieved from environment
+  variables.
+
+  With a bot token, the message sent by a previous job of the pipeline is
+  updated, so the channel contains one message per pipeline. The message is
+  identified by the key stored in its metadata:
+    gitlab:project_id:pipeline:pipeline_id, or
+    gitlab:project_id:commit:sha if no pipeline is provided
+
+  You can use the -last option to indicate that the current job is the last one.
+
+Options:
+%s
+  -webhook            The incoming webhook url (default: env var SLACK_WEBHOOK_URL)
+  -token              The bot token, used instead of the webhook if set
+                        (default: env var SLACK_TOKEN)
+  -channel            The channel, required with a bot token (default: env var SLACK_CHANNEL)
+  -update             Update the previous status message of the pipeline, only
+                        available with a bot token (default: true)
+  -username           The username displayed with the message
+  -icon               The url of the icon displayed with the message
+%s
+  -verbose            Add verbose information to the output
+
+%s
+Gitlab's credentials are retrieved from environment:
+
+  GITLAB_HOST         The gitlab host
+  GITLAB_TOKEN        The user's token
+  GITLAB_API_PATH     (optional) the api path, default to: "/api/v4"
+
+`, notifier.StatusHelpOptions, notifier.HelpOptions, slackConfiguration)
+
+	return strings.TrimSpace(helpText)
+}
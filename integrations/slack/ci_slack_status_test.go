@@ -0,0 +1,92 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package slack
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SlackStatusCommand_Webhook(t *testing.T) {
+	fpProject, err := os.Open("../../fixtures/project.json")
+	assert.NoError(t, err)
+
+	fpJobs, err := os.Open("../../fixtures/pipeline_12_jobs.json")
+	assert.NoError(t, err)
+
+	reqs := []*helper.FakeRequest{
+		{
+			Path:   "/api/v4/projects/3",
+			Method: "GET",
+			Response: &http.Response{
+				Body: fpProject,
+			},
+		},
+		{
+			Path:   "/api/v4/projects/3/pipelines/12/jobs",
+			Method: "GET",
+			Response: &http.Response{
+				Body: fpJobs,
+			},
+		},
+	}
+
+	message := &Message{}
+
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(message))
+
+		w.Write([]byte("ok"))
+	}))
+	defer webhook.Close()
+
+	envs := map[string]string{
+		"SLACK_WEBHOOK_URL": webhook.URL,
+	}
+
+	helper.WrapperTestCommand(reqs, envs, t, func(ts *httptest.Server) {
+		ui := &cli.MockUi{}
+		c := &SlackStatusCommand{
+			Ui: ui,
+		}
+
+		code := c.Run([]string{"-project", "3", "-pipeline", "12", "-ref", "889935cf4d3e7558ae6c0d4dd62e20ea600f5a57", "-ref-name", "master"})
+
+		assert.Equal(t, 0, code, ui.ErrorWriter.String())
+		assert.Contains(t, ui.OutputWriter.String(), "Status message sent (status: failed)")
+	})
+
+	assert.Equal(t, "Diaspora / Diaspora Project Site - master: failed", message.Text)
+	assert.Equal(t, "gitlab:3:pipeline:12", message.Metadata.EventPayload["key"])
+	assert.Len(t, message.Attachments, 1)
+	assert.Equal(t, "#db3b21", message.Attachments[0].Color)
+	assert.Contains(t, message.Attachments[0].Blocks[0].Text.Text, "<http://example.com/diaspora/diaspora-project-site/-/jobs/69|#69 - canceled> *rubocop* (test)")
+	assert.Contains(t, message.Blocks[1].Elements[0].Text, "*Administrator*: Test the CI integration.")
+}
+
+func Test_SlackStatusCommand_Help(t *testing.T) {
+	c := &SlackStatusCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.True(t, len(c.Help()) > 0)
+	assert.True(t, len(c.Synopsis()) > 0)
+}
+
+func Test_SlackStatusCommand_InvalidRun(t *testing.T) {
+	c := &SlackStatusCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.Equal(t, 1, c.Run([]string{"--foobar"}))
+}
@@ -0,0 +1,227 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package slack
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rande/gitlab-ci-helper/integrations/notifier"
+)
+
+const SLACK_API_URL = "https://slack.com/api"
+
+// metadata event type used to find the status message of a pipeline
+const STATUS_EVENT_TYPE = "gitlab_pipeline_status"
+
+var slackConfiguration = `
+Configuration:
+
+  The Slack settings are retrieved from the configuration file (slack section)
+  or from the environment:
+
+  SLACK_WEBHOOK_URL   The incoming webhook url, used if no token is set
+  SLACK_TOKEN         The bot token (xoxb-...), the bot requires the chat:write scope
+  SLACK_CHANNEL       The channel, the channel id is required to update a message
+
+  Incoming webhooks can only post new messages. With a bot token, the status
+  message is updated as the pipeline progresses, the bot also requires the
+  channels:history scope (groups:history for a private channel).
+`
+
+type Text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Block is a Block Kit block, only the section and context blocks are used.
+type Block struct {
+	Type     string  `json:"type"`
+	Text     *Text   `json:"text,omitempty"`
+	Fields   []*Text `json:"fields,omitempty"`
+	Elements []*Text `json:"elements,omitempty"`
+}
+
+type Field struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// Attachment is a legacy attachment, it is used to display the colored bar
+// next to the blocks. Mattermost supports the same format.
+type Attachment struct {
+	Color     string   `json:"color,omitempty"`
+	Fallback  string   `json:"fallback,omitempty"`
+	Title     string   `json:"title,omitempty"`
+	TitleLink string   `json:"title_link,omitempty"`
+	Text      string   `json:"text,omitempty"`
+	Footer    string   `json:"footer,omitempty"`
+	Fields    []*Field `json:"fields,omitempty"`
+	Blocks    []*Block `json:"blocks,omitempty"`
+}
+
+type Metadata struct {
+	EventType    string                 `json:"event_type"`
+	EventPayload map[string]interface{} `json:"event_payload"`
+}
+
+type Message struct {
+	Channel     string        `json:"channel,omitempty"`
+	Ts          string        `json:"ts,omitempty"`
+	Text        string        `json:"text"`
+	Blocks      []*Block      `json:"blocks,omitempty"`
+	Attachments []*Attachment `json:"attachments,omitempty"`
+	Username    string        `json:"username,omitempty"`
+	IconUrl     string        `json:"icon_url,omitempty"`
+	Metadata    *Metadata     `json:"metadata,omitempty"`
+}
+
+type apiResponse struct {
+	Ok       bool       `json:"ok"`
+	Error    string     `json:"error"`
+	Channel  string     `json:"channel"`
+	Ts       string     `json:"ts"`
+	Messages []*Message `json:"messages"`
+}
+
+// Client sends the messages with the Web API if a bot token is set, or with
+// the incoming webhook.
+type Client struct {
+	Notifier   *notifier.Notifier
+	WebhookUrl string
+	Token      string
+	ApiUrl     string
+}
+
+func NewClient(n *notifier.Notifier, webhookUrl, token string) *Client {
+	return &Client{
+		Notifier:   n,
+		WebhookUrl: webhookUrl,
+		Token:      token,
+		ApiUrl:     SLACK_API_URL,
+	}
+}
+
+// CanUpdate returns true if the messages can be updated, this is only
+// possible with a bot token.
+func (c *Client) CanUpdate() bool {
+	return len(c.Token) > 0
+}
+
+// Post sends a new message, the timestamp identifying the message is only
+// returned with a bot token.
+func (c *Client) Post(m *Message) (string, error) {
+	if len(c.Token) > 0 {
+		resp := &apiResponse{}
+
+		if err := c.call("chat.postMessage", nil, m, resp); err != nil {
+			return "", err
+		}
+
+		return resp.Ts, nil
+	}
+
+	if len(c.WebhookUrl) == 0 {
+		return "", errors.New("the webhook url or the bot token is required")
+	}
+
+	return "", c.webhook(m)
+}
+
+// Update replaces the content of the message identified by its channel and
+// timestamp.
+func (c *Client) Update(m *Message) error {
+	return c.call("chat.update", nil, m, &apiResponse{})
+}
+
+// FindMessage searches the latest messages of the channel for the one with
+// the key in its metadata, an empty timestamp is returned if not found.
+func (c *Client) FindMessage(channel, key string) (string, error) {
+	params := url.Values{}
+	params.Set("channel", channel)
+	params.Set("limit", "100")
+	params.Set("include_all_metadata", "true")
+
+	resp := &apiResponse{}
+
+	if err := c.call("conversations.history", params, nil, resp); err != nil {
+		return "", err
+	}
+
+	for _, m := range resp.Messages {
+		if m.Metadata != nil && m.Metadata.EventType == STATUS_EVENT_TYPE && m.Metadata.EventPayload["key"] == key {
+			return m.Ts, nil
+		}
+	}
+
+	return "", nil
+}
+
+func (c *Client) webhook(m *Message) error {
+	_, err := c.Notifier.Send(&notifier.Notification{
+		Url:     c.WebhookUrl,
+		Body:    m,
+		Secrets: []string{c.WebhookUrl},
+	})
+
+	return err
+}
+
+// call sends a json body if provided, the read methods only accept the
+// parameters in the query string.
+func (c *Client) call(method string, params url.Values, body, v interface{}) error {
+	u := fmt.Sprintf("%s/%s", strings.TrimRight(c.ApiUrl, "/"), method)
+
+	if len(params) > 0 {
+		u = fmt.Sprintf("%s?%s", u, params.Encode())
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+c.Token)
+
+	httpMethod := "GET"
+
+	if body != nil {
+		httpMethod = "POST"
+		header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+
+	resp, err := c.Notifier.Send(&notifier.Notification{
+		Method:  httpMethod,
+		Url:     u,
+		Header:  header,
+		Body:    body,
+		Secrets: []string{c.Token},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if resp.DryRun {
+		return nil
+	}
+
+	if err := resp.Decode(v); err != nil {
+		return fmt.Errorf("Unable to decode the response from %s, err: %s", method, err)
+	}
+
+	// the Web API returns a 200 status code with the error in the payload
+	if r, ok := v.(*apiResponse); ok && !r.Ok {
+		return fmt.Errorf("Invalid response from %s, error: %s", method, r.Error)
+	}
+
+	return nil
+}
+
+// Escape escapes the control characters of the mrkdwn format.
+func Escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,177 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package slack
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/rande/gitlab-ci-helper/integrations/notifier"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Client_Post_Webhook(t *testing.T) {
+	var body map[string]interface{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/services/T/B/X", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	client := NewClient(notifier.New(&cli.MockUi{}), ts.URL+"/services/T/B/X", "")
+
+	ts2, err := client.Post(&Message{Channel: "#ci", Text: "Hello"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "", ts2)
+	assert.Equal(t, "Hello", body["text"])
+	assert.Equal(t, "#ci", body["channel"])
+	assert.False(t, client.CanUpdate())
+}
+
+func Test_Client_Post_Webhook_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("no_service"))
+	}))
+	defer ts.Close()
+
+	_, err := NewClient(notifier.New(&cli.MockUi{}), ts.URL, "").Post(&Message{Text: "Hello"})
+
+	assert.EqualError(t, err, "Invalid response from POST ****, status: 404, message: no_service")
+
+	_, err = NewClient(notifier.New(&cli.MockUi{}), "", "").Post(&Message{Text: "Hello"})
+
+	assert.Error(t, err)
+}
+
+func Test_Client_Token(t *testing.T) {
+	calls := []string{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+
+		assert.Equal(t, "Bearer xoxb-token", r.Header.Get("Authorization"))
+
+		switch r.URL.Path {
+		case "/api/chat.postMessage":
+			data, _ := ioutil.ReadAll(r.Body)
+
+			assert.Contains(t, string(data), `"event_type":"gitlab_pipeline_status"`)
+
+			w.Write([]byte(`{"ok": true, "channel": "C123", "ts": "1503435956.000247"}`))
+		case "/api/conversations.history":
+			assert.Equal(t, "C123", r.URL.Query().Get("channel"))
+			assert.Equal(t, "true", r.URL.Query().Get("include_all_metadata"))
+
+			w.Write([]byte(`{"ok": true, "messages": [
+				{"ts": "1503435957.000001", "text": "hello"},
+				{"ts": "1503435956.000247", "metadata": {"event_type": "gitlab_pipeline_status", "event_payload": {"key": "gitlab:3:pipeline:12"}}}
+			]}`))
+		case "/api/chat.update":
+			m := &Message{}
+
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(m))
+			assert.Equal(t, "1503435956.000247", m.Ts)
+
+			w.Write([]byte(`{"ok": true}`))
+		}
+	}))
+	defer ts.Close()
+
+	client := NewClient(notifier.New(&cli.MockUi{}), "", "xoxb-token")
+	client.ApiUrl = ts.URL + "/api"
+
+	assert.True(t, client.CanUpdate())
+
+	message := &Message{
+		Channel:  "C123",
+		Text:     "status",
+		Metadata: &Metadata{EventType: STATUS_EVENT_TYPE, EventPayload: map[string]interface{}{"key": "gitlab:3:pipeline:12"}},
+	}
+
+	id, err := client.Post(message)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1503435956.000247", id)
+
+	id, err = client.FindMessage("C123", "gitlab:3:pipeline:12")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1503435956.000247", id)
+
+	id, err = client.FindMessage("C123", "gitlab:3:pipeline:13")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "", id)
+
+	message.Ts = "1503435956.000247"
+
+	assert.NoError(t, client.Update(message))
+	assert.Equal(t, []string{
+		"POST /api/chat.postMessage",
+		"GET /api/conversations.history",
+		"GET /api/conversations.history",
+		"POST /api/chat.update",
+	}, calls)
+}
+
+func Test_Client_Token_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok": false, "error": "channel_not_found"}`))
+	}))
+	defer ts.Close()
+
+	client := NewClient(notifier.New(&cli.MockUi{}), "", "xoxb-token")
+	client.ApiUrl = ts.URL
+
+	_, err := client.Post(&Message{Channel: "#ci", Text: "Hello"})
+
+	assert.EqualError(t, err, "Invalid response from chat.postMessage, error: channel_not_found")
+}
+
+func Test_Client_DryRun(t *testing.T) {
+	calls := []string{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+
+		w.Write([]byte(`{"ok": true, "messages": []}`))
+	}))
+	defer ts.Close()
+
+	ui := &cli.MockUi{}
+
+	n := notifier.New(ui)
+	n.DryRun = true
+
+	client := NewClient(n, "", "xoxb-token")
+	client.ApiUrl = ts.URL
+
+	id, err := client.FindMessage("C123", "gitlab:3:pipeline:12")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "", id)
+
+	id, err = client.Post(&Message{Channel: "C123", Text: "Hello"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "", id)
+	assert.Equal(t, []string{"GET /conversations.history"}, calls)
+	assert.Contains(t, ui.OutputWriter.String(), "Dry run, POST "+ts.URL+"/chat.postMessage")
+	assert.NotContains(t, ui.OutputWriter.String(), "xoxb-token")
+}
+
+func Test_Escape(t *testing.T) {
+	assert.Equal(t, "a &lt;b&gt; &amp; c", Escape("a <b> & c"))
+}
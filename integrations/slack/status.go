@@ -0,0 +1,91 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package slack
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/rande/gitlab-ci-helper/integrations/notifier"
+)
+
+var emojis = map[string]string{
+	"pending":  ":hourglass_flowing_sand:",
+	"success":  ":white_check_mark:",
+	"running":  ":arrows_counterclockwise:",
+	"failed":   ":x:",
+	"canceled": ":no_entry_sign:",
+	"skipped":  ":fast_forward:",
+	"manual":   ":arrow_forward:",
+}
+
+// the maximum length of a section text is 3000 characters
+const maxSectionLength = 2900
+
+func StatusEmoji(status string) string {
+	if emoji, ok := emojis[status]; ok {
+		return emoji
+	}
+
+	return emojis["pending"]
+}
+
+// NewStatusMessage builds the Block Kit message of the report, the jobs are
+// listed in an attachment to display the status color.
+func NewStatusMessage(r *notifier.StatusReport) *Message {
+	header := fmt.Sprintf("*<%s|%s>* - `%s`: *<%s|%s>*", r.Project.WebUrl, Escape(r.Project.NameWithNamespace), Escape(r.RefName), r.Url(), r.Status)
+
+	blocks := []*Block{
+		{Type: "section", Text: &Text{Type: "mrkdwn", Text: header}},
+	}
+
+	if r.Commit != nil {
+		blocks = append(blocks, &Block{
+			Type: "context",
+			Elements: []*Text{
+				{Type: "mrkdwn", Text: fmt.Sprintf("*%s*: %s (%s)", Escape(r.Commit.AuthorName), Escape(r.Commit.Title), r.Commit.ShortId)},
+			},
+		})
+	}
+
+	jobs := []*Block{}
+	buf := bytes.NewBuffer([]byte(""))
+
+	for _, j := range r.Jobs {
+		line := fmt.Sprintf("%s <%s|#%d - %s> *%s* (%s)", StatusEmoji(j.Status), r.JobUrl(j), j.Id, j.Status, Escape(j.Name), Escape(j.Stage))
+
+		if j.ArtifactsFile.Size > 0 {
+			line += fmt.Sprintf(" <%s/artifacts/browse|:package:>", r.JobUrl(j))
+		}
+
+		if buf.Len() > 0 && buf.Len()+len(line) > maxSectionLength {
+			jobs = append(jobs, &Block{Type: "section", Text: &Text{Type: "mrkdwn", Text: buf.String()}})
+			buf.Reset()
+		}
+
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+
+		buf.WriteString(line)
+	}
+
+	if buf.Len() > 0 {
+		jobs = append(jobs, &Block{Type: "section", Text: &Text{Type: "mrkdwn", Text: buf.String()}})
+	}
+
+	return &Message{
+		Text:   r.Title(),
+		Blocks: blocks,
+		Attachments: []*Attachment{
+			{Color: r.Color(), Blocks: jobs},
+		},
+		Metadata: &Metadata{
+			EventType:    STATUS_EVENT_TYPE,
+			EventPayload: map[string]interface{}{"key": r.Key()},
+		},
+	}
+}
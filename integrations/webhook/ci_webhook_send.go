@@ -0,0 +1,226 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/rande/gitlab-ci-helper/integrations/notifier"
+)
+
+type WebhookSendCommand struct {
+	notifier.StatusOptions
+
+	Ui              cli.Ui
+	Verbose         bool
+	Jobs            bool
+	Url             string
+	Method          string
+	Headers         helper.Paths
+	Template        string
+	Body            string
+	Preset          string
+	Secret          string
+	SignatureHeader string
+}
+
+func (c *WebhookSendCommand) Run(args []string) int {
+
+	settings, err := helper.NewConfig()
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	cmdFlags := flag.NewFlagSet("webhook:send", flag.ContinueOnError)
+	cmdFlags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	c.Headers = make(helper.Paths, 0)
+
+	n := notifier.New(c.Ui)
+	n.Flags(cmdFlags)
+	c.StatusOptions.Flags(cmdFlags)
+
+	cmdFlags.BoolVar(&c.Verbose, "verbose", false, "")
+	cmdFlags.BoolVar(&c.Jobs, "jobs", true, "Retrieve the pipeline jobs")
+	cmdFlags.StringVar(&c.Url, "url", settings.Webhook.Url, "The url receiving the payload")
+	cmdFlags.StringVar(&c.Method, "method", "POST", "The http method")
+	cmdFlags.Var(&c.Headers, "header", "An http header")
+	cmdFlags.StringVar(&c.Template, "template", "", "The template file")
+	cmdFlags.StringVar(&c.Body, "body", "", "The inline template")
+	cmdFlags.StringVar(&c.Preset, "preset", "default", "The template preset")
+	cmdFlags.StringVar(&c.Secret, "secret", settings.Webhook.Secret, "The secret used to sign the payload")
+	cmdFlags.StringVar(&c.SignatureHeader, "signature-header", DEFAULT_SIGNATURE_HEADER, "The signature header")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.Url) == 0 {
+		c.Ui.Error("Error: the url is required")
+
+		return 1
+	}
+
+	tpl, ok := presets[c.Preset]
+
+	if !ok {
+		names := []string{}
+		for name := range presets {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		c.Ui.Error(fmt.Sprintf("Error: invalid preset %s, available presets: %s", c.Preset, strings.Join(names, ", ")))
+
+		return 1
+	}
+
+	switch {
+	case len(c.Body) > 0:
+		tpl = c.Body
+	case len(c.Template) > 0:
+		data, err := ioutil.ReadFile(c.Template)
+
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error: unable to read the template, %s", err.Error()))
+
+			return 1
+		}
+
+		tpl = string(data)
+	case reportPresets[c.Preset] && !c.Jobs:
+		c.Ui.Error(fmt.Sprintf("Error: the %s preset requires the pipeline jobs", c.Preset))
+
+		return 1
+	}
+
+	header := http.Header{}
+
+	for _, h := range c.Headers {
+		name, value, err := ParseHeader(h)
+
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+			return 1
+		}
+
+		header.Add(name, value)
+	}
+
+	payload := notifier.NewPayload(nil)
+
+	if c.Jobs {
+		client := gitlab.NewClient(settings.Gitlab.Host, settings.Gitlab.ApiPath, settings.Gitlab.Token)
+
+		if payload.Report, err = c.Report(client, c.Ui); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+			return 1
+		}
+	}
+
+	body, err := Render(tpl, payload)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	if len(c.Secret) > 0 {
+		header.Set(c.SignatureHeader, Sign(c.Secret, body))
+	}
+
+	if c.Verbose {
+		c.Ui.Output(fmt.Sprintf("Send %s %s\n%s", c.Method, c.Url, body))
+	}
+
+	resp, err := n.Send(&notifier.Notification{
+		Method:  strings.ToUpper(c.Method),
+		Url:     c.Url,
+		Header:  header,
+		Body:    body,
+		Secrets: []string{c.Secret},
+	})
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error: %s", err.Error()))
+
+		return 1
+	}
+
+	if !resp.DryRun {
+		c.Ui.Output(fmt.Sprintf("Webhook sent to %s (status: %d)", c.Url, resp.StatusCode))
+	}
+
+	return 0
+}
+
+func (c *WebhookSendCommand) Synopsis() string {
+	return "Send a templated json payload to a webhook."
+}
+
+func (c *WebhookSendCommand) Help() string {
+	helpText := fmt.Sprintf(`
+Usage: gitlab-ci-helper webhook:send [options]
+
+  Send a json payload to an url, the payload is rendered with a Go template
+  (text/template). Information are retrieved from environment variables.
+
+%s
+    teamsColor          Return the Adaptive Card color of a status
+
+  The presets are:
+    default             The build information, the pipeline status and the jobs
+    teams               An Adaptive Card for a Microsoft Teams incoming webhook
+                          or workflow
+
+  The network errors, the 429 and 5xx responses are retried.
+
+  You can use the -last option to indicate that the current job is the last one.
+
+Options:
+  -url                The url receiving the payload (default: env var WEBHOOK_URL)
+  -method             The http method (default: POST)
+  -header             An http header, can be repeated, ie: "Authorization: Bearer $TOKEN",
+                        the env vars are expanded in the value
+  -preset             The template preset: default or teams (default: default)
+  -template           The template file, used instead of the preset
+  -body               The inline template, used instead of the preset
+  -secret             The secret used to sign the payload (default: env var WEBHOOK_SECRET)
+  -signature-header   The header containing the signature (default: %s)
+  -jobs               Retrieve the pipeline jobs, use -jobs=false if the GitLab
+                        credentials are not available (default: true)
+%s
+%s
+  -verbose            Add verbose information to the output
+
+%s
+Gitlab's credentials are retrieved from environment:
+
+  GITLAB_HOST         The gitlab host
+  GITLAB_TOKEN        The user's token
+  GITLAB_API_PATH     (optional) the api path, default to: "/api/v4"
+
+`, notifier.TemplateHelp, DEFAULT_SIGNATURE_HEADER, notifier.StatusHelpOptions, notifier.HelpOptions, webhookConfiguration)
+
+	return strings.TrimSpace(helpText)
+}
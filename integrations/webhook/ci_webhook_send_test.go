@@ -0,0 +1,142 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WebhookSendCommand_Teams(t *testing.T) {
+	fpProject, err := os.Open("../../fixtures/project.json")
+	assert.NoError(t, err)
+
+	fpJobs, err := os.Open("../../fixtures/pipeline_12_jobs.json")
+	assert.NoError(t, err)
+
+	reqs := []*helper.FakeRequest{
+		{
+			Path:   "/api/v4/projects/3",
+			Method: "GET",
+			Response: &http.Response{
+				Body: fpProject,
+			},
+		},
+		{
+			Path:   "/api/v4/projects/3/pipelines/12/jobs",
+			Method: "GET",
+			Response: &http.Response{
+				Body: fpJobs,
+			},
+		},
+	}
+
+	var body []byte
+	var header http.Header
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+		header = r.Header
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer target.Close()
+
+	envs := map[string]string{
+		"WEBHOOK_URL":        target.URL,
+		"WEBHOOK_SECRET":     "secret",
+		"WEBHOOK_TEST_TOKEN": "token",
+	}
+
+	helper.WrapperTestCommand(reqs, envs, t, func(ts *httptest.Server) {
+		ui := &cli.MockUi{}
+		c := &WebhookSendCommand{
+			Ui: ui,
+		}
+
+		code := c.Run([]string{"-preset", "teams", "-header", "Authorization: Bearer $WEBHOOK_TEST_TOKEN", "-project", "3", "-pipeline", "12", "-ref-name", "master"})
+
+		assert.Equal(t, 0, code, ui.ErrorWriter.String())
+		assert.Contains(t, ui.OutputWriter.String(), "Webhook sent to "+target.URL+" (status: 202)")
+	})
+
+	assert.Contains(t, string(body), `"contentType": "application/vnd.microsoft.card.adaptive"`)
+	assert.Contains(t, string(body), `{"title": "#69 rubocop (test)", "value": "canceled"}`)
+	assert.Equal(t, "Bearer token", header.Get("Authorization"))
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Equal(t, Sign("secret", body), header.Get(DEFAULT_SIGNATURE_HEADER))
+}
+
+func Test_WebhookSendCommand_Body(t *testing.T) {
+	var body []byte
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method)
+
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer target.Close()
+
+	envs := map[string]string{
+		"CI_JOB_ID": "42",
+	}
+
+	helper.WrapperTestCommand([]*helper.FakeRequest{}, envs, t, func(ts *httptest.Server) {
+		ui := &cli.MockUi{}
+		c := &WebhookSendCommand{
+			Ui: ui,
+		}
+
+		code := c.Run([]string{"-url", target.URL, "-method", "put", "-jobs=false", "-body", `{"job": {{ json .Meta.Build.Id }}}`})
+
+		assert.Equal(t, 0, code, ui.ErrorWriter.String())
+	})
+
+	assert.Equal(t, `{"job": "42"}`, string(body))
+}
+
+func Test_WebhookSendCommand_Errors(t *testing.T) {
+	ui := &cli.MockUi{}
+	c := &WebhookSendCommand{
+		Ui: ui,
+	}
+
+	assert.Equal(t, 1, c.Run([]string{}))
+	assert.Contains(t, ui.ErrorWriter.String(), "Error: the url is required")
+
+	assert.Equal(t, 1, c.Run([]string{"-url", "http://localhost", "-preset", "foo"}))
+	assert.Contains(t, ui.ErrorWriter.String(), "Error: invalid preset foo, available presets: default, teams")
+
+	assert.Equal(t, 1, c.Run([]string{"-url", "http://localhost", "-preset", "teams", "-jobs=false"}))
+	assert.Contains(t, ui.ErrorWriter.String(), "Error: the teams preset requires the pipeline jobs")
+
+	assert.Equal(t, 1, c.Run([]string{"-url", "http://localhost", "-jobs=false", "-header", "foo"}))
+	assert.Contains(t, ui.ErrorWriter.String(), "Error: invalid header")
+}
+
+func Test_WebhookSendCommand_Help(t *testing.T) {
+	c := &WebhookSendCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.True(t, len(c.Help()) > 0)
+	assert.True(t, len(c.Synopsis()) > 0)
+}
+
+func Test_WebhookSendCommand_InvalidRun(t *testing.T) {
+	c := &WebhookSendCommand{
+		Ui: &cli.MockUi{},
+	}
+
+	assert.Equal(t, 1, c.Run([]string{"--foobar"}))
+}
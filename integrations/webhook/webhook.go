@@ -0,0 +1,135 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rande/gitlab-ci-helper/integrations/notifier"
+)
+
+const DEFAULT_SIGNATURE_HEADER = "X-Signature-256"
+
+var webhookConfiguration = `
+Configuration:
+
+  The webhook settings are retrieved from the configuration file (webhook
+  section) or from the environment:
+
+  WEBHOOK_URL         The url receiving the payload
+  WEBHOOK_SECRET      The secret used to sign the payload, the signature is
+                        sent as sha256=hex(hmac_sha256(secret, body))
+`
+
+var presets = map[string]string{
+	"default": `{
+    "meta": {{ json .Meta }},
+    "pipeline": {{ if .Report }}{
+        "project": {{ json .Report.Project.PathWithNamespace }},
+        "ref": {{ json .Report.Ref }},
+        "ref_name": {{ json .Report.RefName }},
+        "status": {{ json .Report.Status }},
+        "url": {{ json .Report.Url }},
+        "jobs": [{{ range $i, $j := .Report.Jobs }}{{ if $i }},{{ end }}
+            {"id": {{ $j.Id }}, "name": {{ json $j.Name }}, "stage": {{ json $j.Stage }}, "status": {{ json $j.Status }}, "url": {{ json ($.Report.JobUrl $j) }}}{{ end }}
+        ]
+    }{{ else }}null{{ end }}
+}`,
+
+	// Adaptive Card accepted by the Teams incoming webhooks and workflows
+	"teams": `{
+    "type": "message",
+    "attachments": [{
+        "contentType": "application/vnd.microsoft.card.adaptive",
+        "contentUrl": null,
+        "content": {
+            "$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+            "type": "AdaptiveCard",
+            "version": "1.4",
+            "msteams": {"width": "Full"},
+            "body": [
+                {"type": "TextBlock", "size": "Medium", "weight": "Bolder", "wrap": true, "text": {{ json .Report.Project.NameWithNamespace }}},
+                {"type": "TextBlock", "wrap": true, "weight": "Bolder", "color": {{ json (teamsColor .Report.Status) }}, "text": {{ json (printf "%s: %s" .Report.RefName .Report.Status) }}}{{ if .Report.Commit }},
+                {"type": "FactSet", "facts": [
+                    {"title": "Author", "value": {{ json .Report.Commit.AuthorName }}},
+                    {"title": "Commit", "value": {{ json (printf "%s (%s)" .Report.Commit.Title .Report.Commit.ShortId) }}}
+                ]}{{ end }},
+                {"type": "FactSet", "separator": true, "facts": [{{ range $i, $j := .Report.Jobs }}{{ if $i }},{{ end }}
+                    {"title": {{ json (printf "#%d %s (%s)" $j.Id $j.Name $j.Stage) }}, "value": {{ json $j.Status }}}{{ end }}
+                ]}
+            ],
+            "actions": [
+                {"type": "Action.OpenUrl", "title": "View pipeline", "url": {{ json .Report.Url }}}
+            ]
+        }
+    }]
+}`,
+}
+
+// presets requiring the pipeline jobs
+var reportPresets = map[string]bool{
+	"teams": true,
+}
+
+var teamsColors = map[string]string{
+	"success":  "Good",
+	"running":  "Warning",
+	"failed":   "Attention",
+	"canceled": "Default",
+}
+
+var funcs = map[string]interface{}{
+	"teamsColor": func(status string) string {
+		if color, ok := teamsColors[status]; ok {
+			return color
+		}
+
+		return "Default"
+	},
+}
+
+// Render executes the template and checks the result is a valid json
+// document.
+func Render(tpl string, payload *notifier.Payload) ([]byte, error) {
+	body, err := notifier.Render(tpl, payload, funcs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !json.Valid([]byte(body)) {
+		return nil, errors.New("the rendered body is not a valid json document")
+	}
+
+	return []byte(body), nil
+}
+
+// Sign returns the HMAC-SHA256 signature of the body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ParseHeader parses a "Name: value" header, the env vars are expanded in the
+// value so the secrets do not appear in the command line.
+func ParseHeader(header string) (string, string, error) {
+	parts := strings.SplitN(header, ":", 2)
+
+	if len(parts) != 2 || len(strings.TrimSpace(parts[0])) == 0 {
+		return "", "", fmt.Errorf("invalid header %q, the format is: Name: value", header)
+	}
+
+	return strings.TrimSpace(parts[0]), os.ExpandEnv(strings.TrimSpace(parts[1])), nil
+}
@@ -0,0 +1,98 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	helper "github.com/rande/gitlab-ci-helper"
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/rande/gitlab-ci-helper/integrations/notifier"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPayload() *notifier.Payload {
+	project := &gitlab.Project{Id: 3, WebUrl: "http://example.com/foo/bar", NameWithNamespace: "Foo / Bar", PathWithNamespace: "foo/bar"}
+
+	return &notifier.Payload{
+		Meta: &helper.Meta{Build: &helper.MetaBuild{Id: "42", RefName: "master"}},
+		Report: notifier.NewStatusReport(project, "sha", "master", "12", []*gitlab.Job{
+			{Id: 1, Name: "test \"unit\"", Stage: "test", Status: "failed", Commit: &gitlab.Commit{AuthorName: "Thomas", Title: "Fix", ShortId: "abc"}},
+			{Id: 2, Name: "package", Stage: "build", Status: "success"},
+		}),
+	}
+}
+
+func Test_Render_Presets(t *testing.T) {
+	body, err := Render(presets["default"], newPayload())
+
+	assert.NoError(t, err)
+
+	data := map[string]interface{}{}
+
+	assert.NoError(t, json.Unmarshal(body, &data))
+	assert.Equal(t, "42", data["meta"].(map[string]interface{})["build"].(map[string]interface{})["id"])
+
+	pipeline := data["pipeline"].(map[string]interface{})
+
+	assert.Equal(t, "failed", pipeline["status"])
+	assert.Equal(t, "http://example.com/foo/bar/-/pipelines/12", pipeline["url"])
+	assert.Equal(t, "test \"unit\"", pipeline["jobs"].([]interface{})[0].(map[string]interface{})["name"])
+
+	body, err = Render(presets["default"], notifier.NewPayload(nil))
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"pipeline": null`)
+
+	body, err = Render(presets["teams"], newPayload())
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"color": "Attention", "text": "master: failed"`)
+	assert.Contains(t, string(body), `{"title": "#2 package (build)", "value": "success"}`)
+	assert.True(t, json.Valid(body))
+}
+
+func Test_Render_Errors(t *testing.T) {
+	_, err := Render(`{"status": {{ .Report.Status }}}`, newPayload())
+
+	assert.EqualError(t, err, "the rendered body is not a valid json document")
+
+	_, err = Render(`{{ .Foo`, newPayload())
+
+	assert.Error(t, err)
+}
+
+func Test_Render_Env(t *testing.T) {
+	os.Setenv("WEBHOOK_TEST_VALUE", "foo")
+	defer os.Unsetenv("WEBHOOK_TEST_VALUE")
+
+	body, err := Render(`{"value": {{ json (env "WEBHOOK_TEST_VALUE") }}}`, newPayload())
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"value": "foo"}`, string(body))
+}
+
+func Test_Sign(t *testing.T) {
+	// echo -n '{"foo":"bar"}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "sha256=3f3ab3986b656abb17af3eb1443ed6c08ef8fff9fea83915909d1b421aec89be", Sign("secret", []byte(`{"foo":"bar"}`)))
+}
+
+func Test_ParseHeader(t *testing.T) {
+	os.Setenv("WEBHOOK_TEST_TOKEN", "token")
+	defer os.Unsetenv("WEBHOOK_TEST_TOKEN")
+
+	name, value, err := ParseHeader("Authorization: Bearer $WEBHOOK_TEST_TOKEN")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Authorization", name)
+	assert.Equal(t, "Bearer token", value)
+
+	_, _, err = ParseHeader("Authorization")
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,168 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+)
+
+type ManifestFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+// Manifest contains the checksum of an archive and of every file stored
+// in the archive.
+type Manifest struct {
+	Sha256 string          `json:"sha256"`
+	Size   int64           `json:"size"`
+	Files  []*ManifestFile `json:"files"`
+}
+
+type ErrChecksumMismatch struct {
+	Name     string
+	Expected string
+	Actual   string
+}
+
+func (e *ErrChecksumMismatch) Error() string {
+	return fmt.Sprintf("Checksum mismatch for %s, expected: %s, actual: %s", e.Name, e.Expected, e.Actual)
+}
+
+// Verify compares the manifest with the expected one, the archive checksum and
+// the files are only compared if the expected manifest contains them.
+func (m *Manifest) Verify(expected *Manifest) error {
+	if len(expected.Sha256) > 0 && expected.Sha256 != m.Sha256 {
+		return &ErrChecksumMismatch{Name: "archive", Expected: expected.Sha256, Actual: m.Sha256}
+	}
+
+	files := map[string]*ManifestFile{}
+	for _, f := range m.Files {
+		files[f.Name] = f
+	}
+
+	for _, f := range expected.Files {
+		actual, ok := files[f.Name]
+
+		if !ok {
+			return &ErrChecksumMismatch{Name: f.Name, Expected: f.Sha256, Actual: "missing file"}
+		}
+
+		if actual.Sha256 != f.Sha256 {
+			return &ErrChecksumMismatch{Name: f.Name, Expected: f.Sha256, Actual: actual.Sha256}
+		}
+	}
+
+	if expected.Files != nil && len(m.Files) != len(expected.Files) {
+		return fmt.Errorf("Checksum mismatch, expected %d files, actual: %d", len(expected.Files), len(m.Files))
+	}
+
+	return nil
+}
+
+// NewZipManifest computes the checksum of the zip file and of every file
+// stored in it, a truncated or corrupted archive returns an error.
+func NewZipManifest(archive string) (*Manifest, error) {
+	sum, size, err := Sha256File(archive)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := zip.OpenReader(archive)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+
+	manifest := &Manifest{
+		Sha256: sum,
+		Size:   size,
+		Files:  []*ManifestFile{},
+	}
+
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := f.Open()
+
+		if err != nil {
+			return nil, err
+		}
+
+		h := sha256.New()
+
+		// the zip reader validates the crc32 once the file is fully read
+		size, err := io.Copy(h, rc)
+		rc.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read %s, %s", f.Name, err)
+		}
+
+		manifest.Files = append(manifest.Files, &ManifestFile{
+			Name:   f.Name,
+			Size:   size,
+			Sha256: hex.EncodeToString(h.Sum(nil)),
+		})
+	}
+
+	return manifest, nil
+}
+
+func Sha256File(path string) (string, int64, error) {
+	fp, err := os.Open(path)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	defer fp.Close()
+
+	h := sha256.New()
+
+	size, err := io.Copy(h, fp)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// ChecksumWriter computes the sha256 checksum and the size of the data
+// written into it.
+type ChecksumWriter struct {
+	Size int64
+
+	h hash.Hash
+}
+
+func NewChecksumWriter() *ChecksumWriter {
+	return &ChecksumWriter{h: sha256.New()}
+}
+
+func (w *ChecksumWriter) Write(p []byte) (int, error) {
+	n, err := w.h.Write(p)
+	w.Size += int64(n)
+
+	return n, err
+}
+
+func (w *ChecksumWriter) Sum() string {
+	return hex.EncodeToString(w.h.Sum(nil))
+}
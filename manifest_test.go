@@ -0,0 +1,91 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewZipManifest(t *testing.T) {
+	targetPath := fmt.Sprintf("%s/gitlab_ci_helper_manifest.zip", os.TempDir())
+
+	defer os.Remove(targetPath)
+
+	includePath := make(Paths, 0)
+	includePath.Set("README.md")
+	includePath.Set("zip.go")
+
+	fp, err := os.Create(targetPath)
+	assert.NoError(t, err)
+
+	streamed := &Manifest{}
+	checksum := NewChecksumWriter()
+
+	err = ZipStream(includePath, nil, io.MultiWriter(fp, checksum), streamed)
+	assert.NoError(t, err)
+	fp.Close()
+
+	manifest, err := NewZipManifest(targetPath)
+	assert.NoError(t, err)
+
+	assert.Equal(t, checksum.Sum(), manifest.Sha256)
+	assert.Equal(t, checksum.Size, manifest.Size)
+	assert.Len(t, manifest.Files, 2)
+	assert.Equal(t, "README.md", manifest.Files[0].Name)
+
+	sum, _, err := Sha256File("README.md")
+	assert.NoError(t, err)
+	assert.Equal(t, sum, manifest.Files[0].Sha256)
+
+	// the manifest generated while streaming must match the archive
+	streamed.Sha256 = checksum.Sum()
+	assert.NoError(t, manifest.Verify(streamed))
+}
+
+func Test_NewZipManifest_Corrupted(t *testing.T) {
+	fp, err := ioutil.TempFile("", "gitlab_ci_helper_manifest")
+	assert.NoError(t, err)
+
+	defer os.Remove(fp.Name())
+
+	fp.Write([]byte("not a zip file"))
+	fp.Close()
+
+	_, err = NewZipManifest(fp.Name())
+	assert.Error(t, err)
+}
+
+func Test_Manifest_Verify(t *testing.T) {
+	manifest := &Manifest{
+		Sha256: "abc",
+		Files: []*ManifestFile{
+			{Name: "file1", Sha256: "111"},
+			{Name: "file2", Sha256: "222"},
+		},
+	}
+
+	assert.NoError(t, manifest.Verify(&Manifest{Sha256: "abc"}))
+	assert.NoError(t, manifest.Verify(&Manifest{}))
+
+	err := manifest.Verify(&Manifest{Sha256: "def"})
+	assert.IsType(t, &ErrChecksumMismatch{}, err)
+	assert.Equal(t, "Checksum mismatch for archive, expected: def, actual: abc", err.Error())
+
+	err = manifest.Verify(&Manifest{Files: []*ManifestFile{{Name: "file1", Sha256: "000"}}})
+	assert.Equal(t, "Checksum mismatch for file1, expected: 000, actual: 111", err.Error())
+
+	err = manifest.Verify(&Manifest{Files: []*ManifestFile{{Name: "file3", Sha256: "333"}}})
+	assert.Equal(t, "Checksum mismatch for file3, expected: 333, actual: missing file", err.Error())
+
+	err = manifest.Verify(&Manifest{Files: []*ManifestFile{{Name: "file1", Sha256: "111"}}})
+	assert.Equal(t, "Checksum mismatch, expected 1 files, actual: 2", err.Error())
+}
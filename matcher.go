@@ -0,0 +1,200 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+)
+
+// the exclude rules modes
+const (
+	MATCH_GLOB   = "glob"
+	MATCH_REGEXP = "regexp"
+)
+
+// the default file containing the exclude rules
+const IGNORE_FILE = ".ciarchiveignore"
+
+type matchRule struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+	regexp  *regexp.Regexp
+}
+
+// Matcher excludes files from an archive. In glob mode, the rules follow
+// the .gitignore syntax:
+//
+//   - `**` matches any number of directories: **/node_modules/**
+//   - a leading `!` negates the rule: !dist/keep.txt
+//   - a leading `/` or a `/` in the middle anchors the rule to the current path,
+//     otherwise the rule matches at any depth
+//   - a trailing `/` only matches directories
+//
+// The last matching rule wins. Unlike git, a file can be re-included even if
+// its parent directory is excluded.
+//
+// In regexp mode, each rule is a regular expression matched against the
+// full path, the negation is not supported.
+type Matcher struct {
+	Mode string
+
+	rules    []*matchRule
+	negation bool
+}
+
+func NewMatcher(mode string) (*Matcher, error) {
+	if mode != MATCH_GLOB && mode != MATCH_REGEXP {
+		return nil, fmt.Errorf("Invalid match mode: %s", mode)
+	}
+
+	return &Matcher{Mode: mode, rules: []*matchRule{}}, nil
+}
+
+// Add appends a rule, the rules are evaluated in the order of insertion.
+func (m *Matcher) Add(pattern string) error {
+	if m.Mode == MATCH_REGEXP {
+		r, err := regexp.Compile(pattern)
+
+		if err != nil {
+			return fmt.Errorf("Invalid exclude rule: %s, %s", pattern, err)
+		}
+
+		m.rules = append(m.rules, &matchRule{pattern: pattern, regexp: r})
+
+		return nil
+	}
+
+	rule := &matchRule{}
+
+	pattern = strings.TrimRight(pattern, " ")
+
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	} else if strings.HasPrefix(pattern, "\\!") || strings.HasPrefix(pattern, "\\#") {
+		pattern = pattern[1:]
+	}
+
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+
+	if strings.HasPrefix(pattern, "/") {
+		pattern = strings.TrimLeft(pattern, "/")
+	} else if len(pattern) > 0 && !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+
+	if len(pattern) == 0 {
+		return fmt.Errorf("Invalid exclude rule: empty pattern")
+	}
+
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("Invalid exclude rule: %s, %s", pattern, err)
+		}
+	}
+
+	rule.pattern = pattern
+
+	m.rules = append(m.rules, rule)
+	m.negation = m.negation || rule.negate
+
+	return nil
+}
+
+// AddFile appends the rules stored in the file, one rule per line. Empty
+// lines and lines starting with # are ignored.
+func (m *Matcher) AddFile(file string) error {
+	fp, err := os.Open(file)
+
+	if err != nil {
+		return err
+	}
+
+	defer fp.Close()
+
+	scanner := bufio.NewScanner(fp)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if len(strings.TrimSpace(line)) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if err := m.Add(line); err != nil {
+			return fmt.Errorf("%s (file: %s)", err, file)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// Excluded returns true if the path must not be added to the archive.
+func (m *Matcher) Excluded(path string, isDir bool) bool {
+	if m == nil || len(m.rules) == 0 {
+		return false
+	}
+
+	if m.Mode == MATCH_REGEXP {
+		for _, rule := range m.rules {
+			if rule.regexp.MatchString(path) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	path = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "./")
+
+	if path == "." {
+		return false
+	}
+
+	// the parent directories are evaluated first, so a file inherits the
+	// status of its directory unless a rule matches the file itself
+	excluded := false
+	parts := strings.Split(path, "/")
+
+	for i := range parts {
+		current := strings.Join(parts[:i+1], "/")
+		dir := isDir || i < len(parts)-1
+
+		for _, rule := range m.rules {
+			if rule.dirOnly && !dir {
+				continue
+			}
+
+			if ok, _ := doublestar.Match(rule.pattern, current); ok {
+				excluded = !rule.negate
+			}
+		}
+	}
+
+	return excluded
+}
+
+// Prune returns true if the content of the excluded directory can be skipped,
+// ie: no rule can re-include a file.
+func (m *Matcher) Prune(path string) bool {
+	if m == nil || m.Mode != MATCH_GLOB || m.negation {
+		return false
+	}
+
+	return m.Excluded(path, true)
+}
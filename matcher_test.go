@@ -0,0 +1,122 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Matcher_Glob(t *testing.T) {
+	m, err := NewMatcher(MATCH_GLOB)
+	assert.NoError(t, err)
+
+	for _, rule := range []string{"**/node_modules/**", "dist/**", "!dist/keep.txt", "/build", "*.log", "cache/"} {
+		assert.NoError(t, m.Add(rule))
+	}
+
+	cases := []struct {
+		Path     string
+		Dir      bool
+		Excluded bool
+	}{
+		{"src/app.js", false, false},
+		{"node_modules/lib/index.js", false, true},
+		{"src/node_modules/lib/index.js", false, true},
+		{"./dist/app.js", false, true},
+		{"dist/keep.txt", false, false},
+		{"build", true, true},
+		{"build/app", false, true},
+		{"src/build", true, false},
+		{"app.log", false, true},
+		{"var/log/app.log", false, true},
+		{"var/cache", true, true},
+		{"var/cache/data", false, true},
+		{"var/cache", false, false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.Excluded, m.Excluded(c.Path, c.Dir), c.Path)
+	}
+
+	// a negated rule can re-include a file, so excluded directories are walked
+	assert.False(t, m.Prune("dist"))
+}
+
+func Test_Matcher_Prune(t *testing.T) {
+	m, _ := NewMatcher(MATCH_GLOB)
+	m.Add("node_modules/")
+
+	assert.True(t, m.Prune("src/node_modules"))
+	assert.False(t, m.Prune("src"))
+
+	var empty *Matcher
+	assert.False(t, empty.Excluded("src", true))
+	assert.False(t, empty.Prune("src"))
+}
+
+func Test_Matcher_Regexp(t *testing.T) {
+	m, err := NewMatcher(MATCH_REGEXP)
+	assert.NoError(t, err)
+
+	assert.NoError(t, m.Add("\\.git/"))
+	assert.Error(t, m.Add("[a-"))
+
+	assert.True(t, m.Excluded("./.git/HEAD", false))
+	assert.False(t, m.Excluded("./.gitignore", false))
+	assert.False(t, m.Prune(".git/"))
+}
+
+func Test_Matcher_Invalid(t *testing.T) {
+	_, err := NewMatcher("foo")
+	assert.Error(t, err)
+
+	m, _ := NewMatcher(MATCH_GLOB)
+	assert.Error(t, m.Add("[a-"))
+	assert.Error(t, m.Add("!"))
+}
+
+func Test_Matcher_AddFile(t *testing.T) {
+	fp, err := ioutil.TempFile("", "gitlab_ci_helper_ignore")
+	assert.NoError(t, err)
+
+	defer os.Remove(fp.Name())
+
+	fp.WriteString("# comment\n\n*.log\n!keep.log\n")
+	fp.Close()
+
+	m, _ := NewMatcher(MATCH_GLOB)
+	assert.NoError(t, m.AddFile(fp.Name()))
+
+	assert.True(t, m.Excluded("app.log", false))
+	assert.False(t, m.Excluded("keep.log", false))
+	assert.False(t, m.Excluded("app.txt", false))
+
+	assert.Error(t, m.AddFile("/path/not/found"))
+}
+
+func Test_ListFiles_Glob_Include(t *testing.T) {
+	includes := make(Paths, 0)
+	includes.Set("fixtures/*.json")
+
+	excludes, _ := NewMatcher(MATCH_GLOB)
+	excludes.Add("pipeline_*.json")
+
+	files, err := listFiles(includes, excludes)
+	assert.NoError(t, err)
+	assert.Contains(t, files, "fixtures/project.json")
+	assert.NotContains(t, files, "fixtures/pipeline_47_jobs.json")
+	assert.NotContains(t, files, "fixtures/artifacts.zip")
+
+	includes = make(Paths, 0)
+	includes.Set("fixtures/*.txt")
+
+	_, err = listFiles(includes, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,317 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rande/gitlab-ci-helper/gitlab"
+)
+
+// META_VERSION is the version of the Meta schema, it is increased when a
+// field is renamed or removed. The version 1 only contained the build, the
+// project and the server sections.
+const META_VERSION = 2
+
+// the sha used by GitLab as the before sha of a new branch
+const nullSha = "0000000000000000000000000000000000000000"
+
+type MetaBuild struct {
+	Id      string `json:"id" yaml:"id"`
+	Ref     string `json:"ref" yaml:"ref"`
+	RefName string `json:"ref_name" yaml:"ref_name"`
+	Tag     string `json:"tag" yaml:"tag"`
+	Stage   string `json:"stage" yaml:"stage"`
+	JobName string `json:"job_name" yaml:"job_name"`
+}
+
+type MetaProject struct {
+	Id  string `json:"id" yaml:"id"`
+	Dir string `json:"dir" yaml:"dir"`
+}
+
+type MetaServer struct {
+	Name     string `json:"name" yaml:"name"`
+	Revision string `json:"revision" yaml:"revision"`
+	Version  string `json:"version" yaml:"version"`
+}
+
+type MetaPipeline struct {
+	Id        string `json:"id" yaml:"id"`
+	Iid       string `json:"iid" yaml:"iid"`
+	Source    string `json:"source" yaml:"source"`
+	Url       string `json:"url" yaml:"url"`
+	CreatedAt string `json:"created_at" yaml:"created_at"`
+}
+
+type MetaRunner struct {
+	Id          string   `json:"id" yaml:"id"`
+	Description string   `json:"description" yaml:"description"`
+	Tags        []string `json:"tags" yaml:"tags"`
+}
+
+type MetaEnvironment struct {
+	Name string `json:"name" yaml:"name"`
+	Slug string `json:"slug" yaml:"slug"`
+	Url  string `json:"url" yaml:"url"`
+	Tier string `json:"tier" yaml:"tier"`
+}
+
+type MetaMergeRequest struct {
+	Iid          string `json:"iid" yaml:"iid"`
+	Title        string `json:"title" yaml:"title"`
+	SourceBranch string `json:"source_branch" yaml:"source_branch"`
+	TargetBranch string `json:"target_branch" yaml:"target_branch"`
+	DiffBaseSha  string `json:"diff_base_sha" yaml:"diff_base_sha"`
+	Url          string `json:"url" yaml:"url"`
+}
+
+type MetaCommit struct {
+	Sha       string `json:"sha" yaml:"sha"`
+	ShortSha  string `json:"short_sha" yaml:"short_sha"`
+	BeforeSha string `json:"before_sha" yaml:"before_sha"`
+	Title     string `json:"title" yaml:"title"`
+	Message   string `json:"message" yaml:"message"`
+	// the author with the git format: name <email>
+	Author    string `json:"author" yaml:"author"`
+	Timestamp string `json:"timestamp" yaml:"timestamp"`
+	// the changed files are only available with the API
+	ChangedFiles []string `json:"changed_files" yaml:"changed_files"`
+}
+
+// Meta contains the build information dumped by the ci:meta command, it is
+// also available in the webhook templates. The environment and the merge
+// request sections are nil if the job has no environment or does not run in
+// a merge request pipeline.
+type Meta struct {
+	Version      int               `json:"version" yaml:"version"`
+	Build        *MetaBuild        `json:"build" yaml:"build"`
+	Project      *MetaProject      `json:"project" yaml:"project"`
+	Server       *MetaServer       `json:"server" yaml:"server"`
+	Pipeline     *MetaPipeline     `json:"pipeline" yaml:"pipeline"`
+	Runner       *MetaRunner       `json:"runner" yaml:"runner"`
+	Environment  *MetaEnvironment  `json:"environment" yaml:"environment"`
+	MergeRequest *MetaMergeRequest `json:"merge_request" yaml:"merge_request"`
+	Commit       *MetaCommit       `json:"commit" yaml:"commit"`
+}
+
+// NewMeta retrieves the build information from the GitLab CI env vars.
+func NewMeta() *Meta {
+	m := &Meta{
+		Version: META_VERSION,
+		Build: &MetaBuild{
+			Id:      GetEnv("CI_JOB_ID", os.Getenv("CI_BUILD_ID")),
+			Ref:     GetEnv("CI_COMMIT_SHA", os.Getenv("CI_BUILD_REF")),
+			RefName: GetEnv("CI_COMMIT_REF_NAME", os.Getenv("CI_BUILD_REF_NAME")),
+			Tag:     GetEnv("CI_COMMIT_TAG", os.Getenv("CI_BUILD_TAG")),
+			Stage:   GetEnv("CI_JOB_STAGE", os.Getenv("CI_BUILD_STAGE")),
+			JobName: GetEnv("CI_JOB_NAME", os.Getenv("CI_BUILD_NAME")),
+		},
+		Project: &MetaProject{
+			Id:  os.Getenv("CI_PROJECT_ID"),
+			Dir: os.Getenv("CI_PROJECT_DIR"),
+		},
+		Server: &MetaServer{
+			Name:     os.Getenv("CI_SERVER_NAME"),
+			Revision: os.Getenv("CI_SERVER_REVISION"),
+			Version:  os.Getenv("CI_SERVER_VERSION"),
+		},
+		Pipeline: &MetaPipeline{
+			Id:        os.Getenv("CI_PIPELINE_ID"),
+			Iid:       os.Getenv("CI_PIPELINE_IID"),
+			Source:    os.Getenv("CI_PIPELINE_SOURCE"),
+			Url:       os.Getenv("CI_PIPELINE_URL"),
+			CreatedAt: os.Getenv("CI_PIPELINE_CREATED_AT"),
+		},
+		Runner: &MetaRunner{
+			Id:          os.Getenv("CI_RUNNER_ID"),
+			Description: os.Getenv("CI_RUNNER_DESCRIPTION"),
+			Tags:        parseRunnerTags(os.Getenv("CI_RUNNER_TAGS")),
+		},
+		Commit: &MetaCommit{
+			Sha:          GetEnv("CI_COMMIT_SHA", os.Getenv("CI_BUILD_REF")),
+			ShortSha:     os.Getenv("CI_COMMIT_SHORT_SHA"),
+			BeforeSha:    GetEnv("CI_COMMIT_BEFORE_SHA", os.Getenv("CI_BUILD_BEFORE_SHA")),
+			Title:        os.Getenv("CI_COMMIT_TITLE"),
+			Message:      os.Getenv("CI_COMMIT_MESSAGE"),
+			Author:       os.Getenv("CI_COMMIT_AUTHOR"),
+			Timestamp:    os.Getenv("CI_COMMIT_TIMESTAMP"),
+			ChangedFiles: []string{},
+		},
+	}
+
+	// the short sha is only available since GitLab 11.7
+	if len(m.Commit.ShortSha) == 0 && len(m.Commit.Sha) >= 8 {
+		m.Commit.ShortSha = m.Commit.Sha[:8]
+	}
+
+	if name := os.Getenv("CI_ENVIRONMENT_NAME"); len(name) > 0 {
+		m.Environment = &MetaEnvironment{
+			Name: name,
+			Slug: os.Getenv("CI_ENVIRONMENT_SLUG"),
+			Url:  os.Getenv("CI_ENVIRONMENT_URL"),
+			Tier: os.Getenv("CI_ENVIRONMENT_TIER"),
+		}
+	}
+
+	if iid := os.Getenv("CI_MERGE_REQUEST_IID"); len(iid) > 0 {
+		m.MergeRequest = &MetaMergeRequest{
+			Iid:          iid,
+			Title:        os.Getenv("CI_MERGE_REQUEST_TITLE"),
+			SourceBranch: os.Getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME"),
+			TargetBranch: os.Getenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME"),
+			DiffBaseSha:  os.Getenv("CI_MERGE_REQUEST_DIFF_BASE_SHA"),
+		}
+
+		if url := os.Getenv("CI_MERGE_REQUEST_PROJECT_URL"); len(url) > 0 {
+			m.MergeRequest.Url = fmt.Sprintf("%s/-/merge_requests/%s", url, iid)
+		}
+	}
+
+	return m
+}
+
+// parseRunnerTags reads the CI_RUNNER_TAGS variable, a json array since
+// GitLab 14.x, a comma separated list before.
+func parseRunnerTags(value string) []string {
+	tags := []string{}
+
+	if strings.HasPrefix(value, "[") && json.Unmarshal([]byte(value), &tags) == nil {
+		return tags
+	}
+
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); len(tag) > 0 {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// Enrich completes the information missing from the env vars with the API,
+// the values from the env vars are kept. The changed files are retrieved from
+// the merge request base, from the previous pushed commit or from the commit
+// itself, in this order.
+func (m *Meta) Enrich(project *gitlab.Project, client gitlab.Client) error {
+	if len(m.Commit.Sha) > 0 {
+		commit, err := client.Commit(project.Id, m.Commit.Sha)
+
+		if err != nil {
+			return fmt.Errorf("Unable to retrieve the commit %s, err: %s", m.Commit.Sha, err)
+		}
+
+		setDefault(&m.Commit.ShortSha, commit.ShortId)
+		setDefault(&m.Commit.Title, commit.Title)
+		setDefault(&m.Commit.Message, commit.Message)
+		setDefault(&m.Commit.Author, fmt.Sprintf("%s <%s>", commit.AuthorName, commit.AuthorEmail))
+		setDefault(&m.Commit.Timestamp, commit.CommittedDate)
+
+		if m.Commit.ChangedFiles, err = m.changedFiles(project, client); err != nil {
+			return fmt.Errorf("Unable to retrieve the changed files, err: %s", err)
+		}
+	}
+
+	if id, err := strconv.Atoi(m.Pipeline.Id); err == nil {
+		pipeline, err := client.Pipeline(project.Id, id)
+
+		if err != nil {
+			return fmt.Errorf("Unable to retrieve the pipeline %d, err: %s", id, err)
+		}
+
+		if pipeline.Iid > 0 {
+			setDefault(&m.Pipeline.Iid, strconv.Itoa(pipeline.Iid))
+		}
+
+		setDefault(&m.Pipeline.Source, pipeline.Source)
+		setDefault(&m.Pipeline.Url, pipeline.WebUrl)
+		setDefault(&m.Pipeline.CreatedAt, pipeline.CreatedAt)
+	}
+
+	if id, err := strconv.Atoi(m.Build.Id); err == nil && len(m.Runner.Id) == 0 {
+		job, err := client.Job(project.Id, id)
+
+		if err != nil {
+			return fmt.Errorf("Unable to retrieve the job %d, err: %s", id, err)
+		}
+
+		if job.Runner != nil {
+			m.Runner.Id = strconv.Itoa(job.Runner.Id)
+			m.Runner.Description = job.Runner.Description
+			m.Runner.Tags = append([]string{}, job.Runner.TagList...)
+		}
+	}
+
+	if m.MergeRequest != nil {
+		iid, err := strconv.Atoi(m.MergeRequest.Iid)
+
+		if err != nil {
+			return fmt.Errorf("Invalid merge request iid: %s", m.MergeRequest.Iid)
+		}
+
+		mr, err := client.MergeRequest(project.Id, iid)
+
+		if err != nil {
+			return fmt.Errorf("Unable to retrieve the merge request %d, err: %s", iid, err)
+		}
+
+		setDefault(&m.MergeRequest.Title, mr.Title)
+		setDefault(&m.MergeRequest.SourceBranch, mr.SourceBranch)
+		setDefault(&m.MergeRequest.TargetBranch, mr.TargetBranch)
+		setDefault(&m.MergeRequest.Url, mr.WebUrl)
+	}
+
+	return nil
+}
+
+func (m *Meta) changedFiles(project *gitlab.Project, client gitlab.Client) ([]string, error) {
+	from := m.Commit.BeforeSha
+	if m.MergeRequest != nil {
+		from = m.MergeRequest.DiffBaseSha
+	}
+
+	diffs := []*gitlab.Diff{}
+
+	if len(from) > 0 && from != nullSha {
+		comparison, err := client.Compare(project.Id, from, m.Commit.Sha)
+
+		if err != nil {
+			return nil, err
+		}
+
+		diffs = comparison.Diffs
+	} else {
+		opts := &gitlab.ListOptions{Page: 1, PerPage: 100}
+
+		for opts.Page > 0 {
+			pageDiffs, resp, err := client.CommitDiff(project.Id, m.Commit.Sha, opts)
+
+			if err != nil {
+				return nil, err
+			}
+
+			diffs = append(diffs, pageDiffs...)
+			opts.Page = resp.NextPage
+		}
+	}
+
+	files := []string{}
+	for _, d := range diffs {
+		files = append(files, d.NewPath)
+	}
+
+	return files, nil
+}
+
+func setDefault(field *string, value string) {
+	if len(*field) == 0 {
+		*field = value
+	}
+}
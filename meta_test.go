@@ -0,0 +1,95 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"testing"
+
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeMetaClient struct {
+	gitlab.Client
+
+	compared []string
+}
+
+func (f *fakeMetaClient) Commit(projectId int, sha string) (*gitlab.Commit, error) {
+	return &gitlab.Commit{Id: sha, ShortId: "4f0ac8c2", Title: "Fix the build", AuthorName: "Thomas", AuthorEmail: "thomas@example.com", CommittedDate: "2017-08-01T10:00:00Z"}, nil
+}
+
+func (f *fakeMetaClient) Compare(projectId int, from, to string) (*gitlab.Comparison, error) {
+	f.compared = []string{from, to}
+
+	return &gitlab.Comparison{Diffs: []*gitlab.Diff{{NewPath: "README.md"}, {NewPath: "meta.go"}}}, nil
+}
+
+func (f *fakeMetaClient) CommitDiff(projectId int, sha string, opts *gitlab.ListOptions) ([]*gitlab.Diff, *gitlab.Response, error) {
+	return []*gitlab.Diff{{NewPath: "meta.go"}}, &gitlab.Response{}, nil
+}
+
+func (f *fakeMetaClient) Pipeline(projectId, pipelineId int) (*gitlab.Pipeline, error) {
+	return &gitlab.Pipeline{Id: pipelineId, Iid: 4, Source: "merge_request_event", WebUrl: "http://example.com/pipelines/12"}, nil
+}
+
+func (f *fakeMetaClient) Job(projectId, jobId int) (*gitlab.Job, error) {
+	return &gitlab.Job{Id: jobId, Runner: &gitlab.Runner{Id: 7, Description: "shared", TagList: []string{"docker"}}}, nil
+}
+
+func (f *fakeMetaClient) MergeRequest(projectId, iid int) (*gitlab.MergeRequest, error) {
+	return &gitlab.MergeRequest{Iid: iid, Title: "Add the meta", TargetBranch: "master", WebUrl: "http://example.com/merge_requests/2"}, nil
+}
+
+func Test_Meta_Enrich(t *testing.T) {
+	m := &Meta{
+		Build:        &MetaBuild{Id: "69"},
+		Pipeline:     &MetaPipeline{Id: "12", Source: "push"},
+		Runner:       &MetaRunner{Tags: []string{}},
+		MergeRequest: &MetaMergeRequest{Iid: "2", DiffBaseSha: "base"},
+		Commit:       &MetaCommit{Sha: "4f0ac8c2a1", BeforeSha: "before", Title: "From the env"},
+	}
+
+	client := &fakeMetaClient{}
+
+	assert.NoError(t, m.Enrich(&gitlab.Project{Id: 3}, client))
+
+	// the env vars are kept
+	assert.Equal(t, "push", m.Pipeline.Source)
+	assert.Equal(t, "From the env", m.Commit.Title)
+
+	assert.Equal(t, "4", m.Pipeline.Iid)
+	assert.Equal(t, "Thomas <thomas@example.com>", m.Commit.Author)
+	assert.Equal(t, "2017-08-01T10:00:00Z", m.Commit.Timestamp)
+	assert.Equal(t, &MetaRunner{Id: "7", Description: "shared", Tags: []string{"docker"}}, m.Runner)
+	assert.Equal(t, "master", m.MergeRequest.TargetBranch)
+	assert.Equal(t, "http://example.com/merge_requests/2", m.MergeRequest.Url)
+
+	// the merge request base is used
+	assert.Equal(t, []string{"base", "4f0ac8c2a1"}, client.compared)
+	assert.Equal(t, []string{"README.md", "meta.go"}, m.Commit.ChangedFiles)
+}
+
+func Test_Meta_Enrich_NewBranch(t *testing.T) {
+	m := &Meta{
+		Build:    &MetaBuild{},
+		Pipeline: &MetaPipeline{},
+		Runner:   &MetaRunner{},
+		Commit:   &MetaCommit{Sha: "4f0ac8c2a1", BeforeSha: nullSha},
+	}
+
+	client := &fakeMetaClient{}
+
+	assert.NoError(t, m.Enrich(&gitlab.Project{Id: 3}, client))
+	assert.Nil(t, client.compared)
+	assert.Equal(t, []string{"meta.go"}, m.Commit.ChangedFiles)
+}
+
+func Test_ParseRunnerTags(t *testing.T) {
+	assert.Equal(t, []string{}, parseRunnerTags(""))
+	assert.Equal(t, []string{"docker", "linux"}, parseRunnerTags("docker, linux"))
+	assert.Equal(t, []string{"docker", "linux"}, parseRunnerTags(`["docker", "linux"]`))
+}
@@ -0,0 +1,162 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rande/gitlab-ci-helper/gitlab"
+)
+
+// ErrAmbiguousJob is returned when several jobs match the search criteria
+// and it is not possible to choose one of them.
+type ErrAmbiguousJob struct {
+	Name string
+	Jobs []*gitlab.Job
+}
+
+func (e *ErrAmbiguousJob) Error() string {
+	candidates := []string{}
+	for _, j := range e.Jobs {
+		candidates = append(candidates, fmt.Sprintf("job:%d pipeline:%d status:%s", j.Id, j.PipelineId(), j.Status))
+	}
+
+	return fmt.Sprintf("Several jobs match the name %s, please use the -pipeline or -build option:\n - %s", e.Name, strings.Join(candidates, "\n - "))
+}
+
+// GetPipelineJobs returns all the jobs of the pipeline.
+func GetPipelineJobs(project *gitlab.Project, pipelineId string, client gitlab.Client) ([]*gitlab.Job, error) {
+	id, err := strconv.Atoi(pipelineId)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid pipeline id: %s", pipelineId)
+	}
+
+	jobs := []*gitlab.Job{}
+	opts := &gitlab.ListOptions{Page: 1, PerPage: 100}
+
+	for opts.Page > 0 {
+		pageJobs, resp, err := client.PipelineJobs(project.Id, id, opts)
+
+		if err != nil {
+			return nil, fmt.Errorf("Unable to retrieve the jobs (projectId:%d, pipelineId:%s), err: %s", project.Id, pipelineId, err)
+		}
+
+		jobs = append(jobs, pageJobs...)
+		opts.Page = resp.NextPage
+	}
+
+	// jobs from a pipeline always belong to this pipeline
+	for _, j := range jobs {
+		if j.Pipeline == nil {
+			j.Pipeline = &gitlab.Pipeline{Id: id}
+		}
+	}
+
+	return jobs, nil
+}
+
+// GetCommitJobs returns the jobs of all the pipelines created for the commit.
+func GetCommitJobs(project *gitlab.Project, sha string, client gitlab.Client) ([]*gitlab.Job, error) {
+	jobs := []*gitlab.Job{}
+	opts := &gitlab.PipelineListOptions{Sha: sha, ListOptions: gitlab.ListOptions{Page: 1, PerPage: 100}}
+
+	for opts.Page > 0 {
+		pipelines, resp, err := client.Pipelines(project.Id, opts)
+
+		if err != nil {
+			return nil, fmt.Errorf("Unable to retrieve the jobs (projectId:%d, sha:%s), err: %s", project.Id, sha, err)
+		}
+
+		for _, p := range pipelines {
+			pipelineJobs, err := GetPipelineJobs(project, strconv.Itoa(p.Id), client)
+
+			if err != nil {
+				return nil, err
+			}
+
+			jobs = append(jobs, pipelineJobs...)
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	return jobs, nil
+}
+
+// GetDownstreamPipeline returns the pipeline created by the trigger job, nil
+// if the trigger job has not created the pipeline yet.
+func GetDownstreamPipeline(project *gitlab.Project, pipelineId, name string, client gitlab.Client) (*gitlab.Pipeline, error) {
+	id, err := strconv.Atoi(pipelineId)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid pipeline id: %s", pipelineId)
+	}
+
+	opts := &gitlab.ListOptions{Page: 1, PerPage: 100}
+
+	for opts.Page > 0 {
+		bridges, resp, err := client.PipelineBridges(project.Id, id, opts)
+
+		if err != nil {
+			return nil, fmt.Errorf("Unable to retrieve the trigger jobs (projectId:%d, pipelineId:%s), err: %s", project.Id, pipelineId, err)
+		}
+
+		for _, b := range bridges {
+			if b.Name == name {
+				return b.DownstreamPipeline, nil
+			}
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	return nil, fmt.Errorf("Unable to find the trigger job %s in the pipeline %s", name, pipelineId)
+}
+
+// FindJob returns the job matching the name and the status (an empty status
+// matches any status). When a job has been retried, only the latest attempt is
+// considered. An ErrAmbiguousJob is returned if the matching jobs belong to
+// different pipelines.
+func FindJob(jobs []*gitlab.Job, name, status string) (*gitlab.Job, error) {
+	latest := map[int]*gitlab.Job{}
+
+	for _, j := range jobs {
+		if j.Name != name {
+			continue
+		}
+
+		if lj, ok := latest[j.PipelineId()]; !ok || lj.Id < j.Id {
+			latest[j.PipelineId()] = j
+		}
+	}
+
+	candidates := []*gitlab.Job{}
+	for _, j := range latest {
+		if len(status) > 0 && j.Status != status {
+			continue
+		}
+
+		candidates = append(candidates, j)
+	}
+
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	if len(candidates) > 1 {
+		sort.Slice(candidates, func(i, k int) bool {
+			return candidates[i].Id > candidates[k].Id
+		})
+
+		return nil, &ErrAmbiguousJob{Name: name, Jobs: candidates}
+	}
+
+	return candidates[0], nil
+}
@@ -0,0 +1,231 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package pipeline computes the status of a pipeline from its jobs, with the
+// rules used by GitLab: only the latest attempt of a job is considered, the
+// jobs allowed to fail do not fail the pipeline and the manual jobs do not
+// block it unless they are required.
+package pipeline
+
+import (
+	"sort"
+
+	"github.com/rande/gitlab-ci-helper/gitlab"
+)
+
+const (
+	CREATED              = "created"
+	WAITING_FOR_RESOURCE = "waiting_for_resource"
+	PREPARING            = "preparing"
+	PENDING              = "pending"
+	RUNNING              = "running"
+	SUCCESS              = "success"
+	FAILED               = "failed"
+	CANCELED             = "canceled"
+	SKIPPED              = "skipped"
+	MANUAL               = "manual"
+	SCHEDULED            = "scheduled"
+)
+
+// the ignored jobs are the optional manual jobs, the failed or canceled jobs
+// allowed to fail count as successful with warnings
+const ignored = "ignored"
+
+type Stage struct {
+	Name   string
+	Status string
+
+	// the stage has passed but a job allowed to fail has failed
+	Warnings bool
+	Jobs     []*gitlab.Job
+}
+
+type Status struct {
+	Status   string
+	Warnings bool
+	Stages   []*Stage
+	Jobs     []*gitlab.Job
+}
+
+// IsFinished returns true if no job can change the status anymore, a
+// pipeline blocked by a manual job is finished.
+func (s *Status) IsFinished() bool {
+	return IsFinished(s.Status)
+}
+
+// IsSuccessful returns true if the pipeline has passed, the warnings are
+// accepted.
+func (s *Status) IsSuccessful() bool {
+	return s.Status == SUCCESS
+}
+
+// Stage returns the stage with the name, nil if not found.
+func (s *Status) Stage(name string) *Stage {
+	for _, st := range s.Stages {
+		if st.Name == name {
+			return st
+		}
+	}
+
+	return nil
+}
+
+// Finish marks the job as successful and computes the status again, it is
+// used by the reporting job which is still running.
+func (s *Status) Finish(name string) {
+	for _, j := range s.Jobs {
+		if j.Name == name && j.Status == RUNNING {
+			j.Status = SUCCESS
+		}
+	}
+
+	*s = *Aggregate(s.Jobs)
+}
+
+// IsFinished returns true if the status is final.
+func IsFinished(status string) bool {
+	switch status {
+	case SUCCESS, FAILED, CANCELED, SKIPPED, MANUAL:
+		return true
+	}
+
+	return false
+}
+
+// Latest returns the latest attempt of each job, sorted by id.
+func Latest(jobs []*gitlab.Job) []*gitlab.Job {
+	latest := map[string]*gitlab.Job{}
+
+	for _, j := range jobs {
+		if lj, ok := latest[j.Name]; !ok || lj.Id < j.Id {
+			latest[j.Name] = j
+		}
+	}
+
+	active := []*gitlab.Job{}
+	for _, j := range latest {
+		active = append(active, j)
+	}
+
+	sort.Slice(active, func(i, k int) bool {
+		return active[i].Id < active[k].Id
+	})
+
+	return active
+}
+
+// Aggregate computes the status of the pipeline and of each stage from the
+// latest attempt of the jobs. The stages are sorted by their first job, the
+// API does not return the stage position.
+func Aggregate(jobs []*gitlab.Job) *Status {
+	s := &Status{
+		Jobs:   Latest(jobs),
+		Stages: []*Stage{},
+	}
+
+	for _, j := range s.Jobs {
+		st := s.Stage(j.Stage)
+
+		if st == nil {
+			st = &Stage{Name: j.Stage}
+			s.Stages = append(s.Stages, st)
+		}
+
+		st.Jobs = append(st.Jobs, j)
+	}
+
+	for _, st := range s.Stages {
+		st.Status, st.Warnings = composite(st.Jobs)
+	}
+
+	s.Status, s.Warnings = composite(s.Jobs)
+
+	return s
+}
+
+// composite is a port of the GitLab composite status, the first matching
+// rule wins.
+func composite(jobs []*gitlab.Job) (string, bool) {
+	if len(jobs) == 0 {
+		return PENDING, false
+	}
+
+	statuses := map[string]bool{}
+	warnings := false
+
+	for _, j := range jobs {
+		status := j.Status
+
+		if j.AllowFailure {
+			switch status {
+			case FAILED, CANCELED:
+				status = SUCCESS
+				warnings = true
+			case MANUAL:
+				status = ignored
+			}
+		}
+
+		statuses[status] = true
+	}
+
+	onlyOf := func(allowed ...string) bool {
+		known := map[string]bool{ignored: true}
+		for _, a := range allowed {
+			known[a] = true
+		}
+
+		for status := range statuses {
+			if !known[status] {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	anyOf := func(wanted ...string) bool {
+		for _, w := range wanted {
+			if statuses[w] {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	var status string
+
+	switch {
+	case onlyOf(SKIPPED):
+		status = SKIPPED
+	case onlyOf(SUCCESS, SKIPPED):
+		status = SUCCESS
+	case onlyOf(CREATED):
+		status = CREATED
+	case onlyOf(PREPARING):
+		status = PREPARING
+	case onlyOf(CANCELED, SUCCESS, SKIPPED):
+		status = CANCELED
+	case onlyOf(PENDING, CREATED, SKIPPED):
+		status = PENDING
+	case anyOf(RUNNING, PENDING):
+		status = RUNNING
+	case anyOf(WAITING_FOR_RESOURCE):
+		status = WAITING_FOR_RESOURCE
+	case anyOf(MANUAL):
+		status = MANUAL
+	case anyOf(SCHEDULED):
+		status = SCHEDULED
+	case anyOf(PREPARING):
+		status = PREPARING
+	case anyOf(CREATED):
+		status = RUNNING
+	default:
+		status = FAILED
+	}
+
+	return status, warnings && status == SUCCESS
+}
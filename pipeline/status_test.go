@@ -0,0 +1,152 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/stretchr/testify/assert"
+)
+
+func newJob(id int, name, stage, status string, allowFailure bool) *gitlab.Job {
+	return &gitlab.Job{Id: id, Name: name, Stage: stage, Status: status, AllowFailure: allowFailure}
+}
+
+func Test_Latest(t *testing.T) {
+	jobs := Latest([]*gitlab.Job{
+		newJob(12, "package", "build", SUCCESS, false),
+		newJob(11, "test", "test", SUCCESS, false),
+		newJob(10, "package", "build", FAILED, false),
+	})
+
+	assert.Len(t, jobs, 2)
+	assert.Equal(t, 11, jobs[0].Id)
+	assert.Equal(t, 12, jobs[1].Id)
+}
+
+func Test_Aggregate(t *testing.T) {
+	cases := []struct {
+		name     string
+		jobs     []*gitlab.Job
+		status   string
+		warnings bool
+	}{
+		{"no job", []*gitlab.Job{}, PENDING, false},
+		{"success", []*gitlab.Job{
+			newJob(1, "test", "test", SUCCESS, false),
+			newJob(2, "deploy", "deploy", SKIPPED, false),
+		}, SUCCESS, false},
+		{"retried job", []*gitlab.Job{
+			newJob(1, "test", "test", FAILED, false),
+			newJob(2, "test", "test", SUCCESS, false),
+		}, SUCCESS, false},
+		{"allowed failure", []*gitlab.Job{
+			newJob(1, "test", "test", SUCCESS, false),
+			newJob(2, "lint", "test", FAILED, true),
+		}, SUCCESS, true},
+		{"only allowed failures", []*gitlab.Job{
+			newJob(1, "lint", "test", FAILED, true),
+			newJob(2, "audit", "test", CANCELED, true),
+		}, SUCCESS, true},
+		{"allowed failure and optional manual job", []*gitlab.Job{
+			newJob(1, "lint", "test", FAILED, true),
+			newJob(2, "deploy", "test", MANUAL, true),
+		}, SUCCESS, true},
+		{"failed and canceled", []*gitlab.Job{
+			newJob(1, "test", "test", FAILED, false),
+			newJob(2, "lint", "test", CANCELED, false),
+		}, FAILED, false},
+		{"canceled", []*gitlab.Job{
+			newJob(1, "test", "test", SUCCESS, false),
+			newJob(2, "lint", "test", CANCELED, false),
+		}, CANCELED, false},
+		{"running", []*gitlab.Job{
+			newJob(1, "test", "test", FAILED, false),
+			newJob(2, "lint", "test", RUNNING, false),
+		}, RUNNING, false},
+		{"pending", []*gitlab.Job{
+			newJob(1, "test", "test", PENDING, false),
+			newJob(2, "deploy", "deploy", CREATED, false),
+		}, PENDING, false},
+		{"next stage created", []*gitlab.Job{
+			newJob(1, "test", "test", SUCCESS, false),
+			newJob(2, "deploy", "deploy", CREATED, false),
+		}, RUNNING, false},
+		{"optional manual job", []*gitlab.Job{
+			newJob(1, "test", "test", SUCCESS, false),
+			newJob(2, "deploy", "deploy", MANUAL, true),
+		}, SUCCESS, false},
+		{"blocking manual job", []*gitlab.Job{
+			newJob(1, "test", "test", SUCCESS, false),
+			newJob(2, "deploy", "deploy", MANUAL, false),
+			newJob(3, "notify", "notify", CREATED, false),
+		}, MANUAL, false},
+		{"skipped", []*gitlab.Job{
+			newJob(1, "deploy", "deploy", SKIPPED, false),
+			newJob(2, "notify", "notify", MANUAL, true),
+		}, SKIPPED, false},
+	}
+
+	for _, c := range cases {
+		s := Aggregate(c.jobs)
+
+		assert.Equal(t, c.status, s.Status, c.name)
+		assert.Equal(t, c.warnings, s.Warnings, c.name)
+	}
+}
+
+func Test_Aggregate_Stages(t *testing.T) {
+	s := Aggregate([]*gitlab.Job{
+		newJob(5, "deploy", "deploy", SKIPPED, false),
+		newJob(1, "build", "build", SUCCESS, false),
+		newJob(2, "unit", "test", SUCCESS, false),
+		newJob(3, "lint", "test", FAILED, true),
+		newJob(4, "unit", "test", FAILED, false),
+	})
+
+	assert.Equal(t, FAILED, s.Status)
+	assert.True(t, s.IsFinished())
+	assert.False(t, s.IsSuccessful())
+	assert.Len(t, s.Jobs, 4)
+	assert.Len(t, s.Stages, 3)
+
+	assert.Equal(t, "build", s.Stages[0].Name)
+	assert.Equal(t, SUCCESS, s.Stages[0].Status)
+	assert.Equal(t, "test", s.Stages[1].Name)
+	assert.Equal(t, FAILED, s.Stages[1].Status)
+	assert.Len(t, s.Stages[1].Jobs, 2)
+	assert.Equal(t, "deploy", s.Stages[2].Name)
+	assert.Equal(t, SKIPPED, s.Stages[2].Status)
+
+	assert.Nil(t, s.Stage("foo"))
+}
+
+func Test_Status_Finish(t *testing.T) {
+	s := Aggregate([]*gitlab.Job{
+		newJob(1, "test", "test", SUCCESS, false),
+		newJob(2, "lint", "test", FAILED, true),
+		newJob(3, "notify", "notify", RUNNING, false),
+	})
+
+	assert.Equal(t, RUNNING, s.Status)
+	assert.False(t, s.IsFinished())
+
+	s.Finish("notify")
+
+	assert.Equal(t, SUCCESS, s.Status)
+	assert.True(t, s.Warnings)
+	assert.Equal(t, SUCCESS, s.Stage("notify").Status)
+
+	s = Aggregate([]*gitlab.Job{
+		newJob(1, "test", "test", FAILED, false),
+		newJob(3, "notify", "notify", RUNNING, false),
+	})
+
+	s.Finish("notify")
+
+	assert.Equal(t, FAILED, s.Status)
+}
@@ -0,0 +1,150 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"testing"
+
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePipelineClient struct {
+	gitlab.Client
+
+	pipelines []*gitlab.Pipeline
+	jobs      map[int][]*gitlab.Job
+	bridges   map[int][]*gitlab.Bridge
+}
+
+func (f *fakePipelineClient) PipelineBridges(projectId, pipelineId int, opts *gitlab.ListOptions) ([]*gitlab.Bridge, *gitlab.Response, error) {
+	return f.bridges[pipelineId], &gitlab.Response{}, nil
+}
+
+func (f *fakePipelineClient) Pipelines(projectId int, opts *gitlab.PipelineListOptions) ([]*gitlab.Pipeline, *gitlab.Response, error) {
+	return f.pipelines, &gitlab.Response{}, nil
+}
+
+func (f *fakePipelineClient) PipelineJobs(projectId, pipelineId int, opts *gitlab.ListOptions) ([]*gitlab.Job, *gitlab.Response, error) {
+	// two jobs per page
+	jobs := f.jobs[pipelineId]
+	start := (opts.Page - 1) * 2
+
+	resp := &gitlab.Response{}
+	if start+2 < len(jobs) {
+		resp.NextPage = opts.Page + 1
+		jobs = jobs[start : start+2]
+	} else {
+		jobs = jobs[start:]
+	}
+
+	return jobs, resp, nil
+}
+
+func newJob(id int, name, status string, pipelineId int) *gitlab.Job {
+	return &gitlab.Job{
+		Id:       id,
+		Name:     name,
+		Status:   status,
+		Pipeline: &gitlab.Pipeline{Id: pipelineId},
+	}
+}
+
+func Test_FindJob_Latest_Attempt(t *testing.T) {
+	jobs := []*gitlab.Job{
+		newJob(10, "package", "failed", 1),
+		newJob(12, "package", "success", 1),
+		newJob(11, "test", "success", 1),
+	}
+
+	job, err := FindJob(jobs, "package", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 12, job.Id)
+
+	job, err = FindJob(jobs, "package", "failed")
+
+	assert.NoError(t, err)
+	assert.Nil(t, job)
+
+	job, err = FindJob(jobs, "deploy", "")
+
+	assert.NoError(t, err)
+	assert.Nil(t, job)
+}
+
+func Test_FindJob_Ambiguous(t *testing.T) {
+	jobs := []*gitlab.Job{
+		newJob(10, "package", "success", 1),
+		newJob(20, "package", "success", 2),
+	}
+
+	job, err := FindJob(jobs, "package", "success")
+
+	assert.Nil(t, job)
+	assert.IsType(t, &ErrAmbiguousJob{}, err)
+	assert.Equal(t, 20, err.(*ErrAmbiguousJob).Jobs[0].Id)
+
+	job, err = FindJob(jobs[1:], "package", "success")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 20, job.Id)
+}
+
+func Test_GetCommitJobs(t *testing.T) {
+	client := &fakePipelineClient{
+		pipelines: []*gitlab.Pipeline{{Id: 2}, {Id: 1}},
+		jobs: map[int][]*gitlab.Job{
+			1: {{Id: 10, Name: "test"}, {Id: 11, Name: "package"}, {Id: 12, Name: "deploy"}},
+			2: {{Id: 20, Name: "test"}},
+		},
+	}
+
+	jobs, err := GetCommitJobs(&gitlab.Project{Id: 3}, "sha1", client)
+
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 4)
+	assert.Equal(t, 2, jobs[0].PipelineId())
+	assert.Equal(t, 12, jobs[3].Id)
+	assert.Equal(t, 1, jobs[3].PipelineId())
+
+	_, err = GetPipelineJobs(&gitlab.Project{Id: 3}, "foo", client)
+
+	assert.Error(t, err)
+}
+
+func Test_GetDownstreamPipeline(t *testing.T) {
+	client := &fakePipelineClient{
+		bridges: map[int][]*gitlab.Bridge{
+			12: {
+				{Id: 1, Name: "deploy", DownstreamPipeline: &gitlab.Pipeline{Id: 20, ProjectId: 4}},
+				{Id: 2, Name: "docs"},
+			},
+		},
+	}
+
+	project := &gitlab.Project{Id: 3}
+
+	p, err := GetDownstreamPipeline(project, "12", "deploy", client)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 20, p.Id)
+	assert.Equal(t, 4, p.ProjectId)
+
+	// the downstream pipeline is not created yet
+	p, err = GetDownstreamPipeline(project, "12", "docs", client)
+
+	assert.NoError(t, err)
+	assert.Nil(t, p)
+
+	_, err = GetDownstreamPipeline(project, "12", "foo", client)
+
+	assert.EqualError(t, err, "Unable to find the trigger job foo in the pipeline 12")
+
+	_, err = GetDownstreamPipeline(project, "foo", "deploy", client)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,196 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rande/gitlab-ci-helper/gitlab"
+)
+
+// ReleaseNotes contains the changes of a release, Previous is empty for the
+// first release.
+type ReleaseNotes struct {
+	Previous      string
+	Commits       []*gitlab.Commit
+	MergeRequests []*gitlab.MergeRequest
+}
+
+// PreviousVersion returns the highest release version lower than the current
+// one, the pre-releases are ignored.
+func PreviousVersion(tags []string, matcher *regexp.Regexp, current *Version) *Version {
+	lower := []string{}
+
+	for _, tag := range tags {
+		if v, err := ParseVersion(tag); err == nil && v.Compare(current) < 0 {
+			lower = append(lower, tag)
+		}
+	}
+
+	return LatestVersion(lower, matcher)
+}
+
+// NewReleaseNotes retrieves the commits created since the previous release
+// tag and the merge requests merged by these commits. The commits are
+// compared up to the to reference, the tag or its commit if the tag does not
+// exist yet.
+func NewReleaseNotes(project *gitlab.Project, client gitlab.Client, tag, to string, matcher *regexp.Regexp) (*ReleaseNotes, error) {
+	current, err := ParseVersion(tag)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := (&ApiVersionSource{Project: project, Client: client}).Tags()
+
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve the tags, err: %s", err)
+	}
+
+	notes := &ReleaseNotes{
+		Commits:       []*gitlab.Commit{},
+		MergeRequests: []*gitlab.MergeRequest{},
+	}
+
+	previous := PreviousVersion(tags, matcher, current)
+
+	if previous == nil {
+		return notes, nil
+	}
+
+	notes.Previous = previous.String()
+
+	comparison, err := client.Compare(project.Id, notes.Previous, to)
+
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve the commits since %s, err: %s", notes.Previous, err)
+	}
+
+	shas := map[string]bool{}
+
+	for _, c := range comparison.Commits {
+		shas[c.Id] = true
+	}
+
+	// only the merge requests updated since the previous release can be part
+	// of the release
+	commit, err := client.Commit(project.Id, notes.Previous)
+
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve the commit of %s, err: %s", notes.Previous, err)
+	}
+
+	opts := &gitlab.MergeRequestListOptions{
+		ListOptions:  gitlab.ListOptions{Page: 1, PerPage: 100},
+		State:        "merged",
+		UpdatedAfter: commit.CommittedDate,
+	}
+
+	// the merge and the squash commits of the merge requests are described
+	// by the merge requests
+	merged := map[string]bool{}
+
+	for opts.Page > 0 {
+		mergeRequests, resp, err := client.MergeRequests(project.Id, opts)
+
+		if err != nil {
+			return nil, fmt.Errorf("Unable to retrieve the merge requests, err: %s", err)
+		}
+
+		for _, mr := range mergeRequests {
+			if shas[mr.MergeCommitSha] || shas[mr.SquashCommitSha] || shas[mr.Sha] {
+				notes.MergeRequests = append(notes.MergeRequests, mr)
+				merged[mr.MergeCommitSha] = true
+				merged[mr.SquashCommitSha] = true
+			}
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	for _, c := range comparison.Commits {
+		if len(c.ParentIds) < 2 && !merged[c.Id] {
+			notes.Commits = append(notes.Commits, c)
+		}
+	}
+
+	return notes, nil
+}
+
+// Markdown returns the notes grouped by conventional commit type, the
+// breaking changes first.
+func (n *ReleaseNotes) Markdown() string {
+	sections := []struct {
+		title   string
+		entries []string
+	}{
+		{title: "Breaking changes"},
+		{title: "Features"},
+		{title: "Bug fixes"},
+		{title: "Performance improvements"},
+		{title: "Other changes"},
+	}
+
+	for _, c := range n.Commits {
+		title := c.Title
+		if len(title) == 0 {
+			title = strings.SplitN(strings.TrimSpace(c.Message), "\n", 2)[0]
+		}
+
+		section := 4
+		entry := title
+
+		if m := conventionalRegexp.FindStringSubmatch(title); m != nil {
+			entry = strings.TrimPrefix(title, m[0])
+
+			if scope := strings.Trim(m[2], "()"); len(scope) > 0 {
+				entry = fmt.Sprintf("**%s:** %s", scope, entry)
+			}
+
+			switch {
+			case CommitBump(c.Message) == BUMP_MAJOR || len(m[3]) > 0:
+				section = 0
+			case strings.ToLower(m[1]) == "feat":
+				section = 1
+			case strings.ToLower(m[1]) == "fix":
+				section = 2
+			case strings.ToLower(m[1]) == "perf":
+				section = 3
+			}
+		}
+
+		sections[section].entries = append(sections[section].entries, fmt.Sprintf("- %s (%s)", entry, c.ShortId))
+	}
+
+	buf := bytes.NewBuffer([]byte(""))
+
+	if len(n.Previous) > 0 {
+		fmt.Fprintf(buf, "## Changes since %s\n", n.Previous)
+	} else {
+		buf.WriteString("## Initial release\n")
+	}
+
+	for _, s := range sections {
+		if len(s.entries) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(buf, "\n### %s\n\n%s\n", s.title, strings.Join(s.entries, "\n"))
+	}
+
+	if len(n.MergeRequests) > 0 {
+		buf.WriteString("\n### Merge requests\n\n")
+
+		for _, mr := range n.MergeRequests {
+			fmt.Fprintf(buf, "- !%d %s\n", mr.Iid, mr.Title)
+		}
+	}
+
+	return buf.String()
+}
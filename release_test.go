@@ -0,0 +1,110 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/rande/gitlab-ci-helper/gitlab"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeReleaseClient struct {
+	gitlab.Client
+
+	compared []string
+	opts     *gitlab.MergeRequestListOptions
+}
+
+func (f *fakeReleaseClient) Tags(projectId int, opts *gitlab.TagListOptions) ([]*gitlab.Tag, *gitlab.Response, error) {
+	return []*gitlab.Tag{{Name: "v1.3.0"}, {Name: "v1.2.0"}, {Name: "v1.2.1-beta.1"}, {Name: "v1.1.0"}, {Name: "nightly"}}, &gitlab.Response{}, nil
+}
+
+func (f *fakeReleaseClient) Compare(projectId int, from, to string) (*gitlab.Comparison, error) {
+	f.compared = []string{from, to}
+
+	return &gitlab.Comparison{Commits: []*gitlab.Commit{
+		{Id: "sha1", ShortId: "sha1", Title: "feat(api): add the releases", Message: "feat(api): add the releases", ParentIds: []string{"sha0"}},
+		{Id: "sha2", ShortId: "sha2", Title: "Merge branch 'feature' into 'master'", ParentIds: []string{"sha1", "sha0"}},
+		{Id: "sha3", ShortId: "sha3", Title: "fix: handle the empty tags", Message: "fix: handle the empty tags\n\nBREAKING CHANGE: the tags are required", ParentIds: []string{"sha2"}},
+		{Id: "sha4", ShortId: "sha4", Title: "Update the README", ParentIds: []string{"sha3"}},
+	}}, nil
+}
+
+func (f *fakeReleaseClient) Commit(projectId int, sha string) (*gitlab.Commit, error) {
+	return &gitlab.Commit{Id: "sha0", CommittedDate: "2017-08-01T10:00:00Z"}, nil
+}
+
+func (f *fakeReleaseClient) MergeRequests(projectId int, opts *gitlab.MergeRequestListOptions) ([]*gitlab.MergeRequest, *gitlab.Response, error) {
+	f.opts = opts
+
+	return []*gitlab.MergeRequest{
+		{Iid: 12, Title: "Add the releases", MergeCommitSha: "sha2"},
+		{Iid: 13, Title: "Update the README", SquashCommitSha: "sha4"},
+		{Iid: 11, Title: "Released in v1.2.0", MergeCommitSha: "sha0"},
+	}, &gitlab.Response{}, nil
+}
+
+func Test_PreviousVersion(t *testing.T) {
+	current, _ := ParseVersion("v1.3.0")
+	tags := []string{"v1.3.0", "v1.2.0", "v1.2.1-beta.1", "v1.4.0", "1.2.5"}
+
+	assert.Equal(t, "1.2.5", PreviousVersion(tags, nil, current).String())
+	assert.Equal(t, "v1.2.0", PreviousVersion(tags, regexp.MustCompile("^v"), current).String())
+
+	current, _ = ParseVersion("v1.0.0")
+	assert.Nil(t, PreviousVersion(tags, nil, current))
+}
+
+func Test_NewReleaseNotes(t *testing.T) {
+	client := &fakeReleaseClient{}
+
+	notes, err := NewReleaseNotes(&gitlab.Project{Id: 3}, client, "v1.3.0", "sha4", regexp.MustCompile(SEMVER_PATTERN))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.2.0", notes.Previous)
+	assert.Equal(t, []string{"v1.2.0", "sha4"}, client.compared)
+	assert.Equal(t, "merged", client.opts.State)
+	assert.Equal(t, "2017-08-01T10:00:00Z", client.opts.UpdatedAfter)
+
+	// the merge and the squash commits are replaced by their merge requests
+	assert.Len(t, notes.Commits, 2)
+	assert.Len(t, notes.MergeRequests, 2)
+	assert.Equal(t, 12, notes.MergeRequests[0].Iid)
+	assert.Equal(t, 13, notes.MergeRequests[1].Iid)
+
+	expected := `## Changes since v1.2.0
+
+### Breaking changes
+
+- handle the empty tags (sha3)
+
+### Features
+
+- **api:** add the releases (sha1)
+
+### Merge requests
+
+- !12 Add the releases
+- !13 Update the README
+`
+
+	assert.Equal(t, expected, notes.Markdown())
+}
+
+func Test_NewReleaseNotes_InitialRelease(t *testing.T) {
+	client := &fakeReleaseClient{}
+
+	notes, err := NewReleaseNotes(&gitlab.Project{Id: 3}, client, "v1.0.0", "v1.0.0", nil)
+
+	assert.NoError(t, err)
+	assert.Nil(t, client.compared)
+	assert.Equal(t, "## Initial release\n", notes.Markdown())
+
+	_, err = NewReleaseNotes(&gitlab.Project{Id: 3}, client, "nightly", "nightly", nil)
+	assert.Error(t, err)
+}
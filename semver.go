@@ -0,0 +1,320 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the version increments, from the lowest to the highest
+const (
+	BUMP_NONE = iota
+	BUMP_PATCH
+	BUMP_MINOR
+	BUMP_MAJOR
+)
+
+// versionRegexp is the strict semver format used to parse and compare the
+// versions, unlike SEMVER_PATTERN which selects the release tags
+var versionRegexp = regexp.MustCompile(`^(v?)(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(?:-([0-9A-Za-z.-]+))?(?:\+([0-9A-Za-z.-]+))?$`)
+
+// Version is a semantic version, the prefix is the optional v of the tag.
+type Version struct {
+	Prefix     string
+	Major      int
+	Minor      int
+	Patch      int
+	PreRelease string
+	Build      string
+}
+
+// ParseVersion parses a semantic version, with or without the v prefix.
+func ParseVersion(value string) (*Version, error) {
+	m := versionRegexp.FindStringSubmatch(value)
+
+	if m == nil {
+		return nil, fmt.Errorf("Invalid semantic version: %s", value)
+	}
+
+	v := &Version{Prefix: m[1], PreRelease: m[5], Build: m[6]}
+
+	v.Major, _ = strconv.Atoi(m[2])
+	v.Minor, _ = strconv.Atoi(m[3])
+	v.Patch, _ = strconv.Atoi(m[4])
+
+	return v, nil
+}
+
+func (v *Version) String() string {
+	s := fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+
+	if len(v.PreRelease) > 0 {
+		s += "-" + v.PreRelease
+	}
+
+	if len(v.Build) > 0 {
+		s += "+" + v.Build
+	}
+
+	return s
+}
+
+// Compare returns -1, 0 or 1 with the semver precedence, the prefix and the
+// build metadata are ignored.
+func (v *Version) Compare(o *Version) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+
+	// a pre-release version has a lower precedence
+	switch {
+	case v.PreRelease == o.PreRelease:
+		return 0
+	case len(v.PreRelease) == 0:
+		return 1
+	case len(o.PreRelease) == 0:
+		return -1
+	}
+
+	a, b := strings.Split(v.PreRelease, "."), strings.Split(o.PreRelease, ".")
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareIdentifier(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+
+	return sign(len(a) - len(b))
+}
+
+// compareIdentifier compares the numeric identifiers numerically, they have
+// a lower precedence than the alphanumeric ones.
+func compareIdentifier(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+
+	switch {
+	case errA == nil && errB == nil:
+		return sign(na - nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+
+	return strings.Compare(a, b)
+}
+
+func sign(d int) int {
+	switch {
+	case d < 0:
+		return -1
+	case d > 0:
+		return 1
+	}
+
+	return 0
+}
+
+// Bump returns the next release version, the pre-release and the build
+// metadata are removed.
+func (v *Version) Bump(level int) *Version {
+	next := &Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+
+	switch level {
+	case BUMP_MAJOR:
+		next.Major, next.Minor, next.Patch = v.Major+1, 0, 0
+	case BUMP_MINOR:
+		next.Minor, next.Patch = v.Minor+1, 0
+	case BUMP_PATCH:
+		next.Patch = v.Patch + 1
+	}
+
+	return next
+}
+
+var conventionalRegexp = regexp.MustCompile(`^([a-zA-Z]+)(\([^)]*\))?(!)?: `)
+
+var breakingRegexp = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: `)
+
+// CommitBump returns the increment required by a conventional commit
+// message: a breaking change is a major, a feat is a minor, a fix or a perf
+// is a patch, the other types do not require a release.
+func CommitBump(message string) int {
+	m := conventionalRegexp.FindStringSubmatch(message)
+
+	if m == nil {
+		return BUMP_NONE
+	}
+
+	if len(m[3]) > 0 || breakingRegexp.MatchString(message) {
+		return BUMP_MAJOR
+	}
+
+	switch strings.ToLower(m[1]) {
+	case "feat":
+		return BUMP_MINOR
+	case "fix", "perf":
+		return BUMP_PATCH
+	}
+
+	return BUMP_NONE
+}
+
+// VersionSource returns the tags and the commit messages of a repository,
+// the GitLab API and the local git repository are supported.
+type VersionSource interface {
+	Tags() ([]string, error)
+	// the messages of the commits reachable from to and not from from, all
+	// the commits if from is empty
+	Messages(from, to string) ([]string, error)
+}
+
+// NextVersionOptions contains the settings used to compute the next version.
+type NextVersionOptions struct {
+	// the tags not matching the pattern are ignored
+	TagMatcher *regexp.Regexp
+	// the commit or the branch to release
+	Ref    string
+	Branch string
+	// the version used if there is no release tag
+	Initial string
+	// the pre-release identifier by branch pattern, ie: develop=beta
+	PreReleases map[string]string
+}
+
+// NextVersion contains the computed version, Latest is nil if no release tag
+// exists.
+type NextVersion struct {
+	Latest  *Version
+	Version *Version
+	Level   int
+	Commits int
+}
+
+// IsRelease returns false if no commit requires a new version.
+func (n *NextVersion) IsRelease() bool {
+	return n.Latest == nil || n.Level > BUMP_NONE
+}
+
+// LatestVersion returns the highest release version, the pre-releases are
+// ignored. The tag name is kept in the prefix.
+func LatestVersion(tags []string, matcher *regexp.Regexp) *Version {
+	var latest *Version
+
+	for _, tag := range tags {
+		if matcher != nil && !matcher.MatchString(tag) {
+			continue
+		}
+
+		v, err := ParseVersion(tag)
+
+		if err != nil || len(v.PreRelease) > 0 {
+			continue
+		}
+
+		if latest == nil || v.Compare(latest) > 0 {
+			latest = v
+		}
+	}
+
+	return latest
+}
+
+// ComputeNextVersion computes the next version from the conventional commits
+// created since the latest release tag. On a pre-release branch the
+// identifier is suffixed with a counter, ie: 1.3.0-beta.2.
+func ComputeNextVersion(source VersionSource, opts *NextVersionOptions) (*NextVersion, error) {
+	tags, err := source.Tags()
+
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve the tags, err: %s", err)
+	}
+
+	next := &NextVersion{Latest: LatestVersion(tags, opts.TagMatcher)}
+
+	if next.Latest == nil {
+		initial := opts.Initial
+		if len(initial) == 0 {
+			initial = "0.1.0"
+		}
+
+		if next.Version, err = ParseVersion(initial); err != nil {
+			return nil, err
+		}
+	} else {
+		messages, err := source.Messages(next.Latest.String(), opts.Ref)
+
+		if err != nil {
+			return nil, fmt.Errorf("Unable to retrieve the commits since %s, err: %s", next.Latest, err)
+		}
+
+		for _, message := range messages {
+			if level := CommitBump(message); level > next.Level {
+				next.Level = level
+			}
+		}
+
+		next.Commits = len(messages)
+		next.Version = next.Latest.Bump(next.Level)
+	}
+
+	if id := preRelease(opts.PreReleases, opts.Branch); len(id) > 0 && next.IsRelease() {
+		next.Version.PreRelease = fmt.Sprintf("%s.%d", id, preReleaseNumber(tags, next.Version, id)+1)
+	}
+
+	return next, nil
+}
+
+// preRelease returns the identifier of the first matching branch pattern,
+// the patterns are sorted to get a stable result.
+func preRelease(preReleases map[string]string, branch string) string {
+	patterns := []string{}
+	for pattern := range preReleases {
+		patterns = append(patterns, pattern)
+	}
+
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return preReleases[pattern]
+		}
+	}
+
+	return ""
+}
+
+// preReleaseNumber returns the highest counter of the pre-release tags of
+// the version, 0 if none exists.
+func preReleaseNumber(tags []string, version *Version, id string) int {
+	number := 0
+
+	for _, tag := range tags {
+		v, err := ParseVersion(tag)
+
+		if err != nil || v.Major != version.Major || v.Minor != version.Minor || v.Patch != version.Patch {
+			continue
+		}
+
+		if !strings.HasPrefix(v.PreRelease, id+".") {
+			continue
+		}
+
+		if n, err := strconv.Atoi(strings.TrimPrefix(v.PreRelease, id+".")); err == nil && n > number {
+			number = n
+		}
+	}
+
+	return number
+}
@@ -0,0 +1,120 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/rande/gitlab-ci-helper/gitlab"
+)
+
+// ApiVersionSource reads the tags and the commits with the GitLab API.
+type ApiVersionSource struct {
+	Project *gitlab.Project
+	Client  gitlab.Client
+}
+
+func (s *ApiVersionSource) Tags() ([]string, error) {
+	tags := []string{}
+	opts := &gitlab.TagListOptions{ListOptions: gitlab.ListOptions{Page: 1, PerPage: 100}}
+
+	for opts.Page > 0 {
+		pageTags, resp, err := s.Client.Tags(s.Project.Id, opts)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range pageTags {
+			tags = append(tags, t.Name)
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	return tags, nil
+}
+
+func (s *ApiVersionSource) Messages(from, to string) ([]string, error) {
+	if len(from) == 0 {
+		return nil, errors.New("The api source requires a reference to compare with")
+	}
+
+	comparison, err := s.Client.Compare(s.Project.Id, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []string{}
+	for _, c := range comparison.Commits {
+		messages = append(messages, c.Message)
+	}
+
+	return messages, nil
+}
+
+// GitVersionSource reads the tags and the commits from a local clone, the
+// clone must contain the tags: GIT_DEPTH=0 or git fetch --tags.
+type GitVersionSource struct {
+	Dir string
+}
+
+func (s *GitVersionSource) Tags() ([]string, error) {
+	out, err := s.git("tag", "--list")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Fields(out), nil
+}
+
+func (s *GitVersionSource) Messages(from, to string) ([]string, error) {
+	if len(to) == 0 {
+		to = "HEAD"
+	}
+
+	if len(from) > 0 {
+		to = from + ".." + to
+	}
+
+	out, err := s.git("log", "--format=%B%x00", to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []string{}
+
+	for _, m := range strings.Split(out, "\x00") {
+		if m = strings.TrimSpace(m); len(m) > 0 {
+			messages = append(messages, m)
+		}
+	}
+
+	return messages, nil
+}
+
+func (s *GitVersionSource) git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = s.Dir
+
+	stderr := bytes.NewBuffer([]byte(""))
+	cmd.Stderr = stderr
+
+	out, err := cmd.Output()
+
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return string(out), nil
+}
@@ -0,0 +1,166 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseVersion(t *testing.T) {
+	v, err := ParseVersion("v1.2.3-beta.1+build.5")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3, PreRelease: "beta.1", Build: "build.5"}, v)
+	assert.Equal(t, "v1.2.3-beta.1+build.5", v.String())
+
+	for _, invalid := range []string{"1.2", "v01.2.3", "release-1.2.3", "1.2.3-"} {
+		_, err := ParseVersion(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func Test_Version_Compare(t *testing.T) {
+	// sorted by precedence, from the semver specification
+	versions := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "v1.0.0", "1.0.1", "1.2.0", "2.0.0"}
+
+	for i := 1; i < len(versions); i++ {
+		a, _ := ParseVersion(versions[i-1])
+		b, _ := ParseVersion(versions[i])
+
+		assert.Equal(t, -1, a.Compare(b), "%s < %s", a, b)
+		assert.Equal(t, 1, b.Compare(a), "%s > %s", b, a)
+	}
+
+	a, _ := ParseVersion("v1.0.0+build")
+	b, _ := ParseVersion("1.0.0")
+	assert.Equal(t, 0, a.Compare(b))
+}
+
+func Test_Version_Bump(t *testing.T) {
+	v, _ := ParseVersion("v1.2.3-rc.1")
+
+	assert.Equal(t, "v1.2.3", v.Bump(BUMP_NONE).String())
+	assert.Equal(t, "v1.2.4", v.Bump(BUMP_PATCH).String())
+	assert.Equal(t, "v1.3.0", v.Bump(BUMP_MINOR).String())
+	assert.Equal(t, "v2.0.0", v.Bump(BUMP_MAJOR).String())
+}
+
+func Test_CommitBump(t *testing.T) {
+	values := []struct {
+		Message string
+		Expect  int
+	}{
+		{"feat: add the version:next command", BUMP_MINOR},
+		{"feat(version): add the command", BUMP_MINOR},
+		{"fix: handle the empty tags", BUMP_PATCH},
+		{"perf: cache the tags", BUMP_PATCH},
+		{"refactor!: drop the 8.x variables", BUMP_MAJOR},
+		{"fix: rename the option\n\nBREAKING CHANGE: the -file option is renamed", BUMP_MAJOR},
+		{"docs: update the readme", BUMP_NONE},
+		{"Merge branch 'master'", BUMP_NONE},
+	}
+
+	for _, v := range values {
+		assert.Equal(t, v.Expect, CommitBump(v.Message), v.Message)
+	}
+}
+
+type fakeVersionSource struct {
+	tags     []string
+	messages []string
+	from     string
+}
+
+func (s *fakeVersionSource) Tags() ([]string, error) {
+	return s.tags, nil
+}
+
+func (s *fakeVersionSource) Messages(from, to string) ([]string, error) {
+	s.from = from
+
+	return s.messages, nil
+}
+
+func Test_ComputeNextVersion(t *testing.T) {
+	source := &fakeVersionSource{
+		tags:     []string{"v1.2.0", "v1.10.0", "v2.0.0-rc.1", "v1.11.0-beta.1", "v1.11.0-beta.3", "foo"},
+		messages: []string{"fix: a bug", "feat: a feature", "docs: the readme"},
+	}
+
+	opts := &NextVersionOptions{
+		TagMatcher:  regexp.MustCompile(SEMVER_PATTERN),
+		Branch:      "master",
+		PreReleases: map[string]string{"develop": "beta", "feature/*": "alpha"},
+	}
+
+	next, err := ComputeNextVersion(source, opts)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.10.0", source.from)
+	assert.Equal(t, "v1.10.0", next.Latest.String())
+	assert.Equal(t, "v1.11.0", next.Version.String())
+	assert.Equal(t, BUMP_MINOR, next.Level)
+	assert.Equal(t, 3, next.Commits)
+
+	opts.Branch = "develop"
+	next, _ = ComputeNextVersion(source, opts)
+	assert.Equal(t, "v1.11.0-beta.4", next.Version.String())
+
+	opts.Branch = "feature/semver"
+	next, _ = ComputeNextVersion(source, opts)
+	assert.Equal(t, "v1.11.0-alpha.1", next.Version.String())
+
+	// no release required
+	source.messages = []string{"docs: the readme"}
+	next, _ = ComputeNextVersion(source, opts)
+	assert.False(t, next.IsRelease())
+	assert.Equal(t, "v1.10.0", next.Version.String())
+
+	// no release tag
+	source.tags = []string{}
+	next, _ = ComputeNextVersion(source, &NextVersionOptions{})
+	assert.True(t, next.IsRelease())
+	assert.Equal(t, "0.1.0", next.Version.String())
+}
+
+func Test_GitVersionSource(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	dir, err := ioutil.TempDir("", "gitlab-ci-helper")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	source := &GitVersionSource{Dir: dir}
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "feat: initial"},
+		{"tag", "v1.0.0"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "fix: a bug\n\nwith a body"},
+	} {
+		_, err := source.git(args...)
+		assert.NoError(t, err)
+	}
+
+	tags, err := source.Tags()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v1.0.0"}, tags)
+
+	messages, err := source.Messages("v1.0.0", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fix: a bug\n\nwith a body"}, messages)
+
+	_, err = source.Messages("v9.9.9", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,304 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// the objects are encrypted by chunks, so they can be streamed
+	ENCRYPTION_CHUNK_SIZE = 64 * 1024
+
+	// metadata attached to the encrypted objects
+	META_ENCRYPTION    = "Encryption"
+	ENCRYPTION_AES_GCM = "aes-256-gcm"
+)
+
+var encryptionMagic = []byte("GCIHENC1")
+
+var ErrNotEncrypted = errors.New("The object is not encrypted")
+
+// ParseEncryptionKey decodes a base64 encoded 256 bits key, ie: the output
+// of `openssl rand -base64 32`.
+func ParseEncryptionKey(value string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(value)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid encryption key, the key must be base64 encoded: %s", err)
+	}
+
+	if len(key) != 32 {
+		return nil, fmt.Errorf("Invalid encryption key, the key must contain 32 bytes, %d found", len(key))
+	}
+
+	return key, nil
+}
+
+// EncryptedStorage encrypts the objects with AES-256-GCM before sending them
+// to the underlying storage, and decrypts them on retrieval.
+//
+// The object starts with a magic header and a random nonce, followed by the
+// encrypted chunks. The nonce of each chunk is derived from its position
+// and the last chunk is flagged, so reordered or truncated objects are
+// rejected.
+type EncryptedStorage struct {
+	Storage
+
+	Key []byte
+
+	// returns the objects without the encryption header as is, ie: the
+	// objects stored before the encryption was enabled
+	AllowPlaintext bool
+
+	// called when a plaintext object is returned, optional
+	OnPlaintext func(key string)
+}
+
+func (s *EncryptedStorage) Put(key string, body io.Reader, object *Object) error {
+	aead, err := newAEAD(s.Key)
+
+	if err != nil {
+		return err
+	}
+
+	encrypted := &Object{Metadata: map[string]string{}}
+
+	if object != nil {
+		*encrypted = *object
+		encrypted.Metadata = map[string]string{}
+
+		for k, v := range object.Metadata {
+			encrypted.Metadata[k] = v
+		}
+	}
+
+	encrypted.Metadata[META_ENCRYPTION] = ENCRYPTION_AES_GCM
+
+	pr, pw := io.Pipe()
+
+	go func() {
+		pw.CloseWithError(encrypt(aead, body, pw))
+	}()
+
+	err = s.Storage.Put(key, pr, encrypted)
+
+	// unblock the encryption if the upload has failed
+	pr.CloseWithError(err)
+
+	return err
+}
+
+func (s *EncryptedStorage) Get(key string) (io.ReadCloser, error) {
+	aead, err := newAEAD(s.Key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := s.Storage.Get(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(body)
+
+	header, err := r.Peek(len(encryptionMagic))
+
+	if err != nil && err != io.EOF {
+		body.Close()
+
+		return nil, err
+	}
+
+	if !bytes.Equal(header, encryptionMagic) {
+		if s.AllowPlaintext {
+			if s.OnPlaintext != nil {
+				s.OnPlaintext(key)
+			}
+
+			return &readCloser{Reader: r, Closer: body}, nil
+		}
+
+		body.Close()
+
+		return nil, ErrNotEncrypted
+	}
+
+	r.Discard(len(encryptionMagic))
+
+	nonce := make([]byte, aead.NonceSize())
+
+	if _, err := io.ReadFull(r, nonce); err != nil {
+		body.Close()
+
+		return nil, fmt.Errorf("Invalid encrypted object, %s", err)
+	}
+
+	return &readCloser{
+		Reader: &decryptReader{aead: aead, nonce: nonce, r: r},
+		Closer: body,
+	}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce derives the nonce of the chunk from the object nonce.
+func chunkNonce(nonce []byte, counter uint64) []byte {
+	n := make([]byte, len(nonce))
+	copy(n, nonce)
+
+	c := make([]byte, 8)
+	binary.BigEndian.PutUint64(c, counter)
+
+	for i := range c {
+		n[len(n)-8+i] ^= c[i]
+	}
+
+	return n
+}
+
+func chunkData(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+
+	return []byte{0}
+}
+
+func encrypt(aead cipher.AEAD, r io.Reader, w io.Writer) error {
+	nonce := make([]byte, aead.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(append(append([]byte{}, encryptionMagic...), nonce...)); err != nil {
+		return err
+	}
+
+	current := make([]byte, ENCRYPTION_CHUNK_SIZE)
+	next := make([]byte, ENCRYPTION_CHUNK_SIZE)
+
+	n, err := io.ReadFull(r, current)
+	final := err == io.EOF || err == io.ErrUnexpectedEOF
+
+	if err != nil && !final {
+		return err
+	}
+
+	for counter := uint64(0); ; counter++ {
+		m := 0
+
+		// read the next chunk to know if the current one is the last one
+		if !final {
+			m, err = io.ReadFull(r, next)
+
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				final = m == 0
+			} else if err != nil {
+				return err
+			}
+		}
+
+		if _, err := w.Write(aead.Seal(nil, chunkNonce(nonce, counter), current[:n], chunkData(final))); err != nil {
+			return err
+		}
+
+		if final {
+			return nil
+		}
+
+		current, next = next, current
+		n = m
+
+		// a partial chunk is always the last one
+		final = n < ENCRYPTION_CHUNK_SIZE
+	}
+}
+
+type decryptReader struct {
+	aead    cipher.AEAD
+	nonce   []byte
+	r       *bufio.Reader
+	counter uint64
+	buf     []byte
+	done    bool
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+
+		if err := d.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+
+	return n, nil
+}
+
+func (d *decryptReader) readChunk() error {
+	chunk := make([]byte, ENCRYPTION_CHUNK_SIZE+d.aead.Overhead())
+
+	n, err := io.ReadFull(d.r, chunk)
+
+	if err != nil && err != io.ErrUnexpectedEOF {
+		if err == io.EOF {
+			return fmt.Errorf("Invalid encrypted object, the object is truncated")
+		}
+
+		return err
+	}
+
+	// the chunk is the last one if there is no more data
+	_, err = d.r.Peek(1)
+	final := err == io.EOF
+
+	if err != nil && !final {
+		return err
+	}
+
+	data, err := d.aead.Open(nil, chunkNonce(d.nonce, d.counter), chunk[:n], chunkData(final))
+
+	if err != nil {
+		return fmt.Errorf("Unable to decrypt the object, invalid key or corrupted object")
+	}
+
+	d.counter++
+	d.buf = data
+	d.done = final
+
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEncryptedStorage(t *testing.T) (*EncryptedStorage, func()) {
+	root, err := ioutil.TempDir("", "gitlab_ci_helper_encryption")
+	assert.NoError(t, err)
+
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	return &EncryptedStorage{Storage: &LocalStorage{Root: root}, Key: key}, func() {
+		os.RemoveAll(root)
+	}
+}
+
+func Test_EncryptedStorage(t *testing.T) {
+	s, clean := newTestEncryptedStorage(t)
+	defer clean()
+
+	for _, size := range []int{0, 1, ENCRYPTION_CHUNK_SIZE - 1, ENCRYPTION_CHUNK_SIZE, ENCRYPTION_CHUNK_SIZE + 1, 3 * ENCRYPTION_CHUNK_SIZE} {
+		content := make([]byte, size)
+		rand.Read(content)
+
+		err := s.Put("archive.zip", bytes.NewReader(content), &Object{Metadata: map[string]string{"Job": "build"}})
+		assert.NoError(t, err)
+
+		// the underlying storage contains the encrypted content
+		raw, _ := ioutil.ReadFile(filepath.Join(s.Storage.(*LocalStorage).Root, "archive.zip"))
+		assert.True(t, bytes.HasPrefix(raw, encryptionMagic))
+		assert.False(t, size > 16 && bytes.Contains(raw, content))
+
+		r, err := s.Get("archive.zip")
+		assert.NoError(t, err)
+
+		data, err := ioutil.ReadAll(r)
+		r.Close()
+
+		assert.NoError(t, err, "size: %d", size)
+		assert.Equal(t, content, data, "size: %d", size)
+	}
+
+	o, err := s.Stat("archive.zip")
+	assert.NoError(t, err)
+	assert.Equal(t, ENCRYPTION_AES_GCM, o.Meta(META_ENCRYPTION))
+	assert.Equal(t, "build", o.Meta("Job"))
+}
+
+func Test_EncryptedStorage_Invalid(t *testing.T) {
+	s, clean := newTestEncryptedStorage(t)
+	defer clean()
+
+	root := s.Storage.(*LocalStorage).Root
+	content := make([]byte, 2*ENCRYPTION_CHUNK_SIZE+10)
+
+	assert.NoError(t, s.Put("archive.zip", bytes.NewReader(content), nil))
+
+	// invalid key
+	other := &EncryptedStorage{Storage: s.Storage, Key: make([]byte, 32)}
+
+	r, err := other.Get("archive.zip")
+	assert.NoError(t, err)
+
+	_, err = ioutil.ReadAll(r)
+	assert.Error(t, err)
+
+	// truncated object, the last chunk is removed
+	raw, _ := ioutil.ReadFile(filepath.Join(root, "archive.zip"))
+	ioutil.WriteFile(filepath.Join(root, "archive.zip"), raw[:len(raw)-26], 0644)
+
+	r, err = s.Get("archive.zip")
+	assert.NoError(t, err)
+
+	_, err = ioutil.ReadAll(r)
+	assert.Error(t, err)
+
+	// plaintext object
+	assert.NoError(t, s.Storage.Put("plain.zip", bytes.NewBufferString("hello"), nil))
+
+	_, err = s.Get("plain.zip")
+	assert.Equal(t, ErrNotEncrypted, err)
+
+	plaintext := []string{}
+
+	s.AllowPlaintext = true
+	s.OnPlaintext = func(key string) {
+		plaintext = append(plaintext, key)
+	}
+
+	r, err = s.Get("plain.zip")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"plain.zip"}, plaintext)
+
+	data, _ := ioutil.ReadAll(r)
+	assert.Equal(t, "hello", string(data))
+}
+
+func Test_ParseEncryptionKey(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	parsed, err := ParseEncryptionKey(base64.StdEncoding.EncodeToString(key))
+	assert.NoError(t, err)
+	assert.Equal(t, key, parsed)
+
+	_, err = ParseEncryptionKey("not base64!")
+	assert.Error(t, err)
+
+	_, err = ParseEncryptionKey(base64.StdEncoding.EncodeToString(key[:16]))
+	assert.Error(t, err)
+}
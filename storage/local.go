@@ -0,0 +1,200 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// the metadata of a local object are stored in a sidecar file
+const localMetaSuffix = ".meta.json"
+
+// LocalStorage stores the objects in a local directory, ie: a shared
+// filesystem mounted on the runners.
+type LocalStorage struct {
+	Root string
+}
+
+func (s *LocalStorage) Put(key string, body io.Reader, object *Object) error {
+	path, err := s.path(key)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so a reader never get a partial file
+	fp, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(fp.Name())
+
+	if _, err := io.Copy(fp, body); err != nil {
+		fp.Close()
+
+		return err
+	}
+
+	if err := fp.Close(); err != nil {
+		return err
+	}
+
+	if object != nil {
+		meta := &Object{
+			ContentType: object.ContentType,
+			Metadata:    object.Metadata,
+		}
+
+		data, err := json.Marshal(meta)
+
+		if err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(path+localMetaSuffix, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(fp.Name(), path)
+}
+
+func (s *LocalStorage) Get(key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fp, err := os.Open(path)
+
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+
+	return fp, err
+}
+
+func (s *LocalStorage) List(prefix string) ([]*Object, error) {
+	objects := []*Object{}
+
+	// walk the deepest directory included in the prefix
+	dir := s.Root
+	if i := strings.LastIndex(prefix, "/"); i > -1 {
+		dir = filepath.Join(s.Root, filepath.FromSlash(prefix[:i]))
+	}
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return objects, nil
+	}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || strings.HasSuffix(path, localMetaSuffix) || strings.HasPrefix(info.Name(), ".tmp-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.Root, path)
+
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(rel)
+
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		objects = append(objects, &Object{
+			Key:          key,
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+
+		return nil
+	})
+
+	return objects, err
+}
+
+func (s *LocalStorage) Delete(key string) error {
+	path, err := s.path(key)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+
+		return err
+	}
+
+	os.Remove(path + localMetaSuffix)
+
+	return nil
+}
+
+func (s *LocalStorage) Stat(key string) (*Object, error) {
+	path, err := s.path(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	object := &Object{}
+
+	if data, err := ioutil.ReadFile(path + localMetaSuffix); err == nil {
+		if err := json.Unmarshal(data, object); err != nil {
+			return nil, err
+		}
+	}
+
+	object.Key = key
+	object.Size = info.Size()
+	object.LastModified = info.ModTime()
+
+	return object, nil
+}
+
+// path returns the file path for the key, keys cannot escape the root directory
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + filepath.FromSlash(key))
+
+	if clean == string(filepath.Separator) {
+		return "", fmt.Errorf("Invalid key: %s", key)
+	}
+
+	return filepath.Join(s.Root, clean), nil
+}
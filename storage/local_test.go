@@ -0,0 +1,90 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LocalStorage(t *testing.T) {
+	root, err := ioutil.TempDir("", "gitlab_ci_helper_storage")
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(root)
+
+	s := &LocalStorage{Root: root}
+
+	err = s.Put("commits/rande/project/sha1_build.zip", bytes.NewBufferString("hello"), &Object{
+		ContentType: "application/zip",
+		Metadata:    map[string]string{"Ref-Name": "master"},
+	})
+	assert.NoError(t, err)
+
+	err = s.Put("releases/rande/project/v1.0.0_build.zip", bytes.NewBufferString("world"), nil)
+	assert.NoError(t, err)
+
+	// stat
+	o, err := s.Stat("commits/rande/project/sha1_build.zip")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), o.Size)
+	assert.Equal(t, "application/zip", o.ContentType)
+	assert.Equal(t, "master", o.Meta("ref-name"))
+
+	_, err = s.Stat("commits/rande/project/sha2_build.zip")
+	assert.Equal(t, ErrNotFound, err)
+
+	// get
+	r, err := s.Get("commits/rande/project/sha1_build.zip")
+	assert.NoError(t, err)
+
+	data, _ := ioutil.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "hello", string(data))
+
+	_, err = s.Get("commits/rande/project/sha2_build.zip")
+	assert.Equal(t, ErrNotFound, err)
+
+	// list
+	objects, err := s.List("commits/")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(objects))
+	assert.Equal(t, "commits/rande/project/sha1_build.zip", objects[0].Key)
+
+	objects, err = s.List("")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(objects))
+
+	objects, err = s.List("commits/rande/project/sha")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(objects))
+
+	objects, err = s.List("foobar/")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(objects))
+
+	// delete
+	assert.NoError(t, s.Delete("commits/rande/project/sha1_build.zip"))
+	assert.Equal(t, ErrNotFound, s.Delete("commits/rande/project/sha1_build.zip"))
+
+	_, err = os.Stat(root + "/commits/rande/project/sha1_build.zip" + localMetaSuffix)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_LocalStorage_Path(t *testing.T) {
+	s := &LocalStorage{Root: "/mnt/cache"}
+
+	p, err := s.path("../../etc/passwd")
+	assert.NoError(t, err)
+	assert.Equal(t, "/mnt/cache/etc/passwd", p)
+
+	_, err = s.path("/")
+	assert.Error(t, err)
+}
@@ -0,0 +1,30 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"time"
+)
+
+const maxRetryDelay = time.Minute
+
+// Retry calls fn until it succeeds or the number of retries is reached.
+// The delay between two attempts doubles after each failure.
+func Retry(retries int, delay time.Duration, fn func(attempt int) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(attempt)
+
+		if err == nil || attempt > retries {
+			return err
+		}
+
+		time.Sleep(delay)
+
+		if delay *= 2; delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
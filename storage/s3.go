@@ -0,0 +1,205 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	helper "github.com/rande/gitlab-ci-helper"
+)
+
+func NewS3Client(region, endpoint, profile string) (*s3.S3, error) {
+	credentials, err := helper.GetAwsCredentials(profile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	awsConfig := &aws.Config{
+		Region:           aws.String(region),
+		Endpoint:         aws.String(endpoint),
+		S3ForcePathStyle: aws.Bool(true),
+		Credentials:      credentials,
+	}
+
+	return s3.New(session.New(), awsConfig), nil
+}
+
+type S3Storage struct {
+	Client s3iface.S3API
+	Bucket string
+	Prefix string
+
+	// multipart upload settings
+	PartSize    int64
+	Concurrency int
+	MaxRetries  int
+
+	// keep the failed uploads so a job retry with the same id resumes them
+	Resume   bool
+	ResumeId string
+
+	// encryption, storage class and tags of the uploaded objects
+	Options S3ObjectOptions
+}
+
+func (s *S3Storage) Put(key string, body io.Reader, object *Object) error {
+	uploader := &S3Uploader{
+		Client:      s.Client,
+		Bucket:      s.Bucket,
+		PartSize:    s.PartSize,
+		Concurrency: s.Concurrency,
+		MaxRetries:  s.MaxRetries,
+		Backoff:     time.Second,
+		Resume:      s.Resume,
+		ResumeId:    s.ResumeId,
+		Options:     s.Options,
+	}
+
+	return uploader.Upload(join(s.Prefix, key), body, object)
+}
+
+func (s *S3Storage) Get(key string) (io.ReadCloser, error) {
+	output, err := s.Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(join(s.Prefix, key)),
+	})
+
+	if err != nil {
+		return nil, s.error(err)
+	}
+
+	return output.Body, nil
+}
+
+func (s *S3Storage) List(prefix string) ([]*Object, error) {
+	objects := []*Object{}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.Bucket),
+		Prefix: aws.String(join(s.Prefix, prefix)),
+	}
+
+	err := s.Client.ListObjectsV2Pages(input, func(page *s3.ListObjectsV2Output, last bool) bool {
+		for _, o := range page.Contents {
+			objects = append(objects, &Object{
+				Key:          s.relative(aws.StringValue(o.Key)),
+				Size:         aws.Int64Value(o.Size),
+				LastModified: aws.TimeValue(o.LastModified),
+			})
+		}
+
+		return true
+	})
+
+	if err != nil {
+		return nil, s.error(err)
+	}
+
+	return objects, nil
+}
+
+func (s *S3Storage) Delete(key string) error {
+	_, err := s.Client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(join(s.Prefix, key)),
+	})
+
+	return s.error(err)
+}
+
+// the maximum number of keys deleted with one request
+const S3_MAX_DELETE_KEYS = 1000
+
+func (s *S3Storage) DeleteBatch(keys []string) error {
+	for start := 0; start < len(keys); start += S3_MAX_DELETE_KEYS {
+		end := start + S3_MAX_DELETE_KEYS
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		objects := []*s3.ObjectIdentifier{}
+		for _, key := range keys[start:end] {
+			objects = append(objects, &s3.ObjectIdentifier{Key: aws.String(join(s.Prefix, key))})
+		}
+
+		output, err := s.Client.DeleteObjects(&s3.DeleteObjectsInput{
+			Bucket: aws.String(s.Bucket),
+			Delete: &s3.Delete{
+				Objects: objects,
+				Quiet:   aws.Bool(true),
+			},
+		})
+
+		if err != nil {
+			return s.error(err)
+		}
+
+		if len(output.Errors) > 0 {
+			e := output.Errors[0]
+
+			return fmt.Errorf("Unable to delete %s, %s (%d errors)", aws.StringValue(e.Key), aws.StringValue(e.Message), len(output.Errors))
+		}
+	}
+
+	return nil
+}
+
+func (s *S3Storage) Stat(key string) (*Object, error) {
+	output, err := s.Client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(join(s.Prefix, key)),
+	})
+
+	if err != nil {
+		return nil, s.error(err)
+	}
+
+	return &Object{
+		Key:          key,
+		Size:         aws.Int64Value(output.ContentLength),
+		LastModified: aws.TimeValue(output.LastModified),
+		ContentType:  aws.StringValue(output.ContentType),
+		Metadata:     aws.StringValueMap(output.Metadata),
+	}, nil
+}
+
+func (s *S3Storage) relative(key string) string {
+	if len(s.Prefix) == 0 {
+		return key
+	}
+
+	return strings.TrimPrefix(key, strings.TrimSuffix(s.Prefix, "/")+"/")
+}
+
+// error converts not found errors to ErrNotFound
+func (s *S3Storage) error(err error) error {
+	if isS3NotFound(err) {
+		return ErrNotFound
+	}
+
+	return err
+}
+
+func isS3NotFound(err error) bool {
+	if aerr, ok := err.(awserr.RequestFailure); ok && aerr.StatusCode() == 404 {
+		return true
+	}
+
+	if aerr, ok := err.(awserr.Error); ok && (aerr.Code() == s3.ErrCodeNoSuchKey || aerr.Code() == "NotFound") {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,63 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeS3DeleteClient struct {
+	s3iface.S3API
+
+	Batches [][]string
+	Errors  []*s3.Error
+}
+
+func (f *fakeS3DeleteClient) DeleteObjects(input *s3.DeleteObjectsInput) (*s3.DeleteObjectsOutput, error) {
+	keys := []string{}
+	for _, o := range input.Delete.Objects {
+		keys = append(keys, aws.StringValue(o.Key))
+	}
+
+	f.Batches = append(f.Batches, keys)
+
+	return &s3.DeleteObjectsOutput{Errors: f.Errors}, nil
+}
+
+func Test_S3Storage_DeleteBatch(t *testing.T) {
+	client := &fakeS3DeleteClient{}
+	s := &S3Storage{Client: client, Bucket: "bucket", Prefix: "cache"}
+
+	keys := []string{}
+	for i := 0; i < 1500; i++ {
+		keys = append(keys, fmt.Sprintf("commits/sha%d_build.zip", i))
+	}
+
+	assert.NoError(t, DeleteBatch(s, keys))
+
+	assert.Len(t, client.Batches, 2)
+	assert.Len(t, client.Batches[0], S3_MAX_DELETE_KEYS)
+	assert.Len(t, client.Batches[1], 500)
+	assert.Equal(t, "cache/commits/sha0_build.zip", client.Batches[0][0])
+}
+
+func Test_S3Storage_DeleteBatch_Errors(t *testing.T) {
+	client := &fakeS3DeleteClient{
+		Errors: []*s3.Error{{Key: aws.String("cache/key"), Message: aws.String("Access Denied")}},
+	}
+
+	s := &S3Storage{Client: client, Bucket: "bucket", Prefix: "cache"}
+
+	err := s.DeleteBatch([]string{"key"})
+	assert.Error(t, err)
+	assert.Equal(t, "Unable to delete cache/key, Access Denied (1 errors)", err.Error())
+}
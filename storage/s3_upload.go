@@ -0,0 +1,460 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+const (
+	S3_DEFAULT_PART_SIZE = 16 * 1024 * 1024
+	S3_MIN_PART_SIZE     = 5 * 1024 * 1024
+	S3_MAX_PARTS         = 10000
+
+	// the object referencing a resumable upload and its metadata
+	S3_UPLOAD_SUFFIX = ".upload"
+	META_UPLOAD_ID   = "Upload-Id"
+	META_RESUME_ID   = "Resume-Id"
+)
+
+// S3Uploader streams a body to S3 using a multipart upload, so the body size
+// does not need to be known in advance. Each part is retried on failure and a
+// failed upload is aborted, unless Resume is enabled: the upload is then kept
+// so the parts already sent are reused by the next upload of the same key
+// with the same ResumeId, ie: a retry of the job.
+type S3Uploader struct {
+	Client      s3iface.S3API
+	Bucket      string
+	PartSize    int64
+	Concurrency int
+	MaxRetries  int
+	Backoff     time.Duration
+	Resume      bool
+	ResumeId    string
+	Options     S3ObjectOptions
+}
+
+// S3ObjectOptions contains the settings applied to the uploaded objects.
+type S3ObjectOptions struct {
+	// the server side encryption: AES256 or aws:kms
+	ServerSideEncryption string
+	KMSKeyId             string
+	StorageClass         string
+
+	// the tags, url encoded: key1=value1&key2=value2
+	Tagging string
+}
+
+func (o *S3ObjectOptions) Validate() error {
+	switch o.ServerSideEncryption {
+	case "", s3.ServerSideEncryptionAes256:
+		if len(o.KMSKeyId) > 0 {
+			return fmt.Errorf("The kms key requires the %s encryption", s3.ServerSideEncryptionAwsKms)
+		}
+	case s3.ServerSideEncryptionAwsKms:
+	default:
+		return fmt.Errorf("Invalid server side encryption: %s", o.ServerSideEncryption)
+	}
+
+	if len(o.StorageClass) > 0 {
+		valid := false
+		for _, v := range s3.StorageClass_Values() {
+			valid = valid || v == o.StorageClass
+		}
+
+		if !valid {
+			return fmt.Errorf("Invalid storage class: %s", o.StorageClass)
+		}
+	}
+
+	return nil
+}
+
+type s3Part struct {
+	Number int64
+	Data   []byte
+}
+
+func (u *S3Uploader) Upload(key string, body io.Reader, object *Object) error {
+	partSize := u.PartSize
+	if partSize <= 0 {
+		partSize = S3_DEFAULT_PART_SIZE
+	}
+
+	data, last, err := readPart(body, partSize)
+
+	if err != nil {
+		return err
+	}
+
+	// the body fits in one part, no need to start a multipart upload
+	if last {
+		return u.putObject(key, data, object)
+	}
+
+	if u.Resume && len(u.ResumeId) == 0 {
+		return fmt.Errorf("The resume id is required to resume the upload of %s", key)
+	}
+
+	uploadId, existing, err := u.start(key, object)
+
+	if err != nil {
+		return err
+	}
+
+	var mutex sync.Mutex
+	var uploadErr error
+
+	completed := []*s3.CompletedPart{}
+
+	failed := func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return uploadErr != nil
+	}
+
+	parts := make(chan *s3Part)
+
+	concurrency := u.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for p := range parts {
+				if failed() {
+					continue
+				}
+
+				etag, err := u.uploadPart(key, uploadId, p, existing)
+
+				mutex.Lock()
+				if err != nil && uploadErr == nil {
+					uploadErr = err
+				} else if err == nil {
+					completed = append(completed, &s3.CompletedPart{
+						ETag:       aws.String(etag),
+						PartNumber: aws.Int64(p.Number),
+					})
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	var readErr error
+	for number := int64(1); ; number++ {
+		if number > S3_MAX_PARTS {
+			readErr = fmt.Errorf("The body is too large, the maximum number of parts is %d, please increase the part size", S3_MAX_PARTS)
+
+			break
+		}
+
+		parts <- &s3Part{Number: number, Data: data}
+
+		if last || failed() {
+			break
+		}
+
+		if data, last, readErr = readPart(body, partSize); readErr != nil || len(data) == 0 {
+			break
+		}
+	}
+
+	close(parts)
+	wg.Wait()
+
+	if uploadErr == nil {
+		uploadErr = readErr
+	}
+
+	if uploadErr == nil {
+		uploadErr = u.complete(key, uploadId, completed)
+	}
+
+	if uploadErr != nil {
+		if !u.Resume {
+			// the parts of an incomplete upload are billed until it is aborted
+			u.Client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+				Bucket:   aws.String(u.Bucket),
+				Key:      aws.String(key),
+				UploadId: aws.String(uploadId),
+			})
+
+			return uploadErr
+		}
+
+		return fmt.Errorf("%s (the upload %s can be resumed)", uploadErr, uploadId)
+	}
+
+	if u.Resume {
+		u.Client.DeleteObject(&s3.DeleteObjectInput{
+			Bucket: aws.String(u.Bucket),
+			Key:    aws.String(key + S3_UPLOAD_SUFFIX),
+		})
+	}
+
+	return nil
+}
+
+func (u *S3Uploader) putObject(key string, data []byte, object *Object) error {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(u.Bucket),
+		Key:    aws.String(key),
+	}
+
+	if object != nil {
+		if len(object.ContentType) > 0 {
+			input.ContentType = aws.String(object.ContentType)
+		}
+
+		if len(object.Metadata) > 0 {
+			input.Metadata = aws.StringMap(object.Metadata)
+		}
+	}
+
+	input.ServerSideEncryption = optionalString(u.Options.ServerSideEncryption)
+	input.SSEKMSKeyId = optionalString(u.Options.KMSKeyId)
+	input.StorageClass = optionalString(u.Options.StorageClass)
+	input.Tagging = optionalString(u.Options.Tagging)
+
+	return Retry(u.MaxRetries, u.Backoff, func(attempt int) error {
+		input.Body = bytes.NewReader(data)
+
+		_, err := u.Client.PutObject(input)
+
+		return err
+	})
+}
+
+// start returns the upload id and, when resuming an upload, the parts
+// already uploaded.
+func (u *S3Uploader) start(key string, object *Object) (string, map[int64]*s3.Part, error) {
+	existing := map[int64]*s3.Part{}
+
+	if u.Resume {
+		uploadId, err := u.findUpload(key)
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		if len(uploadId) > 0 {
+			err := u.Client.ListPartsPages(&s3.ListPartsInput{
+				Bucket:   aws.String(u.Bucket),
+				Key:      aws.String(key),
+				UploadId: aws.String(uploadId),
+			}, func(page *s3.ListPartsOutput, last bool) bool {
+				for _, p := range page.Parts {
+					existing[aws.Int64Value(p.PartNumber)] = p
+				}
+
+				return true
+			})
+
+			if err == nil {
+				return uploadId, existing, nil
+			}
+		}
+	}
+
+	input := &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(u.Bucket),
+		Key:    aws.String(key),
+	}
+
+	if object != nil {
+		if len(object.ContentType) > 0 {
+			input.ContentType = aws.String(object.ContentType)
+		}
+
+		if len(object.Metadata) > 0 {
+			input.Metadata = aws.StringMap(object.Metadata)
+		}
+	}
+
+	input.ServerSideEncryption = optionalString(u.Options.ServerSideEncryption)
+	input.SSEKMSKeyId = optionalString(u.Options.KMSKeyId)
+	input.StorageClass = optionalString(u.Options.StorageClass)
+	input.Tagging = optionalString(u.Options.Tagging)
+
+	var output *s3.CreateMultipartUploadOutput
+
+	err := Retry(u.MaxRetries, u.Backoff, func(attempt int) (err error) {
+		output, err = u.Client.CreateMultipartUpload(input)
+
+		return err
+	})
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	uploadId := aws.StringValue(output.UploadId)
+
+	if u.Resume {
+		// the metadata of a pending upload cannot be read, the upload is
+		// referenced by an object storing the resume id
+		err = Retry(u.MaxRetries, u.Backoff, func(attempt int) error {
+			_, err := u.Client.PutObject(&s3.PutObjectInput{
+				Bucket: aws.String(u.Bucket),
+				Key:    aws.String(key + S3_UPLOAD_SUFFIX),
+				Body:   bytes.NewReader([]byte{}),
+				Metadata: aws.StringMap(map[string]string{
+					META_UPLOAD_ID: uploadId,
+					META_RESUME_ID: u.ResumeId,
+				}),
+			})
+
+			return err
+		})
+
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	return uploadId, existing, nil
+}
+
+// findUpload returns the pending upload started with the same resume id, the
+// uploads started by other jobs are ignored.
+func (u *S3Uploader) findUpload(key string) (string, error) {
+	output, err := u.Client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(u.Bucket),
+		Key:    aws.String(key + S3_UPLOAD_SUFFIX),
+	})
+
+	if isS3NotFound(err) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	marker := &Object{Metadata: aws.StringValueMap(output.Metadata)}
+
+	if marker.Meta(META_RESUME_ID) != u.ResumeId {
+		return "", nil
+	}
+
+	uploadId := marker.Meta(META_UPLOAD_ID)
+	found := false
+
+	err = u.Client.ListMultipartUploadsPages(&s3.ListMultipartUploadsInput{
+		Bucket: aws.String(u.Bucket),
+		Prefix: aws.String(key),
+	}, func(page *s3.ListMultipartUploadsOutput, last bool) bool {
+		for _, upload := range page.Uploads {
+			if aws.StringValue(upload.Key) == key && aws.StringValue(upload.UploadId) == uploadId {
+				found = true
+			}
+		}
+
+		return !found
+	})
+
+	if err != nil || !found {
+		return "", err
+	}
+
+	return uploadId, nil
+}
+
+func (u *S3Uploader) uploadPart(key, uploadId string, p *s3Part, existing map[int64]*s3.Part) (string, error) {
+	sum := md5.Sum(p.Data)
+
+	// the part has already been uploaded by a previous attempt
+	if e, ok := existing[p.Number]; ok {
+		if strings.Trim(aws.StringValue(e.ETag), "\"") == hex.EncodeToString(sum[:]) && aws.Int64Value(e.Size) == int64(len(p.Data)) {
+			return aws.StringValue(e.ETag), nil
+		}
+	}
+
+	var output *s3.UploadPartOutput
+
+	err := Retry(u.MaxRetries, u.Backoff, func(attempt int) (err error) {
+		output, err = u.Client.UploadPart(&s3.UploadPartInput{
+			Bucket:     aws.String(u.Bucket),
+			Key:        aws.String(key),
+			UploadId:   aws.String(uploadId),
+			PartNumber: aws.Int64(p.Number),
+			Body:       bytes.NewReader(p.Data),
+			ContentMD5: aws.String(base64.StdEncoding.EncodeToString(sum[:])),
+		})
+
+		return err
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("Unable to upload the part %d, %s", p.Number, err)
+	}
+
+	return aws.StringValue(output.ETag), nil
+}
+
+func (u *S3Uploader) complete(key, uploadId string, completed []*s3.CompletedPart) error {
+	sort.Slice(completed, func(i, j int) bool {
+		return aws.Int64Value(completed[i].PartNumber) < aws.Int64Value(completed[j].PartNumber)
+	})
+
+	return Retry(u.MaxRetries, u.Backoff, func(attempt int) error {
+		_, err := u.Client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+			Bucket:          aws.String(u.Bucket),
+			Key:             aws.String(key),
+			UploadId:        aws.String(uploadId),
+			MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+		})
+
+		return err
+	})
+}
+
+// readPart reads up to size bytes, last is true if the end of the body is reached
+func readPart(body io.Reader, size int64) ([]byte, bool, error) {
+	data := make([]byte, size)
+
+	n, err := io.ReadFull(body, data)
+
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return data[:n], true, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return data, false, nil
+}
+
+func optionalString(value string) *string {
+	if len(value) == 0 {
+		return nil
+	}
+
+	return aws.String(value)
+}
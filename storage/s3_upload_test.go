@@ -0,0 +1,319 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeS3MultipartClient struct {
+	s3iface.S3API
+
+	mutex     sync.Mutex
+	Failures  map[int64]int
+	Parts     map[int64][]byte
+	Uploaded  []int64
+	Completed []*s3.CompletedPart
+	Pending   [][]*s3.MultipartUpload
+	Markers   map[string]map[string]*string
+	Object    []byte
+	Input     *s3.PutObjectInput
+	Create    *s3.CreateMultipartUploadInput
+	Aborted   bool
+}
+
+func (f *fakeS3MultipartClient) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	if key := aws.StringValue(input.Key); strings.HasSuffix(key, S3_UPLOAD_SUFFIX) {
+		f.Markers[key] = input.Metadata
+
+		return &s3.PutObjectOutput{}, nil
+	}
+
+	f.Object, _ = ioutil.ReadAll(input.Body)
+	f.Input = input
+
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3MultipartClient) HeadObject(input *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+	metadata, ok := f.Markers[aws.StringValue(input.Key)]
+
+	if !ok {
+		return nil, awserr.New("NotFound", "Not Found", nil)
+	}
+
+	return &s3.HeadObjectOutput{Metadata: metadata}, nil
+}
+
+func (f *fakeS3MultipartClient) DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
+	delete(f.Markers, aws.StringValue(input.Key))
+
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (f *fakeS3MultipartClient) ListMultipartUploadsPages(input *s3.ListMultipartUploadsInput, fn func(*s3.ListMultipartUploadsOutput, bool) bool) error {
+	for i, uploads := range f.Pending {
+		if !fn(&s3.ListMultipartUploadsOutput{Uploads: uploads}, i == len(f.Pending)-1) {
+			break
+		}
+	}
+
+	return nil
+}
+
+func (f *fakeS3MultipartClient) ListPartsPages(input *s3.ListPartsInput, fn func(*s3.ListPartsOutput, bool) bool) error {
+	page := &s3.ListPartsOutput{}
+
+	for number, data := range f.Parts {
+		sum := md5.Sum(data)
+
+		page.Parts = append(page.Parts, &s3.Part{
+			PartNumber: aws.Int64(number),
+			ETag:       aws.String("\"" + hex.EncodeToString(sum[:]) + "\""),
+			Size:       aws.Int64(int64(len(data))),
+		})
+	}
+
+	fn(page, true)
+
+	return nil
+}
+
+func (f *fakeS3MultipartClient) CreateMultipartUpload(input *s3.CreateMultipartUploadInput) (*s3.CreateMultipartUploadOutput, error) {
+	f.Create = input
+
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-id")}, nil
+}
+
+func (f *fakeS3MultipartClient) UploadPart(input *s3.UploadPartInput) (*s3.UploadPartOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	number := aws.Int64Value(input.PartNumber)
+
+	if f.Failures[number] > 0 {
+		f.Failures[number]--
+
+		return nil, errors.New("network error")
+	}
+
+	data, _ := ioutil.ReadAll(input.Body)
+	sum := md5.Sum(data)
+
+	f.Parts[number] = data
+	f.Uploaded = append(f.Uploaded, number)
+
+	return &s3.UploadPartOutput{ETag: aws.String("\"" + hex.EncodeToString(sum[:]) + "\"")}, nil
+}
+
+func (f *fakeS3MultipartClient) CompleteMultipartUpload(input *s3.CompleteMultipartUploadInput) (*s3.CompleteMultipartUploadOutput, error) {
+	f.Completed = input.MultipartUpload.Parts
+
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (f *fakeS3MultipartClient) AbortMultipartUpload(input *s3.AbortMultipartUploadInput) (*s3.AbortMultipartUploadOutput, error) {
+	f.Aborted = true
+
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func newFakeS3MultipartClient() *fakeS3MultipartClient {
+	return &fakeS3MultipartClient{
+		Failures: map[int64]int{},
+		Parts:    map[int64][]byte{},
+		Markers:  map[string]map[string]*string{},
+	}
+}
+
+func Test_S3Uploader_Single_Part(t *testing.T) {
+	client := newFakeS3MultipartClient()
+	u := &S3Uploader{Client: client, Bucket: "bucket", PartSize: 10}
+
+	err := u.Upload("key", bytes.NewBufferString("hello"), nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(client.Object))
+	assert.Equal(t, 0, len(client.Completed))
+}
+
+func Test_S3Uploader_Multipart_With_Retries(t *testing.T) {
+	client := newFakeS3MultipartClient()
+	client.Failures[2] = 2
+
+	u := &S3Uploader{Client: client, Bucket: "bucket", PartSize: 4, Concurrency: 3, MaxRetries: 2}
+
+	err := u.Upload("key", bytes.NewBufferString("0123456789abcdef01"), nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, len(client.Completed))
+
+	body := []byte{}
+	for i, p := range client.Completed {
+		assert.Equal(t, int64(i+1), aws.Int64Value(p.PartNumber))
+		body = append(body, client.Parts[aws.Int64Value(p.PartNumber)]...)
+	}
+
+	assert.Equal(t, "0123456789abcdef01", string(body))
+}
+
+func Test_S3Uploader_Multipart_Failure(t *testing.T) {
+	client := newFakeS3MultipartClient()
+	client.Failures[2] = 10
+
+	u := &S3Uploader{Client: client, Bucket: "bucket", PartSize: 4, MaxRetries: 1}
+
+	err := u.Upload("key", bytes.NewBufferString("0123456789"), nil)
+
+	assert.Error(t, err)
+	assert.True(t, client.Aborted)
+
+	client = newFakeS3MultipartClient()
+	client.Failures[2] = 10
+
+	u = &S3Uploader{Client: client, Bucket: "bucket", PartSize: 4, MaxRetries: 1, Resume: true, ResumeId: "12-build"}
+
+	err = u.Upload("key", bytes.NewBufferString("0123456789"), nil)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "can be resumed")
+	assert.False(t, client.Aborted)
+	assert.Equal(t, "12-build", aws.StringValue(client.Markers["key"+S3_UPLOAD_SUFFIX][META_RESUME_ID]))
+
+	// the resume id is required to find the upload again
+	u = &S3Uploader{Client: client, Bucket: "bucket", PartSize: 4, Resume: true}
+
+	assert.Error(t, u.Upload("key", bytes.NewBufferString("0123456789"), nil))
+}
+
+func Test_S3Uploader_Resume(t *testing.T) {
+	client := newFakeS3MultipartClient()
+	client.Pending = [][]*s3.MultipartUpload{
+		{{Key: aws.String("key"), UploadId: aws.String("other-upload-id")}},
+		{{Key: aws.String("key"), UploadId: aws.String("previous-upload-id")}},
+	}
+	client.Markers["key"+S3_UPLOAD_SUFFIX] = aws.StringMap(map[string]string{
+		META_UPLOAD_ID: "previous-upload-id",
+		META_RESUME_ID: "12-build",
+	})
+	client.Parts[1] = []byte("0123")
+	client.Parts[2] = []byte("XXXX") // corrupted part, must be uploaded again
+
+	u := &S3Uploader{Client: client, Bucket: "bucket", PartSize: 4, Resume: true, ResumeId: "12-build"}
+
+	err := u.Upload("key", bytes.NewBufferString("0123456789"), nil)
+
+	assert.NoError(t, err)
+	assert.Nil(t, client.Create)
+	assert.Equal(t, []int64{2, 3}, client.Uploaded)
+	assert.Equal(t, 3, len(client.Completed))
+	assert.Empty(t, client.Markers)
+}
+
+func Test_S3Uploader_Resume_Other_Job(t *testing.T) {
+	client := newFakeS3MultipartClient()
+	client.Pending = [][]*s3.MultipartUpload{
+		{{Key: aws.String("key"), UploadId: aws.String("previous-upload-id")}},
+	}
+	client.Markers["key"+S3_UPLOAD_SUFFIX] = aws.StringMap(map[string]string{
+		META_UPLOAD_ID: "previous-upload-id",
+		META_RESUME_ID: "13-build",
+	})
+	client.Parts[1] = []byte("0123")
+
+	// the upload of a concurrent job is not taken over
+	u := &S3Uploader{Client: client, Bucket: "bucket", PartSize: 4, Resume: true, ResumeId: "12-build"}
+
+	err := u.Upload("key", bytes.NewBufferString("0123456789"), nil)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, client.Create)
+	assert.Equal(t, 3, len(client.Uploaded))
+}
+
+func Test_Retry(t *testing.T) {
+	count := 0
+
+	err := Retry(2, 0, func(attempt int) error {
+		count++
+
+		return errors.New("error")
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 3, count)
+
+	count = 0
+
+	err = Retry(2, 0, func(attempt int) error {
+		count++
+
+		if attempt < 2 {
+			return errors.New("error")
+		}
+
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func Test_S3Uploader_Options(t *testing.T) {
+	options := S3ObjectOptions{
+		ServerSideEncryption: "aws:kms",
+		KMSKeyId:             "key-id",
+		StorageClass:         "STANDARD_IA",
+		Tagging:              "team=backend",
+	}
+
+	client := newFakeS3MultipartClient()
+	u := &S3Uploader{Client: client, Bucket: "bucket", PartSize: 10, Options: options}
+
+	assert.NoError(t, u.Upload("key", bytes.NewBufferString("hello"), nil))
+	assert.Equal(t, "aws:kms", aws.StringValue(client.Input.ServerSideEncryption))
+	assert.Equal(t, "key-id", aws.StringValue(client.Input.SSEKMSKeyId))
+	assert.Equal(t, "STANDARD_IA", aws.StringValue(client.Input.StorageClass))
+	assert.Equal(t, "team=backend", aws.StringValue(client.Input.Tagging))
+
+	u.PartSize = 4
+	assert.NoError(t, u.Upload("key", bytes.NewBufferString("0123456789"), nil))
+	assert.Equal(t, "aws:kms", aws.StringValue(client.Create.ServerSideEncryption))
+	assert.Equal(t, "key-id", aws.StringValue(client.Create.SSEKMSKeyId))
+	assert.Equal(t, "STANDARD_IA", aws.StringValue(client.Create.StorageClass))
+	assert.Equal(t, "team=backend", aws.StringValue(client.Create.Tagging))
+
+	// the options are not set by default
+	client = newFakeS3MultipartClient()
+	u = &S3Uploader{Client: client, Bucket: "bucket", PartSize: 10}
+
+	assert.NoError(t, u.Upload("key", bytes.NewBufferString("hello"), nil))
+	assert.Nil(t, client.Input.ServerSideEncryption)
+	assert.Nil(t, client.Input.Tagging)
+}
+
+func Test_S3ObjectOptions_Validate(t *testing.T) {
+	assert.NoError(t, (&S3ObjectOptions{}).Validate())
+	assert.NoError(t, (&S3ObjectOptions{ServerSideEncryption: "AES256", StorageClass: "GLACIER"}).Validate())
+	assert.NoError(t, (&S3ObjectOptions{ServerSideEncryption: "aws:kms", KMSKeyId: "key"}).Validate())
+
+	assert.Error(t, (&S3ObjectOptions{ServerSideEncryption: "foo"}).Validate())
+	assert.Error(t, (&S3ObjectOptions{ServerSideEncryption: "AES256", KMSKeyId: "key"}).Validate())
+	assert.Error(t, (&S3ObjectOptions{StorageClass: "foo"}).Validate())
+}
@@ -0,0 +1,136 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	helper "github.com/rande/gitlab-ci-helper"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+type Object struct {
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	LastModified time.Time         `json:"last_modified"`
+	ContentType  string            `json:"content_type"`
+	Metadata     map[string]string `json:"metadata"`
+}
+
+// Meta returns the metadata value, the lookup is case insensitive as
+// the backends do not agree on the key format.
+func (o *Object) Meta(name string) string {
+	for k, v := range o.Metadata {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// Storage is the interface implemented by the archive backends, keys are
+// slash separated paths relative to the storage root.
+type Storage interface {
+	Put(key string, body io.Reader, object *Object) error
+	Get(key string) (io.ReadCloser, error)
+	List(prefix string) ([]*Object, error)
+	Delete(key string) error
+	Stat(key string) (*Object, error)
+}
+
+// BatchDeleter is implemented by the storages able to delete several objects
+// with one request.
+type BatchDeleter interface {
+	DeleteBatch(keys []string) error
+}
+
+// DeleteBatch deletes the keys with one request if the storage supports it,
+// the missing keys are ignored.
+func DeleteBatch(s Storage, keys []string) error {
+	if d, ok := s.(BatchDeleter); ok {
+		return d.DeleteBatch(keys)
+	}
+
+	for _, key := range keys {
+		if err := s.Delete(key); err != nil && err != ErrNotFound {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// New creates a storage from an url, supported formats are:
+//   - s3://bucket/prefix?region=eu-west-1&endpoint=http://minio:9000&profile=default
+//   - file:///mnt/cache
+//
+// The s3 settings default to the AWS_REGION, AWS_ENDPOINT and AWS_PROFILE env vars.
+func New(rawurl string) (Storage, error) {
+	u, err := url.Parse(rawurl)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid storage url: %s, %s", rawurl, err)
+	}
+
+	switch u.Scheme {
+	case "s3":
+		if len(u.Host) == 0 {
+			return nil, fmt.Errorf("Invalid storage url: %s, the bucket is missing", rawurl)
+		}
+
+		query := u.Query()
+
+		client, err := NewS3Client(
+			getQueryValue(query, "region", os.Getenv("AWS_REGION")),
+			getQueryValue(query, "endpoint", os.Getenv("AWS_ENDPOINT")),
+			getQueryValue(query, "profile", helper.GetEnv("AWS_PROFILE", "default")),
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &S3Storage{
+			Client: client,
+			Bucket: u.Host,
+			Prefix: strings.Trim(u.Path, "/"),
+		}, nil
+
+	case "file":
+		if len(u.Path) == 0 {
+			return nil, fmt.Errorf("Invalid storage url: %s, the path is missing", rawurl)
+		}
+
+		return &LocalStorage{Root: u.Path}, nil
+	}
+
+	return nil, fmt.Errorf("Invalid storage url: %s, unsupported scheme %s", rawurl, u.Scheme)
+}
+
+func getQueryValue(query url.Values, name, deflt string) string {
+	if v := query.Get(name); len(v) > 0 {
+		return v
+	}
+
+	return deflt
+}
+
+// join concatenates a prefix and a key
+func join(prefix, key string) string {
+	if len(prefix) == 0 {
+		return key
+	}
+
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(prefix, "/"), strings.TrimPrefix(key, "/"))
+}
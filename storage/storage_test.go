@@ -0,0 +1,49 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New_Local(t *testing.T) {
+	s, err := New("file:///mnt/cache")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &LocalStorage{Root: "/mnt/cache"}, s)
+}
+
+func Test_New_Invalid(t *testing.T) {
+	values := []string{
+		"",
+		"ftp://host/path",
+		"s3:///prefix",
+		"file://",
+		"%zz",
+	}
+
+	for _, v := range values {
+		_, err := New(v)
+
+		assert.Error(t, err, v)
+	}
+}
+
+func Test_Join(t *testing.T) {
+	assert.Equal(t, "key", join("", "key"))
+	assert.Equal(t, "prefix/key", join("prefix", "key"))
+	assert.Equal(t, "prefix/key", join("prefix/", "/key"))
+}
+
+func Test_Object_Meta(t *testing.T) {
+	o := &Object{Metadata: map[string]string{"Ref-Name": "master"}}
+
+	assert.Equal(t, "master", o.Meta("ref-name"))
+	assert.Equal(t, "master", o.Meta("Ref-Name"))
+	assert.Equal(t, "", o.Meta("Job"))
+}
@@ -0,0 +1,422 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// TarStream writes a compressed tar archive into w. Unlike the zip format,
+// the modes, the symlinks, the modification times and the ownership are
+// preserved.
+func TarStream(includePaths Paths, excludes *Matcher, w io.Writer, format string, manifest *Manifest) error {
+	files, err := listFiles(includePaths, excludes)
+
+	if err != nil {
+		return err
+	}
+
+	cw, err := newCompressWriter(w, format)
+
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(cw)
+
+	for _, path := range files {
+		if err := addTarFile(tw, path, manifest); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return cw.Close()
+}
+
+func addTarFile(tw *tar.Writer, path string, manifest *Manifest) error {
+	name := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "/")
+
+	if name == "." {
+		return nil
+	}
+
+	info, err := os.Lstat(path)
+
+	if err != nil {
+		return err
+	}
+
+	link := ""
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	case !info.IsDir() && !info.Mode().IsRegular():
+		// devices, sockets and pipes are not archived
+		return nil
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+
+	if err != nil {
+		return err
+	}
+
+	header.Name = name
+
+	if info.IsDir() {
+		header.Name += "/"
+	}
+
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	fp, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer fp.Close()
+
+	if manifest == nil {
+		_, err = io.Copy(tw, fp)
+
+		return err
+	}
+
+	h := sha256.New()
+
+	size, err := io.Copy(io.MultiWriter(tw, h), fp)
+
+	manifest.Files = append(manifest.Files, &ManifestFile{
+		Name:   header.Name,
+		Size:   size,
+		Sha256: hex.EncodeToString(h.Sum(nil)),
+	})
+
+	return err
+}
+
+// Untar extracts the compressed tar archive into the target path. The
+// ownership is only restored when running as root.
+func Untar(archive, target, format string) error {
+	fp, err := os.Open(archive)
+
+	if err != nil {
+		return err
+	}
+
+	defer fp.Close()
+
+	r, err := newDecompressReader(fp, format)
+
+	if err != nil {
+		return err
+	}
+
+	defer r.Close()
+
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return err
+	}
+
+	// the symlinks are resolved to check the entries are written inside the
+	// target path
+	root, err := filepath.EvalSymlinks(target)
+
+	if err != nil {
+		return err
+	}
+
+	type dirInfo struct {
+		path  string
+		mode  os.FileMode
+		mtime time.Time
+	}
+
+	dirs := []dirInfo{}
+	tr := tar.NewReader(r)
+
+	for {
+		header, err := tr.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		path, err := tarTargetPath(target, header.Name)
+
+		if err != nil {
+			return err
+		}
+
+		// a directory is created in place, the other entries in their parent
+		parent := filepath.Dir(path)
+		if header.Typeflag == tar.TypeDir {
+			parent = path
+		}
+
+		if err := tarCheckPath(target, root, parent); err != nil {
+			return err
+		}
+
+		mode := header.FileInfo().Mode()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+
+			// the mode and the modification time are restored once the
+			// directory content is extracted
+			dirs = append(dirs, dirInfo{path, mode.Perm(), header.ModTime})
+
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+
+			if err := extractTarFile(tr, path, mode); err != nil {
+				return err
+			}
+
+			if err := os.Chtimes(path, header.ModTime, header.ModTime); err != nil {
+				return err
+			}
+
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+
+			os.Remove(path)
+
+			if err := os.Symlink(header.Linkname, path); err != nil {
+				return err
+			}
+
+		case tar.TypeLink:
+			source, err := tarTargetPath(target, header.Linkname)
+
+			if err != nil {
+				return err
+			}
+
+			if err := tarCheckPath(target, root, filepath.Dir(source)); err != nil {
+				return err
+			}
+
+			os.Remove(path)
+
+			if err := os.Link(source, path); err != nil {
+				return err
+			}
+
+		default:
+			continue
+		}
+
+		if os.Geteuid() == 0 {
+			os.Lchown(path, header.Uid, header.Gid)
+		}
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i].path, dirs[i].mode); err != nil {
+			return err
+		}
+
+		os.Chtimes(dirs[i].path, dirs[i].mtime, dirs[i].mtime)
+	}
+
+	return nil
+}
+
+func extractTarFile(r io.Reader, path string, mode os.FileMode) error {
+	// the file is removed, so a symlink with the same name is not followed
+	os.Remove(path)
+
+	fp, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm())
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(fp, r); err != nil {
+		fp.Close()
+
+		return err
+	}
+
+	if err := fp.Close(); err != nil {
+		return err
+	}
+
+	// the mode provided to OpenFile is altered by the umask
+	return os.Chmod(path, mode.Perm())
+}
+
+// tarTargetPath returns the path of the entry, entries outside the target
+// path are rejected.
+func tarTargetPath(target, name string) (string, error) {
+	name = filepath.Clean(filepath.FromSlash(name))
+
+	if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("Invalid path in the archive: %s", name)
+	}
+
+	return filepath.Join(target, name), nil
+}
+
+// tarCheckPath rejects the path if it is resolved outside the target path
+// through a symlink, ie: a lib -> /etc entry followed by lib/passwd. The
+// deepest existing directory is resolved as the missing ones are created
+// by the extraction.
+func tarCheckPath(target, root, path string) error {
+	existing := path
+
+	for existing != filepath.Clean(target) {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+
+		existing = filepath.Dir(existing)
+	}
+
+	resolved, err := filepath.EvalSymlinks(existing)
+
+	if err != nil {
+		return fmt.Errorf("Invalid path in the archive: %s, %s", path, err)
+	}
+
+	if resolved != root && !strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+		return fmt.Errorf("Invalid path in the archive: %s is outside the target", path)
+	}
+
+	return nil
+}
+
+// NewTarManifest computes the checksum of the archive and of every file
+// stored in it.
+func NewTarManifest(archive, format string) (*Manifest, error) {
+	sum, size, err := Sha256File(archive)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fp, err := os.Open(archive)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer fp.Close()
+
+	r, err := newDecompressReader(fp, format)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+
+	manifest := &Manifest{
+		Sha256: sum,
+		Size:   size,
+		Files:  []*ManifestFile{},
+	}
+
+	tr := tar.NewReader(r)
+
+	for {
+		header, err := tr.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		h := sha256.New()
+
+		size, err := io.Copy(h, tr)
+
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read %s, %s", header.Name, err)
+		}
+
+		manifest.Files = append(manifest.Files, &ManifestFile{
+			Name:   header.Name,
+			Size:   size,
+			Sha256: hex.EncodeToString(h.Sum(nil)),
+		})
+	}
+
+	return manifest, nil
+}
+
+func newCompressWriter(w io.Writer, format string) (io.WriteCloser, error) {
+	switch format {
+	case FORMAT_TAR_GZ:
+		return gzip.NewWriter(w), nil
+	case FORMAT_TAR_ZST:
+		return zstd.NewWriter(w)
+	}
+
+	return nil, fmt.Errorf("Invalid tar format: %s", format)
+}
+
+func newDecompressReader(r io.Reader, format string) (io.ReadCloser, error) {
+	switch format {
+	case FORMAT_TAR_GZ:
+		return gzip.NewReader(r)
+	case FORMAT_TAR_ZST:
+		d, err := zstd.NewReader(r)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return d.IOReadCloser(), nil
+	}
+
+	return nil, fmt.Errorf("Invalid tar format: %s", format)
+}
@@ -0,0 +1,165 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Tar_RoundTrip(t *testing.T) {
+	for _, format := range []string{FORMAT_TAR_GZ, FORMAT_TAR_ZST} {
+		source, err := ioutil.TempDir("", "gitlab_ci_helper_tar")
+		assert.NoError(t, err)
+
+		defer os.RemoveAll(source)
+
+		target, err := ioutil.TempDir("", "gitlab_ci_helper_untar")
+		assert.NoError(t, err)
+
+		defer os.RemoveAll(target)
+
+		mtime := time.Date(2017, 5, 1, 10, 0, 0, 0, time.UTC)
+
+		os.MkdirAll(source+"/cache/bin", 0755)
+		ioutil.WriteFile(source+"/cache/bin/tool", []byte("#!/bin/sh"), 0755)
+		ioutil.WriteFile(source+"/cache/data.txt", []byte("data"), 0600)
+		os.Chtimes(source+"/cache/data.txt", mtime, mtime)
+		os.Symlink("bin/tool", source+"/cache/tool")
+
+		// the relative paths are stored in the archive
+		cwd, _ := os.Getwd()
+		os.Chdir(source)
+
+		includePath := make(Paths, 0)
+		includePath.Set("cache")
+
+		buf := bytes.NewBuffer([]byte(""))
+		manifest := &Manifest{}
+
+		err = TarStream(includePath, nil, buf, format, manifest)
+		os.Chdir(cwd)
+
+		assert.NoError(t, err)
+		assert.Len(t, manifest.Files, 2)
+
+		archive := target + "/archive." + format
+		ioutil.WriteFile(archive, buf.Bytes(), 0644)
+
+		detected, err := DetectArchiveFormat(archive)
+		assert.NoError(t, err)
+		assert.Equal(t, format, detected)
+
+		actual, err := NewArchiveManifest(archive)
+		assert.NoError(t, err)
+		assert.NoError(t, actual.Verify(manifest))
+
+		assert.NoError(t, ExtractArchive(archive, target))
+
+		info, err := os.Stat(target + "/cache/bin/tool")
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+		info, err = os.Stat(target + "/cache/data.txt")
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		assert.True(t, mtime.Equal(info.ModTime()))
+
+		link, err := os.Readlink(target + "/cache/tool")
+		assert.NoError(t, err)
+		assert.Equal(t, "bin/tool", link)
+	}
+}
+
+func Test_Untar_Invalid_Path(t *testing.T) {
+	target, err := ioutil.TempDir("", "gitlab_ci_helper_untar")
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(target)
+
+	buf := bytes.NewBuffer([]byte(""))
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	tw.WriteHeader(&tar.Header{Name: "../evil.txt", Mode: 0644, Size: 4, Typeflag: tar.TypeReg})
+	tw.Write([]byte("evil"))
+	tw.Close()
+	gw.Close()
+
+	archive := filepath.Join(target, "archive.tar.gz")
+	ioutil.WriteFile(archive, buf.Bytes(), 0644)
+
+	err = ExtractArchive(archive, filepath.Join(target, "extract"))
+	assert.Error(t, err)
+
+	_, err = os.Stat(filepath.Join(target, "evil.txt"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_DetectArchiveFormat(t *testing.T) {
+	format, err := DetectArchiveFormat("fixtures/artifacts.zip")
+	assert.NoError(t, err)
+	assert.Equal(t, FORMAT_ZIP, format)
+
+	_, err = DetectArchiveFormat("README.md")
+	assert.Error(t, err)
+
+	assert.Error(t, ArchiveStream("rar", make(Paths, 0), nil, bytes.NewBuffer(nil), nil))
+}
+
+func Test_Untar_Symlink_Escape(t *testing.T) {
+	target, err := ioutil.TempDir("", "gitlab_ci_helper_untar")
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(target)
+
+	outside := filepath.Join(target, "outside")
+	assert.NoError(t, os.Mkdir(outside, 0755))
+
+	buf := bytes.NewBuffer([]byte(""))
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	tw.WriteHeader(&tar.Header{Name: "lib", Linkname: outside, Mode: 0777, Typeflag: tar.TypeSymlink})
+	tw.WriteHeader(&tar.Header{Name: "lib/passwd", Mode: 0644, Size: 4, Typeflag: tar.TypeReg})
+	tw.Write([]byte("evil"))
+	tw.Close()
+	gw.Close()
+
+	archive := filepath.Join(target, "archive.tar.gz")
+	ioutil.WriteFile(archive, buf.Bytes(), 0644)
+
+	err = ExtractArchive(archive, filepath.Join(target, "extract"))
+	assert.Error(t, err)
+
+	_, err = os.Stat(filepath.Join(outside, "passwd"))
+	assert.True(t, os.IsNotExist(err))
+
+	// the symlinks inside the target are followed
+	buf = bytes.NewBuffer([]byte(""))
+	gw = gzip.NewWriter(buf)
+	tw = tar.NewWriter(gw)
+	tw.WriteHeader(&tar.Header{Name: "vendor/", Mode: 0755, Typeflag: tar.TypeDir})
+	tw.WriteHeader(&tar.Header{Name: "lib", Linkname: "vendor", Mode: 0777, Typeflag: tar.TypeSymlink})
+	tw.WriteHeader(&tar.Header{Name: "lib/file.txt", Mode: 0644, Size: 4, Typeflag: tar.TypeReg})
+	tw.Write([]byte("file"))
+	tw.Close()
+	gw.Close()
+
+	ioutil.WriteFile(archive, buf.Bytes(), 0644)
+
+	assert.NoError(t, ExtractArchive(archive, filepath.Join(target, "valid")))
+
+	data, err := ioutil.ReadFile(filepath.Join(target, "valid", "vendor", "file.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "file", string(data))
+}
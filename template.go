@@ -0,0 +1,59 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"bytes"
+	"encoding/json"
+	htmltemplate "html/template"
+	"os"
+	"text/template"
+)
+
+// TemplateFuncs contains the functions available in all the templates.
+var TemplateFuncs = map[string]interface{}{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+
+		return string(data), err
+	},
+	"env": os.Getenv,
+}
+
+// Render executes a text template, the extra functions are added to the
+// default ones.
+func Render(tpl string, data interface{}, extra map[string]interface{}) (string, error) {
+	t, err := template.New("template").Funcs(TemplateFuncs).Funcs(extra).Parse(tpl)
+
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.NewBuffer([]byte(""))
+
+	if err := t.Execute(buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// RenderHtml executes a html template, the values are escaped.
+func RenderHtml(tpl string, data interface{}, extra map[string]interface{}) (string, error) {
+	t, err := htmltemplate.New("template").Funcs(TemplateFuncs).Funcs(extra).Parse(tpl)
+
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.NewBuffer([]byte(""))
+
+	if err := t.Execute(buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
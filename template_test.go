@@ -0,0 +1,34 @@
+// Copyright © 2016-present Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gitlab_ci_helper
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Render(t *testing.T) {
+	os.Setenv("CI_ENVIRONMENT_NAME", "prod")
+	defer os.Unsetenv("CI_ENVIRONMENT_NAME")
+
+	out, err := Render(`{{ json .Name }} {{ env "CI_ENVIRONMENT_NAME" }} {{ upper .Name }}`, map[string]string{"Name": "build"}, map[string]interface{}{
+		"upper": strings.ToUpper,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `"build" prod BUILD`, out)
+
+	out, err = RenderHtml(`<b>{{ .Name }}</b>`, map[string]string{"Name": "<build>"}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "<b>&lt;build&gt;</b>", out)
+
+	_, err = Render(`{{ .Foo`, nil, nil)
+	assert.Error(t, err)
+}
@@ -6,12 +6,17 @@
 package gitlab_ci_helper
 
 import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
+	"github.com/bmatcuk/doublestar"
 	"github.com/rande/garchive"
 )
 
@@ -19,18 +24,120 @@ func Unzip(archive, target string) error {
 	return garchive.ExtractZipFile(archive, target)
 }
 
-func Zip(includePaths, excludePaths Paths, target string) error {
-	var excludes []*regexp.Regexp
-	for _, path := range excludePaths {
-		excludes = append(excludes, regexp.MustCompile(path))
+func Zip(includePaths Paths, excludes *Matcher, target string) error {
+	files, err := listFiles(includePaths, excludes)
+
+	if err != nil {
+		return err
+	}
+
+	return garchive.CreateZipFile(target, files)
+}
+
+// ZipStream writes the zip archive into w, so the archive can be sent
+// to a remote storage without being stored on the local filesystem. If
+// a manifest is provided, the checksum of each file is added to it.
+func ZipStream(includePaths Paths, excludes *Matcher, w io.Writer, manifest *Manifest) error {
+	files, err := listFiles(includePaths, excludes)
+
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+
+	for _, path := range files {
+		if err := addZipFile(zw, path, manifest); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func addZipFile(zw *zip.Writer, path string, manifest *Manifest) error {
+	name := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "/")
+
+	if name == "." {
+		return nil
+	}
+
+	info, err := os.Lstat(path)
+
+	if err != nil {
+		return err
+	}
+
+	// only regular files and directories are supported by the zip format
+	if !info.IsDir() && !info.Mode().IsRegular() {
+		return nil
+	}
+
+	header, err := zip.FileInfoHeader(info)
+
+	if err != nil {
+		return err
+	}
+
+	header.Name = name
+
+	if info.IsDir() {
+		header.Name += "/"
+
+		_, err := zw.CreateHeader(header)
+
+		return err
 	}
 
+	header.Method = zip.Deflate
+
+	fw, err := zw.CreateHeader(header)
+
+	if err != nil {
+		return err
+	}
+
+	fp, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer fp.Close()
+
+	if manifest == nil {
+		_, err = io.Copy(fw, fp)
+
+		return err
+	}
+
+	h := sha256.New()
+
+	size, err := io.Copy(io.MultiWriter(fw, h), fp)
+
+	manifest.Files = append(manifest.Files, &ManifestFile{
+		Name:   header.Name,
+		Size:   size,
+		Sha256: hex.EncodeToString(h.Sum(nil)),
+	})
+
+	return err
+}
+
+// listFiles walks the include paths, an include path can be a glob pattern.
+func listFiles(includePaths Paths, excludes *Matcher) ([]string, error) {
 	files := []string{}
 
-	for _, source := range includePaths {
+	sources, err := expandPaths(includePaths)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, source := range sources {
 		info, err := os.Stat(source)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 
 		var baseDir string
@@ -38,15 +145,17 @@ func Zip(includePaths, excludePaths Paths, target string) error {
 			baseDir = source
 		}
 
-		filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
 			if err != nil {
 				return err
 			}
 
-			for _, exclude := range excludes {
-				if exclude.Match([]byte(path)) {
-					return nil
+			if excludes.Excluded(path, info.IsDir()) {
+				if info.IsDir() && excludes.Prune(path) {
+					return filepath.SkipDir
 				}
+
+				return nil
 			}
 
 			if baseDir != "" {
@@ -55,13 +164,43 @@ func Zip(includePaths, excludePaths Paths, target string) error {
 
 			files = append(files, path)
 
-			return err
+			return nil
 		})
+
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if len(files) == 0 {
-		return errors.New("No file to zip")
+		return nil, errors.New("No file to zip")
 	}
 
-	return garchive.CreateZipFile(target, files)
+	return files, nil
+}
+
+func expandPaths(paths Paths) ([]string, error) {
+	expanded := []string{}
+
+	for _, path := range paths {
+		if !strings.ContainsAny(path, "*?[{") {
+			expanded = append(expanded, path)
+
+			continue
+		}
+
+		matches, err := doublestar.Glob(path)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid include pattern: %s, %s", path, err)
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("No file matches the include pattern: %s", path)
+		}
+
+		expanded = append(expanded, matches...)
+	}
+
+	return expanded, nil
 }
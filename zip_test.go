@@ -7,6 +7,7 @@ package gitlab_ci_helper
 
 import (
 	"archive/zip"
+	"bytes"
 	"fmt"
 	"os"
 	"testing"
@@ -20,10 +21,10 @@ func Test_Zip_No_Error(t *testing.T) {
 	includePath := make(Paths, 0)
 	includePath.Set("README.md")
 
-	excludePath := make(Paths, 0)
-	excludePath.Set(".git")
+	excludes, _ := NewMatcher(MATCH_GLOB)
+	excludes.Add(".git")
 
-	err := Zip(includePath, excludePath, targetPath)
+	err := Zip(includePath, excludes, targetPath)
 	assert.NoError(t, err)
 
 	r, err := zip.OpenReader(targetPath)
@@ -60,9 +61,7 @@ func Test_Zip_File_Mode(t *testing.T) {
 	includePath := make(Paths, 0)
 	includePath.Set(binPath)
 
-	excludePath := make(Paths, 0)
-
-	err = Zip(includePath, excludePath, targetPath)
+	err = Zip(includePath, nil, targetPath)
 	assert.NoError(t, err)
 
 	r, err := zip.OpenReader(targetPath)
@@ -93,3 +92,35 @@ func Test_Zip_File_Mode(t *testing.T) {
 	os.Remove(binPath)
 	os.Remove(targetPath)
 }
+
+func Test_ZipStream(t *testing.T) {
+	includePath := make(Paths, 0)
+	includePath.Set("README.md")
+	includePath.Set("zip.go")
+
+	buf := bytes.NewBuffer([]byte(""))
+
+	err := ZipStream(includePath, nil, buf, nil)
+	assert.NoError(t, err)
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	names := []string{}
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+
+	assert.Equal(t, []string{"README.md", "zip.go"}, names)
+}
+
+func Test_ZipStream_No_File(t *testing.T) {
+	includePath := make(Paths, 0)
+	includePath.Set("README.md")
+
+	excludes, _ := NewMatcher(MATCH_REGEXP)
+	excludes.Add("README")
+
+	err := ZipStream(includePath, excludes, bytes.NewBuffer([]byte("")), nil)
+	assert.Error(t, err)
+}